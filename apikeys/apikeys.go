@@ -0,0 +1,93 @@
+package apikeys
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/tirathawat/assessment/errs"
+	"github.com/tirathawat/assessment/users"
+)
+
+// Header carries an API key, e.g. "X-API-Key: exp_...".
+const Header = "X-API-Key"
+
+const (
+	// keyPrefix makes keys easy to recognise, e.g. by secret scanners.
+	keyPrefix = "exp_"
+	keyBytes  = 32
+
+	// displayLength is how much of a key is kept in clear so users can
+	// tell their keys apart.
+	displayLength = len(keyPrefix) + 8
+)
+
+var (
+	ErrInvalidKey   = errs.New(http.StatusUnauthorized, "INVALID_API_KEY", "invalid API key")
+	ErrLookupFailed = errs.New(http.StatusInternalServerError, "API_KEY_LOOKUP_FAILED", "failed to look up API key")
+	ErrKeyNotFound  = errs.New(http.StatusNotFound, "API_KEY_NOT_FOUND", "API key not found")
+	ErrInvalidID    = errs.New(http.StatusBadRequest, "INVALID_ID", "invalid id")
+	ErrCreateFailed = errs.New(http.StatusInternalServerError, "API_KEY_CREATE_FAILED", "failed to create API key")
+	ErrListFailed   = errs.New(http.StatusInternalServerError, "API_KEY_LIST_FAILED", "failed to list API keys")
+	ErrRevokeFailed = errs.New(http.StatusInternalServerError, "API_KEY_REVOKE_FAILED", "failed to revoke API key")
+	ErrScopeDenied  = errs.New(http.StatusForbidden, "API_KEY_SCOPE_DENIED", "cannot create an API key with scopes you were not granted")
+	ErrAdminByKey   = errs.New(http.StatusForbidden, "API_KEY_ADMIN_BY_KEY", "an API key cannot create API keys with the admin scope")
+)
+
+// Key is an API key of a user. Only the SHA-256 hash of the key is stored,
+// the key itself is shown once when it is created.
+type Key struct {
+	ID        int            `gorm:"primary_key" json:"id"`
+	OwnerID   int            `gorm:"not null;index" json:"-"`
+	Name      string         `gorm:"type:text;not null" json:"name"`
+	Prefix    string         `gorm:"type:text;not null" json:"prefix"`
+	Hash      string         `gorm:"type:char(64);not null;uniqueIndex" json:"-"`
+	Scopes    pq.StringArray `gorm:"type:text[];not null" json:"scopes"`
+	CreatedAt time.Time      `gorm:"not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+	RevokedAt *time.Time     `json:"revoked_at,omitempty"`
+
+	Owner users.User `gorm:"foreignKey:OwnerID" json:"-"`
+}
+
+func (Key) TableName() string {
+	return "api_keys"
+}
+
+type CreateRequestBody struct {
+	Name   string   `json:"name" binding:"required,max=100"`
+	Scopes []string `json:"scopes" binding:"required,min=1,dive,oneof=expenses:read expenses:write expenses:admin"`
+}
+
+// CreatedKey is returned once, when the key is created.
+type CreatedKey struct {
+	Key
+	Secret string `json:"key"`
+}
+
+// generate returns a new random key.
+func generate() (string, error) {
+	b := make([]byte, keyBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return keyPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// actor names the key in audit records. It uses the id rather than the
+// name, which is not unique.
+func actor(key Key) string {
+	return fmt.Sprintf("api-key:%d", key.ID)
+}
+
+// hash is the value stored for a key. Keys are random, so a fast hash is
+// enough to make a leaked table useless.
+func hash(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,137 @@
+package apikeys
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tirathawat/assessment/errs"
+	"github.com/tirathawat/assessment/logs"
+	"github.com/tirathawat/assessment/middleware"
+	"github.com/tirathawat/assessment/users"
+)
+
+type Handler interface {
+	Create(c *gin.Context)
+	List(c *gin.Context)
+	Revoke(c *gin.Context)
+}
+
+type handler struct {
+	store Store
+}
+
+func NewHandler(store Store) Handler {
+	return &handler{store}
+}
+
+func (h *handler) Create(c *gin.Context) {
+	owner, ok := users.ID(c)
+	if !ok {
+		logs.Error().Context(c.Request.Context()).Msg("no authenticated user")
+		errs.JSON(c, http.StatusUnauthorized, middleware.ErrUnauthorized)
+		return
+	}
+
+	var body CreateRequestBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msg("failed to bind request body")
+		errs.JSON(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := checkScopes(c, body.Scopes); err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("API key scopes refused: %v", body.Scopes)
+		errs.JSON(c, http.StatusForbidden, err)
+		return
+	}
+
+	secret, err := generate()
+	if err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msg("failed to generate API key")
+		errs.JSON(c, http.StatusInternalServerError, ErrCreateFailed)
+		return
+	}
+
+	key := Key{
+		OwnerID: owner,
+		Name:    body.Name,
+		Prefix:  secret[:displayLength],
+		Hash:    hash(secret),
+		Scopes:  body.Scopes,
+	}
+	if err := h.store.Create(&key); err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("failed to create API key: %s", body.Name)
+		errs.JSON(c, http.StatusInternalServerError, ErrCreateFailed)
+		return
+	}
+
+	c.JSON(http.StatusCreated, CreatedKey{Key: key, Secret: secret})
+}
+
+func (h *handler) List(c *gin.Context) {
+	owner, ok := users.ID(c)
+	if !ok {
+		logs.Error().Context(c.Request.Context()).Msg("no authenticated user")
+		errs.JSON(c, http.StatusUnauthorized, middleware.ErrUnauthorized)
+		return
+	}
+
+	keys, err := h.store.List(owner)
+	if err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msg("failed to list API keys")
+		errs.JSON(c, http.StatusInternalServerError, ErrListFailed)
+		return
+	}
+
+	c.JSON(http.StatusOK, keys)
+}
+
+func (h *handler) Revoke(c *gin.Context) {
+	owner, ok := users.ID(c)
+	if !ok {
+		logs.Error().Context(c.Request.Context()).Msg("no authenticated user")
+		errs.JSON(c, http.StatusUnauthorized, middleware.ErrUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("invalid id: %s", c.Param("id"))
+		errs.JSON(c, http.StatusBadRequest, ErrInvalidID)
+		return
+	}
+
+	revoked, err := h.store.Revoke(owner, id, time.Now())
+	if err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("failed to revoke API key: %d", id)
+		errs.JSON(c, http.StatusInternalServerError, ErrRevokeFailed)
+		return
+	}
+
+	if !revoked {
+		logs.Error().Context(c.Request.Context()).Msgf("API key not found: %d", id)
+		errs.JSON(c, http.StatusNotFound, ErrKeyNotFound)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// checkScopes refuses a key that would grant more than the caller has. A
+// caller using an API key cannot create admin keys at all, so a leaked key
+// cannot be used to mint keys that outlive its own revocation.
+func checkScopes(c *gin.Context, scopes []string) error {
+	granted := c.GetStringSlice(middleware.ScopesKey)
+	for _, scope := range scopes {
+		if !middleware.Granted(granted, scope) {
+			return ErrScopeDenied
+		}
+		if scope == middleware.ScopeAdmin && c.GetString(middleware.ActorKey) != "" {
+			return ErrAdminByKey
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,253 @@
+//go:build unit
+// +build unit
+
+package apikeys_test
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tirathawat/assessment/apikeys"
+	"github.com/tirathawat/assessment/middleware"
+	"github.com/tirathawat/assessment/testutils"
+	"github.com/tirathawat/assessment/users"
+	"gorm.io/gorm"
+)
+
+const ownerID = 7
+
+type MockStore struct {
+	keys    []apikeys.Key
+	err     error
+	created *apikeys.Key
+	revoked bool
+}
+
+func (m *MockStore) Create(key *apikeys.Key) error {
+	key.ID = 1
+	m.created = key
+	return m.err
+}
+
+func (m *MockStore) List(owner int) ([]apikeys.Key, error) {
+	return m.keys, m.err
+}
+
+func (m *MockStore) Revoke(owner, id int, now time.Time) (bool, error) {
+	return m.revoked, m.err
+}
+
+func (m *MockStore) Find(hash string) (apikeys.Key, error) {
+	if m.err != nil {
+		return apikeys.Key{}, m.err
+	}
+
+	for _, key := range m.keys {
+		if key.Hash == hash {
+			return key, nil
+		}
+	}
+
+	return apikeys.Key{}, gorm.ErrRecordNotFound
+}
+
+func asUser(handler gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(users.IDKey, ownerID)
+		handler(c)
+	}
+}
+
+func TestCreate(t *testing.T) {
+	admin := []string{middleware.ScopeRead, middleware.ScopeWrite, middleware.ScopeAdmin}
+
+	tests := []struct {
+		name           string
+		store          *MockStore
+		scopes         []string
+		actor          string
+		body           string
+		wantStatusCode int
+	}{
+		{
+			name:           "Should return 201 with the key when create successfully",
+			store:          &MockStore{},
+			body:           `{"name": "reports", "scopes": ["expenses:read"]}`,
+			wantStatusCode: http.StatusCreated,
+		},
+		{
+			name:           "Should return 201 when an admin creates an admin key",
+			store:          &MockStore{},
+			body:           `{"name": "ops", "scopes": ["expenses:admin"]}`,
+			wantStatusCode: http.StatusCreated,
+		},
+		{
+			name:           "Should return 403 when a scope was not granted to the caller",
+			store:          &MockStore{},
+			scopes:         []string{middleware.ScopeRead},
+			body:           `{"name": "reports", "scopes": ["expenses:write"]}`,
+			wantStatusCode: http.StatusForbidden,
+		},
+		{
+			name:           "Should return 403 when an API key creates an admin key",
+			store:          &MockStore{},
+			actor:          "api-key:1",
+			body:           `{"name": "ops", "scopes": ["expenses:read", "expenses:admin"]}`,
+			wantStatusCode: http.StatusForbidden,
+		},
+		{
+			name:           "Should return 201 when an API key creates a key with fewer scopes",
+			store:          &MockStore{},
+			actor:          "api-key:1",
+			body:           `{"name": "reports", "scopes": ["expenses:read"]}`,
+			wantStatusCode: http.StatusCreated,
+		},
+		{
+			name:           "Should return 400 when a scope is unknown",
+			store:          &MockStore{},
+			body:           `{"name": "reports", "scopes": ["expenses:delete"]}`,
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:           "Should return 400 when no scope is given",
+			store:          &MockStore{},
+			body:           `{"name": "reports", "scopes": []}`,
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:           "Should return 500 when store error",
+			store:          &MockStore{err: errors.New("error")},
+			body:           `{"name": "reports", "scopes": ["expenses:read"]}`,
+			wantStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			httpRequest := &testutils.HTTPRequest{
+				Method:   http.MethodPost,
+				Endpoint: "api-keys",
+				Body:     test.body,
+			}
+
+			var created struct {
+				apikeys.Key
+				Secret string `json:"key"`
+			}
+			scopes := test.scopes
+			if scopes == nil {
+				scopes = admin
+			}
+			handler := func(c *gin.Context) {
+				c.Set(middleware.ScopesKey, scopes)
+				if test.actor != "" {
+					c.Set(middleware.ActorKey, test.actor)
+				}
+				apikeys.NewHandler(test.store).Create(c)
+			}
+
+			statusCode, err := httpRequest.MakeTestHTTPRequest(asUser(handler), &created)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if statusCode != test.wantStatusCode {
+				t.Errorf("unexpected status code: got %v want %v", statusCode, test.wantStatusCode)
+			}
+
+			if statusCode != http.StatusCreated {
+				if statusCode == http.StatusForbidden && test.store.created != nil {
+					t.Errorf("unexpected key stored: got %+v", test.store.created)
+				}
+				return
+			}
+
+			stored := test.store.created
+			if stored.OwnerID != ownerID || !strings.HasPrefix(created.Secret, stored.Prefix) || strings.Contains(stored.Hash, created.Secret) {
+				t.Errorf("unexpected key stored: got %+v for %s", stored, created.Secret)
+			}
+		})
+	}
+}
+
+func TestList(t *testing.T) {
+	t.Run("Should return 200 with the keys of the user", func(t *testing.T) {
+		store := &MockStore{keys: []apikeys.Key{{ID: 1, Name: "reports", Hash: "secret"}}}
+		httpRequest := &testutils.HTTPRequest{Method: http.MethodGet, Endpoint: "api-keys"}
+
+		resp, err := httpRequest.MakeTestHTTPResponse(asUser(apikeys.NewHandler(store).List))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.Code != http.StatusOK || strings.Contains(resp.Body.String(), "secret") {
+			t.Errorf("unexpected response: got %v %s", resp.Code, resp.Body.String())
+		}
+	})
+
+	t.Run("Should return 401 when no user is authenticated", func(t *testing.T) {
+		httpRequest := &testutils.HTTPRequest{Method: http.MethodGet, Endpoint: "api-keys"}
+
+		resp, err := httpRequest.MakeTestHTTPResponse(apikeys.NewHandler(&MockStore{}).List)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.Code != http.StatusUnauthorized {
+			t.Errorf("unexpected status code: got %v want %v", resp.Code, http.StatusUnauthorized)
+		}
+	})
+}
+
+func TestRevoke(t *testing.T) {
+	tests := []struct {
+		name           string
+		id             string
+		store          *MockStore
+		wantStatusCode int
+	}{
+		{
+			name:           "Should return 204 when revoke successfully",
+			id:             "1",
+			store:          &MockStore{revoked: true},
+			wantStatusCode: http.StatusNoContent,
+		},
+		{
+			name:           "Should return 404 when key not found",
+			id:             "1",
+			store:          &MockStore{},
+			wantStatusCode: http.StatusNotFound,
+		},
+		{
+			name:           "Should return 400 when id is not a number",
+			id:             "invalid",
+			store:          &MockStore{},
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:           "Should return 500 when store error",
+			id:             "1",
+			store:          &MockStore{err: errors.New("error")},
+			wantStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			httpRequest := &testutils.HTTPRequest{Method: http.MethodDelete, Endpoint: "api-keys"}
+
+			resp, err := httpRequest.MakeTestHTTPResponse(asUser(apikeys.NewHandler(test.store).Revoke), gin.Param{Key: "id", Value: test.id})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if resp.Code != test.wantStatusCode {
+				t.Errorf("unexpected status code: got %v want %v", resp.Code, test.wantStatusCode)
+			}
+		})
+	}
+}
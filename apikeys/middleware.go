@@ -0,0 +1,42 @@
+package apikeys
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tirathawat/assessment/errs"
+	"github.com/tirathawat/assessment/logs"
+	"github.com/tirathawat/assessment/middleware"
+	"gorm.io/gorm"
+)
+
+// Middleware authenticates requests carrying an API key as the key's owner
+// with the key's scopes. Requests without the header are passed through to
+// middleware.Auth.
+func Middleware(store Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		secret := c.GetHeader(Header)
+		if secret == "" {
+			c.Next()
+			return
+		}
+
+		key, err := store.Find(hash(secret))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			logs.Error().Context(c.Request.Context()).Err(err).Msgf("unknown API key: %.*s", displayLength, secret)
+			errs.Abort(c, http.StatusUnauthorized, ErrInvalidKey)
+			return
+		}
+		if err != nil {
+			logs.Error().Context(c.Request.Context()).Err(err).Msg("failed to look up API key")
+			errs.Abort(c, http.StatusInternalServerError, ErrLookupFailed)
+			return
+		}
+
+		c.Set(middleware.SubjectKey, key.Owner.Subject)
+		c.Set(middleware.ActorKey, actor(key))
+		c.Set(middleware.ScopesKey, []string(key.Scopes))
+		c.Next()
+	}
+}
@@ -0,0 +1,92 @@
+//go:build unit
+// +build unit
+
+package apikeys_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tirathawat/assessment/apikeys"
+	"github.com/tirathawat/assessment/middleware"
+	"github.com/tirathawat/assessment/users"
+)
+
+func TestMiddleware(t *testing.T) {
+	const secret = "exp_test-key"
+	sum := sha256.Sum256([]byte(secret))
+	key := apikeys.Key{
+		Hash:   hex.EncodeToString(sum[:]),
+		Scopes: []string{middleware.ScopeRead},
+		Owner:  users.User{ID: ownerID, Subject: "reports"},
+	}
+
+	tests := []struct {
+		name           string
+		store          *MockStore
+		header         string
+		wantStatusCode int
+		wantSubject    string
+		wantScopes     []string
+	}{
+		{
+			name:           "Should authenticate as the owner with the scopes of the key",
+			store:          &MockStore{keys: []apikeys.Key{key}},
+			header:         secret,
+			wantStatusCode: http.StatusOK,
+			wantSubject:    "reports",
+			wantScopes:     []string{middleware.ScopeRead},
+		},
+		{
+			name:           "Should pass requests without a key through",
+			store:          &MockStore{keys: []apikeys.Key{key}},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name:           "Should return 401 when the key is unknown or revoked",
+			store:          &MockStore{keys: []apikeys.Key{key}},
+			header:         "exp_other",
+			wantStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:           "Should return 500 when store error",
+			store:          &MockStore{err: errors.New("error")},
+			header:         secret,
+			wantStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var subject string
+			var scopes []string
+			r := gin.New()
+			r.GET("/", apikeys.Middleware(test.store), func(c *gin.Context) {
+				subject = middleware.Subject(c)
+				scopes = c.GetStringSlice(middleware.ScopesKey)
+				c.Status(http.StatusOK)
+			})
+
+			request := httptest.NewRequest(http.MethodGet, "/", nil)
+			if test.header != "" {
+				request.Header.Set(apikeys.Header, test.header)
+			}
+
+			resp := httptest.NewRecorder()
+			r.ServeHTTP(resp, request)
+
+			if resp.Code != test.wantStatusCode {
+				t.Errorf("unexpected status code: got %v want %v", resp.Code, test.wantStatusCode)
+			}
+
+			if subject != test.wantSubject || len(scopes) != len(test.wantScopes) {
+				t.Errorf("unexpected identity: got %v %v want %v %v", subject, scopes, test.wantSubject, test.wantScopes)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package apikeys
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Store interface {
+	Create(key *Key) error
+	List(owner int) ([]Key, error)
+	// Revoke marks the key as revoked. It reports false when the owner has
+	// no active key with the id.
+	Revoke(owner, id int, now time.Time) (bool, error)
+	// Find returns the active key with the hash along with its owner.
+	Find(hash string) (Key, error)
+}
+
+type store struct {
+	db *gorm.DB
+}
+
+func NewStore(db *gorm.DB) Store {
+	return &store{db}
+}
+
+func (s *store) Create(key *Key) error {
+	return s.db.Omit("Owner").Create(key).Error
+}
+
+func (s *store) List(owner int) ([]Key, error) {
+	keys := []Key{}
+	err := s.db.Where("owner_id = ?", owner).Order("id").Find(&keys).Error
+	return keys, err
+}
+
+func (s *store) Revoke(owner, id int, now time.Time) (bool, error) {
+	result := s.db.Model(&Key{}).
+		Where("id = ? AND owner_id = ? AND revoked_at IS NULL", id, owner).
+		Update("revoked_at", now)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (s *store) Find(hash string) (Key, error) {
+	var key Key
+	err := s.db.Preload("Owner").
+		Where("hash = ? AND revoked_at IS NULL", hash).
+		First(&key).Error
+	return key, err
+}
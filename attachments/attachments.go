@@ -0,0 +1,55 @@
+package attachments
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/tirathawat/assessment/errs"
+	"github.com/tirathawat/assessment/expenses"
+)
+
+// FormField is the multipart field that carries the uploaded file.
+const FormField = "file"
+
+var (
+	ErrNotFound        = errs.New(http.StatusNotFound, "ATTACHMENT_NOT_FOUND", "attachment not found")
+	ErrInvalidID       = errs.New(http.StatusBadRequest, "INVALID_ID", "invalid id")
+	ErrMissingFile     = errs.New(http.StatusBadRequest, "MISSING_FILE", "multipart field \"file\" is required")
+	ErrTooLarge        = errs.New(http.StatusRequestEntityTooLarge, "ATTACHMENT_TOO_LARGE", "attachment is too large")
+	ErrUnsupportedType = errs.New(http.StatusUnsupportedMediaType, "UNSUPPORTED_ATTACHMENT_TYPE", "attachment type is not allowed")
+	ErrUploadFailed    = errs.New(http.StatusInternalServerError, "ATTACHMENT_UPLOAD_FAILED", "failed to upload attachment")
+	ErrListFailed      = errs.New(http.StatusInternalServerError, "ATTACHMENT_LIST_FAILED", "failed to list attachments")
+	ErrDownloadFailed  = errs.New(http.StatusInternalServerError, "ATTACHMENT_DOWNLOAD_FAILED", "failed to download attachment")
+	ErrDeleteFailed    = errs.New(http.StatusInternalServerError, "ATTACHMENT_DELETE_FAILED", "failed to delete attachment")
+	ErrBlobNotFound    = errors.New("blob not found")
+)
+
+// Blob is stored content, kept once however many attachments share it.
+// Its Checksum, the hex SHA-256 of the content, is its key in Storage.
+type Blob struct {
+	Checksum    string    `gorm:"type:char(64);primaryKey"`
+	Size        int64     `gorm:"not null"`
+	ContentType string    `gorm:"type:text;not null"`
+	CreatedAt   time.Time `gorm:"not null;default:CURRENT_TIMESTAMP"`
+	UpdatedAt   time.Time `gorm:"not null;default:CURRENT_TIMESTAMP"`
+}
+
+func (Blob) TableName() string {
+	return "attachment_blobs"
+}
+
+// Attachment is a file such as a receipt kept with an expense. Uploading
+// the same content to an expense twice returns the existing attachment.
+type Attachment struct {
+	ID          int       `gorm:"primary_key" json:"id"`
+	ExpenseID   int       `gorm:"not null;uniqueIndex:idx_attachments_checksum" json:"expense_id"`
+	Checksum    string    `gorm:"type:char(64);not null;uniqueIndex:idx_attachments_checksum;index" json:"checksum"`
+	Filename    string    `gorm:"type:text;not null" json:"filename"`
+	ContentType string    `gorm:"type:text;not null" json:"content_type"`
+	Size        int64     `gorm:"not null" json:"size"`
+	CreatedAt   time.Time `gorm:"not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+
+	Expense expenses.Expense `gorm:"foreignKey:ExpenseID;constraint:OnDelete:CASCADE" json:"-"`
+	Blob    Blob             `gorm:"foreignKey:Checksum;references:Checksum" json:"-"`
+}
@@ -0,0 +1,99 @@
+package attachments
+
+import (
+	"time"
+
+	"github.com/tirathawat/assessment/config"
+	"github.com/tirathawat/assessment/logs"
+)
+
+// gracePeriod keeps a blob that has just been reserved for an upload from
+// being collected before its attachment is recorded.
+const gracePeriod = time.Hour
+
+// Collector removes blobs that no attachment uses any more, once their
+// attachments have been deleted or their expenses purged from the trash.
+type Collector struct {
+	store    Store
+	storage  Storage
+	interval time.Duration
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+func NewCollector(store Store, storage Storage, cfg *config.AppConfig) *Collector {
+	return &Collector{
+		store:    store,
+		storage:  storage,
+		interval: cfg.PurgeInterval,
+	}
+}
+
+func (c *Collector) Start() {
+	c.stop = make(chan struct{})
+	c.done = make(chan struct{})
+
+	go func() {
+		defer close(c.done)
+
+		ticker := time.NewTicker(c.interval)
+		defer ticker.Stop()
+
+		for {
+			c.run()
+
+			select {
+			case <-ticker.C:
+			case <-c.stop:
+				return
+			}
+		}
+	}()
+}
+
+func (c *Collector) Stop() {
+	if c.stop == nil {
+		return
+	}
+
+	close(c.stop)
+	<-c.done
+}
+
+// Collect removes the blobs that have had no attachment since before now
+// minus the grace period and returns how many were removed.
+func (c *Collector) Collect(now time.Time) (int, error) {
+	before := now.Add(-gracePeriod)
+	checksums, err := c.store.Orphans(before)
+	if err != nil {
+		return 0, err
+	}
+
+	var collected int
+	for _, checksum := range checksums {
+		ok, err := c.store.Collect(checksum, before, func() error {
+			return c.storage.Delete(checksum)
+		})
+		if err != nil {
+			return collected, err
+		}
+
+		if ok {
+			collected++
+		}
+	}
+
+	return collected, nil
+}
+
+func (c *Collector) run() {
+	collected, err := c.Collect(time.Now())
+	if err != nil {
+		logs.Error().Err(err).Msg("failed to collect attachments")
+		return
+	}
+
+	if collected > 0 {
+		logs.Info().Msgf("removed %d unused attachment blobs", collected)
+	}
+}
@@ -0,0 +1,298 @@
+package attachments
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tirathawat/assessment/config"
+	"github.com/tirathawat/assessment/errs"
+	"github.com/tirathawat/assessment/expenses"
+	"github.com/tirathawat/assessment/logs"
+	"github.com/tirathawat/assessment/middleware"
+	"github.com/tirathawat/assessment/users"
+	"gorm.io/gorm"
+)
+
+// multipartOverhead allows for the boundaries and part headers around the
+// file when limiting the size of an upload request.
+const multipartOverhead = 1 << 20
+
+// sniffLength is how much of a file http.DetectContentType looks at.
+const sniffLength = 512
+
+type Handler interface {
+	Upload(c *gin.Context)
+	List(c *gin.Context)
+	Download(c *gin.Context)
+	Delete(c *gin.Context)
+}
+
+type handler struct {
+	store   Store
+	storage Storage
+	maxSize int64
+	types   map[string]bool
+}
+
+func NewHandler(store Store, storage Storage, cfg *config.AppConfig) Handler {
+	types := make(map[string]bool, len(cfg.AttachmentTypes))
+	for _, contentType := range cfg.AttachmentTypes {
+		types[contentType] = true
+	}
+
+	return &handler{
+		store:   store,
+		storage: storage,
+		maxSize: cfg.AttachmentMaxSize,
+		types:   types,
+	}
+}
+
+// Upload attaches the file in the multipart field "file" to the expense.
+// The content type is detected from the file rather than trusted from the
+// client. Uploading a file the expense already has returns the existing
+// attachment with 200.
+func (h *handler) Upload(c *gin.Context) {
+	expenseID, ok := h.expense(c)
+	if !ok {
+		return
+	}
+
+	limit := h.maxSize + multipartOverhead
+	if c.Request.ContentLength > limit {
+		logs.Error().Context(c.Request.Context()).Msgf("attachment request too large: %d bytes", c.Request.ContentLength)
+		errs.JSON(c, http.StatusRequestEntityTooLarge, ErrTooLarge)
+		return
+	}
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+
+	header, err := c.FormFile(FormField)
+	if errors.As(err, new(*http.MaxBytesError)) {
+		logs.Error().Context(c.Request.Context()).Err(err).Msg("attachment request too large")
+		errs.JSON(c, http.StatusRequestEntityTooLarge, ErrTooLarge)
+		return
+	}
+	if err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msg("failed to read attachment")
+		errs.JSON(c, http.StatusBadRequest, ErrMissingFile)
+		return
+	}
+
+	if header.Size > h.maxSize {
+		logs.Error().Context(c.Request.Context()).Msgf("attachment too large: %d bytes", header.Size)
+		errs.JSON(c, http.StatusRequestEntityTooLarge, ErrTooLarge)
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msg("failed to open attachment")
+		errs.JSON(c, http.StatusInternalServerError, ErrUploadFailed)
+		return
+	}
+	defer file.Close()
+
+	contentType, checksum, err := inspect(file)
+	if err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msg("failed to read attachment")
+		errs.JSON(c, http.StatusInternalServerError, ErrUploadFailed)
+		return
+	}
+
+	if !h.types[contentType] {
+		logs.Error().Context(c.Request.Context()).Msgf("attachment type not allowed: %s", contentType)
+		errs.JSON(c, http.StatusUnsupportedMediaType, ErrUnsupportedType)
+		return
+	}
+
+	existing, err := h.store.FindByChecksum(expenseID, checksum)
+	if err == nil {
+		c.JSON(http.StatusOK, existing)
+		return
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("failed to find attachment: %s", checksum)
+		errs.JSON(c, http.StatusInternalServerError, ErrUploadFailed)
+		return
+	}
+
+	if err := h.store.Reserve(&Blob{Checksum: checksum, Size: header.Size, ContentType: contentType}); err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("failed to reserve blob: %s", checksum)
+		errs.JSON(c, http.StatusInternalServerError, ErrUploadFailed)
+		return
+	}
+
+	if err := h.storage.Put(checksum, file); err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("failed to store blob: %s", checksum)
+		errs.JSON(c, http.StatusInternalServerError, ErrUploadFailed)
+		return
+	}
+
+	attachment := Attachment{
+		ExpenseID:   expenseID,
+		Checksum:    checksum,
+		Filename:    header.Filename,
+		ContentType: contentType,
+		Size:        header.Size,
+	}
+	if err := h.store.Create(&attachment); err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("failed to create attachment of expense: %d", expenseID)
+		errs.JSON(c, http.StatusInternalServerError, ErrUploadFailed)
+		return
+	}
+
+	c.JSON(http.StatusCreated, attachment)
+}
+
+func (h *handler) List(c *gin.Context) {
+	expenseID, ok := h.expense(c)
+	if !ok {
+		return
+	}
+
+	attachments, err := h.store.List(expenseID)
+	if err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("failed to list attachments of expense: %d", expenseID)
+		errs.JSON(c, http.StatusInternalServerError, ErrListFailed)
+		return
+	}
+
+	c.JSON(http.StatusOK, attachments)
+}
+
+func (h *handler) Download(c *gin.Context) {
+	expenseID, ok := h.expense(c)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("attachment"))
+	if err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("invalid attachment id: %s", c.Param("attachment"))
+		errs.JSON(c, http.StatusBadRequest, ErrInvalidID)
+		return
+	}
+
+	attachment, err := h.store.Get(expenseID, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("attachment not found: %d", id)
+		errs.JSON(c, http.StatusNotFound, ErrNotFound)
+		return
+	}
+	if err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("failed to get attachment: %d", id)
+		errs.JSON(c, http.StatusInternalServerError, ErrDownloadFailed)
+		return
+	}
+
+	content, err := h.storage.Open(attachment.Checksum)
+	if err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("failed to open blob: %s", attachment.Checksum)
+		errs.JSON(c, http.StatusInternalServerError, ErrDownloadFailed)
+		return
+	}
+	defer content.Close()
+
+	c.DataFromReader(http.StatusOK, attachment.Size, attachment.ContentType, content, map[string]string{
+		"Content-Disposition": mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename}),
+		"ETag":                strconv.Quote(attachment.Checksum),
+	})
+}
+
+// Delete removes the attachment. Its content is removed by the Collector
+// once no other attachment uses it.
+func (h *handler) Delete(c *gin.Context) {
+	expenseID, ok := h.expense(c)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("attachment"))
+	if err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("invalid attachment id: %s", c.Param("attachment"))
+		errs.JSON(c, http.StatusBadRequest, ErrInvalidID)
+		return
+	}
+
+	deleted, err := h.store.Delete(expenseID, id)
+	if err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("failed to delete attachment: %d", id)
+		errs.JSON(c, http.StatusInternalServerError, ErrDeleteFailed)
+		return
+	}
+
+	if !deleted {
+		logs.Error().Context(c.Request.Context()).Msgf("attachment not found: %d", id)
+		errs.JSON(c, http.StatusNotFound, ErrNotFound)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// expense returns the id of the expense in the path after checking that
+// the authenticated user owns it, responding with an error otherwise.
+func (h *handler) expense(c *gin.Context) (int, bool) {
+	owner, ok := users.ID(c)
+	if !ok {
+		logs.Error().Context(c.Request.Context()).Msg("no authenticated user")
+		errs.JSON(c, http.StatusUnauthorized, middleware.ErrUnauthorized)
+		return 0, false
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("invalid id: %s", c.Param("id"))
+		errs.JSON(c, http.StatusBadRequest, expenses.ErrInvalidID)
+		return 0, false
+	}
+
+	exists, err := h.store.HasExpense(owner, id)
+	if err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("failed to get expense: %d", id)
+		errs.JSON(c, http.StatusInternalServerError, expenses.ErrGetFailed)
+		return 0, false
+	}
+
+	if !exists {
+		logs.Error().Context(c.Request.Context()).Msgf("expense not found: %d", id)
+		errs.JSON(c, http.StatusNotFound, expenses.ErrNotFound)
+		return 0, false
+	}
+
+	return id, true
+}
+
+// inspect detects the content type of the file and computes its checksum,
+// leaving the file positioned at its start.
+func inspect(file multipart.File) (contentType, checksum string, err error) {
+	head := make([]byte, sniffLength)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", "", err
+	}
+
+	contentType, _, err = mime.ParseMediaType(http.DetectContentType(head[:n]))
+	if err != nil {
+		return "", "", err
+	}
+
+	hash := sha256.New()
+	hash.Write(head[:n])
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", "", err
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", "", err
+	}
+
+	return contentType, hex.EncodeToString(hash.Sum(nil)), nil
+}
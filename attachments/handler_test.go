@@ -0,0 +1,379 @@
+//go:build unit
+// +build unit
+
+package attachments_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tirathawat/assessment/attachments"
+	"github.com/tirathawat/assessment/config"
+	"github.com/tirathawat/assessment/testutils"
+	"github.com/tirathawat/assessment/users"
+	"gorm.io/gorm"
+)
+
+const ownerID = 7
+
+var cfg = &config.AppConfig{
+	AttachmentMaxSize: 1024,
+	AttachmentTypes:   []string{"image/png", "application/pdf"},
+	PurgeInterval:     time.Hour,
+}
+
+// png is the smallest content detected as image/png.
+var png = "\x89PNG\x0D\x0A\x1A\x0A" + strings.Repeat("\x00", 16)
+
+type MockStore struct {
+	expenses    []int
+	attachments []attachments.Attachment
+	blobs       []string
+	orphans     []string
+	err         error
+}
+
+func (m *MockStore) HasExpense(owner, expenseID int) (bool, error) {
+	for _, id := range m.expenses {
+		if id == expenseID {
+			return true, m.err
+		}
+	}
+
+	return false, m.err
+}
+
+func (m *MockStore) List(expenseID int) ([]attachments.Attachment, error) {
+	return m.attachments, m.err
+}
+
+func (m *MockStore) Get(expenseID, id int) (attachments.Attachment, error) {
+	for _, attachment := range m.attachments {
+		if attachment.ID == id {
+			return attachment, m.err
+		}
+	}
+
+	return attachments.Attachment{}, gorm.ErrRecordNotFound
+}
+
+func (m *MockStore) FindByChecksum(expenseID int, checksum string) (attachments.Attachment, error) {
+	for _, attachment := range m.attachments {
+		if attachment.Checksum == checksum {
+			return attachment, m.err
+		}
+	}
+
+	return attachments.Attachment{}, gorm.ErrRecordNotFound
+}
+
+func (m *MockStore) Reserve(blob *attachments.Blob) error {
+	return m.err
+}
+
+func (m *MockStore) Create(attachment *attachments.Attachment) error {
+	attachment.ID = len(m.attachments) + 1
+	return m.err
+}
+
+func (m *MockStore) Delete(expenseID, id int) (bool, error) {
+	_, err := m.Get(expenseID, id)
+	return err == nil, m.err
+}
+
+func (m *MockStore) Orphans(before time.Time) ([]string, error) {
+	return m.orphans, m.err
+}
+
+func (m *MockStore) Collect(checksum string, before time.Time, remove func() error) (bool, error) {
+	for _, blob := range m.blobs {
+		if blob == checksum {
+			return false, m.err
+		}
+	}
+
+	return true, remove()
+}
+
+type MockStorage struct {
+	blobs map[string]string
+	err   error
+}
+
+func (m *MockStorage) Put(key string, content io.Reader) error {
+	if m.err != nil {
+		return m.err
+	}
+
+	data, err := io.ReadAll(content)
+	m.blobs[key] = string(data)
+	return err
+}
+
+func (m *MockStorage) Open(key string) (io.ReadCloser, error) {
+	data, ok := m.blobs[key]
+	if !ok {
+		return nil, attachments.ErrBlobNotFound
+	}
+
+	return io.NopCloser(strings.NewReader(data)), m.err
+}
+
+func (m *MockStorage) Delete(key string) error {
+	delete(m.blobs, key)
+	return m.err
+}
+
+func asUser(handler gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(users.IDKey, ownerID)
+		handler(c)
+	}
+}
+
+// upload builds a multipart request carrying content in field.
+func upload(t *testing.T, field, content string) *testutils.HTTPRequest {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile(field, "receipt.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := part.Write([]byte(content)); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return &testutils.HTTPRequest{
+		Method:   http.MethodPost,
+		Endpoint: "expenses/1/attachments",
+		Body:     body.String(),
+		Headers:  map[string]string{"Content-Type": writer.FormDataContentType()},
+	}
+}
+
+func TestUpload(t *testing.T) {
+	pngChecksum := "f731c2528f4184b386555de339aeb78f1f0908532be04e6bfe1ec790f5f50da9"
+
+	tests := []struct {
+		name           string
+		request        *testutils.HTTPRequest
+		store          *MockStore
+		storage        *MockStorage
+		wantStatusCode int
+		wantStored     bool
+	}{
+		{
+			name:           "Should return 201 and store the file",
+			request:        upload(t, attachments.FormField, png),
+			store:          &MockStore{expenses: []int{1}},
+			storage:        &MockStorage{blobs: map[string]string{}},
+			wantStatusCode: http.StatusCreated,
+			wantStored:     true,
+		},
+		{
+			name:    "Should return 200 with the existing attachment when the file was uploaded before",
+			request: upload(t, attachments.FormField, png),
+			store: &MockStore{expenses: []int{1}, attachments: []attachments.Attachment{
+				{ID: 3, ExpenseID: 1, Checksum: pngChecksum},
+			}},
+			storage:        &MockStorage{blobs: map[string]string{}},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name:           "Should return 404 when expense not found",
+			request:        upload(t, attachments.FormField, png),
+			store:          &MockStore{},
+			storage:        &MockStorage{blobs: map[string]string{}},
+			wantStatusCode: http.StatusNotFound,
+		},
+		{
+			name:           "Should return 400 when the file field is missing",
+			request:        upload(t, "receipt", png),
+			store:          &MockStore{expenses: []int{1}},
+			storage:        &MockStorage{blobs: map[string]string{}},
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:           "Should return 413 when the file is too large",
+			request:        upload(t, attachments.FormField, png+strings.Repeat("\x00", 1024)),
+			store:          &MockStore{expenses: []int{1}},
+			storage:        &MockStorage{blobs: map[string]string{}},
+			wantStatusCode: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:           "Should return 415 when the file type is not allowed",
+			request:        upload(t, attachments.FormField, "plain text"),
+			store:          &MockStore{expenses: []int{1}},
+			storage:        &MockStorage{blobs: map[string]string{}},
+			wantStatusCode: http.StatusUnsupportedMediaType,
+		},
+		{
+			name:           "Should return 500 when storage error",
+			request:        upload(t, attachments.FormField, png),
+			store:          &MockStore{expenses: []int{1}},
+			storage:        &MockStorage{blobs: map[string]string{}, err: errors.New("error")},
+			wantStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			attachment := &attachments.Attachment{}
+			handler := attachments.NewHandler(test.store, test.storage, cfg).Upload
+			statusCode, err := test.request.MakeTestHTTPRequest(asUser(handler), attachment, gin.Param{Key: "id", Value: "1"})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if statusCode != test.wantStatusCode {
+				t.Errorf("unexpected status code: got %v want %v", statusCode, test.wantStatusCode)
+			}
+
+			if statusCode == http.StatusCreated && (attachment.ContentType != "image/png" || attachment.Filename != "receipt.png" || attachment.Checksum != pngChecksum) {
+				t.Errorf("unexpected attachment: got %+v", attachment)
+			}
+
+			if stored := test.storage.blobs[pngChecksum] == png; stored != test.wantStored {
+				t.Errorf("unexpected stored: got %v want %v", stored, test.wantStored)
+			}
+		})
+	}
+}
+
+func TestUploadChunked(t *testing.T) {
+	request := upload(t, attachments.FormField, png+strings.Repeat("\x00", 2<<20))
+
+	r := gin.New()
+	storage := &MockStorage{blobs: map[string]string{}}
+	r.POST("/expenses/:id/attachments", asUser(attachments.NewHandler(&MockStore{expenses: []int{1}}, storage, cfg).Upload))
+
+	httpRequest := httptest.NewRequest(request.Method, "/"+request.Endpoint, strings.NewReader(request.Body))
+	for key, value := range request.Headers {
+		httpRequest.Header.Set(key, value)
+	}
+	// A chunked request does not say how large it is up front.
+	httpRequest.ContentLength = -1
+	httpRequest.TransferEncoding = []string{"chunked"}
+
+	resp := httptest.NewRecorder()
+	r.ServeHTTP(resp, httpRequest)
+
+	if resp.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("unexpected status code: got %v want %v", resp.Code, http.StatusRequestEntityTooLarge)
+	}
+
+	if len(storage.blobs) != 0 {
+		t.Errorf("unexpected stored blobs: got %v want none", len(storage.blobs))
+	}
+}
+
+func TestDownload(t *testing.T) {
+	store := &MockStore{expenses: []int{1}, attachments: []attachments.Attachment{
+		{ID: 1, ExpenseID: 1, Checksum: "abc", Filename: "receipt.png", ContentType: "image/png", Size: int64(len(png))},
+		{ID: 2, ExpenseID: 1, Checksum: "missing", Filename: "lost.png", ContentType: "image/png"},
+	}}
+	storage := &MockStorage{blobs: map[string]string{"abc": png}}
+
+	tests := []struct {
+		name           string
+		expenseID      string
+		attachmentID   string
+		wantStatusCode int
+	}{
+		{name: "Should return 200 with the file", expenseID: "1", attachmentID: "1", wantStatusCode: http.StatusOK},
+		{name: "Should return 404 when attachment not found", expenseID: "1", attachmentID: "9", wantStatusCode: http.StatusNotFound},
+		{name: "Should return 404 when expense not found", expenseID: "2", attachmentID: "1", wantStatusCode: http.StatusNotFound},
+		{name: "Should return 400 when attachment id is not a number", expenseID: "1", attachmentID: "invalid", wantStatusCode: http.StatusBadRequest},
+		{name: "Should return 500 when the content is missing", expenseID: "1", attachmentID: "2", wantStatusCode: http.StatusInternalServerError},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			httpRequest := &testutils.HTTPRequest{Method: http.MethodGet, Endpoint: "expenses/1/attachments/1"}
+
+			resp, err := httpRequest.MakeTestHTTPResponse(asUser(attachments.NewHandler(store, storage, cfg).Download),
+				gin.Param{Key: "id", Value: test.expenseID}, gin.Param{Key: "attachment", Value: test.attachmentID})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if resp.Code != test.wantStatusCode {
+				t.Errorf("unexpected status code: got %v want %v", resp.Code, test.wantStatusCode)
+			}
+
+			if resp.Code != http.StatusOK {
+				return
+			}
+
+			if resp.Body.String() != png || resp.Header().Get("Content-Type") != "image/png" {
+				t.Errorf("unexpected content: got %q of type %v", resp.Body.String(), resp.Header().Get("Content-Type"))
+			}
+
+			if got := resp.Header().Get("Content-Disposition"); got != "attachment; filename=receipt.png" {
+				t.Errorf("unexpected content disposition: got %v", got)
+			}
+		})
+	}
+}
+
+func TestDelete(t *testing.T) {
+	tests := []struct {
+		name           string
+		attachmentID   string
+		wantStatusCode int
+	}{
+		{name: "Should return 204 when delete attachment successfully", attachmentID: "1", wantStatusCode: http.StatusNoContent},
+		{name: "Should return 404 when attachment not found", attachmentID: "2", wantStatusCode: http.StatusNotFound},
+		{name: "Should return 400 when attachment id is not a number", attachmentID: "invalid", wantStatusCode: http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			store := &MockStore{expenses: []int{1}, attachments: []attachments.Attachment{{ID: 1, ExpenseID: 1}}}
+			httpRequest := &testutils.HTTPRequest{Method: http.MethodDelete, Endpoint: "expenses/1/attachments"}
+
+			resp, err := httpRequest.MakeTestHTTPResponse(asUser(attachments.NewHandler(store, &MockStorage{}, cfg).Delete),
+				gin.Param{Key: "id", Value: "1"}, gin.Param{Key: "attachment", Value: test.attachmentID})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if resp.Code != test.wantStatusCode {
+				t.Errorf("unexpected status code: got %v want %v", resp.Code, test.wantStatusCode)
+			}
+		})
+	}
+}
+
+func TestCollect(t *testing.T) {
+	store := &MockStore{orphans: []string{"abc", "def"}, blobs: []string{"def"}}
+	storage := &MockStorage{blobs: map[string]string{"abc": png, "def": png}}
+
+	collected, err := attachments.NewCollector(store, storage, cfg).Collect(time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if collected != 1 {
+		t.Errorf("unexpected collected: got %v want %v", collected, 1)
+	}
+
+	if _, ok := storage.blobs["abc"]; ok {
+		t.Errorf("expected orphan blob to be removed")
+	}
+
+	if _, ok := storage.blobs["def"]; !ok {
+		t.Errorf("expected blob used again to be kept")
+	}
+}
@@ -0,0 +1,87 @@
+package attachments
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Storage keeps blob content by key. Keys are checksums of the content, so
+// whatever is stored under a key never changes.
+type Storage interface {
+	// Put stores the content under key unless it is already there.
+	Put(key string, content io.Reader) error
+	// Open returns the content stored under key, or ErrBlobNotFound.
+	Open(key string) (io.ReadCloser, error)
+	// Delete removes the content under key. Removing a key that is not
+	// there is not an error.
+	Delete(key string) error
+}
+
+// LocalStorage keeps blobs as files under a directory, spread over
+// subdirectories named after the first characters of their keys.
+type LocalStorage struct {
+	dir string
+}
+
+func NewLocalStorage(dir string) *LocalStorage {
+	return &LocalStorage{dir: dir}
+}
+
+func (s *LocalStorage) path(key string) string {
+	if len(key) < 4 {
+		return filepath.Join(s.dir, key)
+	}
+
+	return filepath.Join(s.dir, key[:2], key[2:4], key)
+}
+
+// Put writes to a temporary file first, so a blob is either complete or
+// missing, never partly written.
+func (s *LocalStorage) Put(key string, content io.Reader) error {
+	path := s.path(key)
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	if _, err := io.Copy(file, content); err != nil {
+		file.Close()
+		return err
+	}
+
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(file.Name(), path)
+}
+
+func (s *LocalStorage) Open(key string) (io.ReadCloser, error) {
+	file, err := os.Open(s.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrBlobNotFound
+	}
+
+	return file, err
+}
+
+func (s *LocalStorage) Delete(key string) error {
+	err := os.Remove(s.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+
+	return err
+}
@@ -0,0 +1,59 @@
+//go:build unit
+// +build unit
+
+package attachments_test
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/tirathawat/assessment/attachments"
+)
+
+func TestLocalStorage(t *testing.T) {
+	storage := attachments.NewLocalStorage(t.TempDir())
+	key := "f731c2528f4184b386555de339aeb78f1f0908532be04e6bfe1ec790f5f50da9"
+
+	if err := storage.Put(key, strings.NewReader("receipt")); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Should keep the first content stored under a key", func(t *testing.T) {
+		if err := storage.Put(key, strings.NewReader("other")); err != nil {
+			t.Fatal(err)
+		}
+
+		content, err := storage.Open(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer content.Close()
+
+		data, err := io.ReadAll(content)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(data) != "receipt" {
+			t.Errorf("unexpected content: got %q want %q", data, "receipt")
+		}
+	})
+
+	t.Run("Should return ErrBlobNotFound after delete", func(t *testing.T) {
+		if err := storage.Delete(key); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := storage.Open(key); !errors.Is(err, attachments.ErrBlobNotFound) {
+			t.Errorf("unexpected error: got %v want %v", err, attachments.ErrBlobNotFound)
+		}
+	})
+
+	t.Run("Should ignore deleting a missing key", func(t *testing.T) {
+		if err := storage.Delete(key); err != nil {
+			t.Errorf("unexpected error: got %v", err)
+		}
+	})
+}
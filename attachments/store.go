@@ -0,0 +1,106 @@
+package attachments
+
+import (
+	"time"
+
+	"github.com/tirathawat/assessment/expenses"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Store interface {
+	// HasExpense reports whether the owner has the expense outside the
+	// trash.
+	HasExpense(owner, expenseID int) (bool, error)
+	List(expenseID int) ([]Attachment, error)
+	// Get returns the attachment of the expense, or gorm.ErrRecordNotFound.
+	Get(expenseID, id int) (Attachment, error)
+	// FindByChecksum returns the attachment of the expense with the
+	// checksum, or gorm.ErrRecordNotFound.
+	FindByChecksum(expenseID int, checksum string) (Attachment, error)
+	// Reserve records the blob, or marks it as just used when it is
+	// already recorded, so it is not collected while being attached.
+	Reserve(blob *Blob) error
+	Create(attachment *Attachment) error
+	// Delete reports false when the expense has no attachment with the id.
+	Delete(expenseID, id int) (bool, error)
+	// Orphans returns the checksums of blobs that no attachment uses and
+	// that were last used before the time given.
+	Orphans(before time.Time) ([]string, error)
+	// Collect deletes the blob if it is still an orphan and then calls
+	// remove, all in one transaction so an upload reserving the blob waits
+	// for its content to be gone. It reports false when the blob was kept.
+	Collect(checksum string, before time.Time, remove func() error) (bool, error)
+}
+
+type store struct {
+	db *gorm.DB
+}
+
+func NewStore(db *gorm.DB) Store {
+	return &store{db}
+}
+
+func (s *store) HasExpense(owner, expenseID int) (bool, error) {
+	var count int64
+	err := s.db.Model(&expenses.Expense{}).Where("id = ? AND owner_id = ?", expenseID, owner).Count(&count).Error
+	return count > 0, err
+}
+
+func (s *store) List(expenseID int) ([]Attachment, error) {
+	attachments := []Attachment{}
+	err := s.db.Where("expense_id = ?", expenseID).Order("id").Find(&attachments).Error
+	return attachments, err
+}
+
+func (s *store) Get(expenseID, id int) (Attachment, error) {
+	var attachment Attachment
+	err := s.db.Where("expense_id = ?", expenseID).First(&attachment, "id = ?", id).Error
+	return attachment, err
+}
+
+func (s *store) FindByChecksum(expenseID int, checksum string) (Attachment, error) {
+	var attachment Attachment
+	err := s.db.Where("expense_id = ? AND checksum = ?", expenseID, checksum).First(&attachment).Error
+	return attachment, err
+}
+
+func (s *store) Reserve(blob *Blob) error {
+	return s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "checksum"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"updated_at": gorm.Expr("CURRENT_TIMESTAMP")}),
+	}).Create(blob).Error
+}
+
+func (s *store) Create(attachment *Attachment) error {
+	return s.db.Omit("Expense", "Blob").Create(attachment).Error
+}
+
+func (s *store) Delete(expenseID, id int) (bool, error) {
+	result := s.db.Where("expense_id = ?", expenseID).Delete(&Attachment{}, "id = ?", id)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (s *store) Orphans(before time.Time) ([]string, error) {
+	var checksums []string
+	err := s.db.Model(&Blob{}).
+		Where("updated_at < ? AND checksum NOT IN (?)", before, s.db.Model(&Attachment{}).Select("checksum")).
+		Pluck("checksum", &checksums).Error
+	return checksums, err
+}
+
+func (s *store) Collect(checksum string, before time.Time, remove func() error) (bool, error) {
+	collected := false
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("checksum = ? AND updated_at < ? AND checksum NOT IN (?)", checksum, before, tx.Model(&Attachment{}).Select("checksum")).
+			Delete(&Blob{})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+
+		collected = true
+		return remove()
+	})
+
+	return collected && err == nil, err
+}
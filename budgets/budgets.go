@@ -0,0 +1,91 @@
+package budgets
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/tirathawat/assessment/errs"
+	"github.com/tirathawat/assessment/expenses"
+)
+
+const (
+	PeriodWeekly  = "weekly"
+	PeriodMonthly = "monthly"
+	PeriodYearly  = "yearly"
+)
+
+var (
+	ErrNotFound     = errs.New(http.StatusNotFound, "BUDGET_NOT_FOUND", "budget not found")
+	ErrInvalidID    = errs.New(http.StatusBadRequest, "INVALID_ID", "invalid id")
+	ErrCreateFailed = errs.New(http.StatusInternalServerError, "BUDGET_CREATE_FAILED", "failed to create budget")
+	ErrListFailed   = errs.New(http.StatusInternalServerError, "BUDGET_LIST_FAILED", "failed to list budgets")
+	ErrUpdateFailed = errs.New(http.StatusInternalServerError, "BUDGET_UPDATE_FAILED", "failed to update budget")
+	ErrDeleteFailed = errs.New(http.StatusInternalServerError, "BUDGET_DELETE_FAILED", "failed to delete budget")
+	ErrStatusFailed = errs.New(http.StatusInternalServerError, "BUDGET_STATUS_FAILED", "failed to compute budget status")
+	ErrInvalidLimit = errs.New(http.StatusBadRequest, "INVALID_BUDGET_LIMIT", "limit must be a positive amount")
+)
+
+// Budget limits what may be spent on expenses carrying any of Tags during
+// each Period. With Rollover, the part of the previous period's limit that
+// was not spent is added to the current one.
+type Budget struct {
+	ID        int            `gorm:"primary_key" json:"id"`
+	OwnerID   int            `gorm:"not null;index" json:"-"`
+	Name      string         `gorm:"type:text;not null" json:"name"`
+	Tags      pq.StringArray `gorm:"type:text[];not null" json:"tags"`
+	Period    string         `gorm:"type:text;not null" json:"period"`
+	Limit     expenses.Money `gorm:"type:numeric(19,2);not null" json:"limit"`
+	Currency  string         `gorm:"type:char(3);not null" json:"currency"`
+	Rollover  bool           `gorm:"not null;default:false" json:"rollover"`
+	CreatedAt time.Time      `gorm:"not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt time.Time      `gorm:"not null;default:CURRENT_TIMESTAMP" json:"updated_at"`
+}
+
+type RequestBody struct {
+	Name     string         `json:"name" binding:"required,max=100"`
+	Tags     []string       `json:"tags" binding:"required,min=1,dive,required"`
+	Period   string         `json:"period" binding:"required,oneof=weekly monthly yearly"`
+	Limit    expenses.Money `json:"limit" binding:"required"`
+	Currency string         `json:"currency" binding:"omitempty,iso4217"`
+	Rollover bool           `json:"rollover"`
+}
+
+func (body *RequestBody) validate() error {
+	if body.Limit <= 0 {
+		return ErrInvalidLimit
+	}
+
+	if body.Limit%expenses.CurrencyUnit(body.Currency) != 0 {
+		return expenses.ErrAmountPrecision
+	}
+
+	return nil
+}
+
+// apply copies the body onto the budget.
+func (body *RequestBody) apply(budget *Budget) {
+	budget.Name = body.Name
+	budget.Tags = pq.StringArray(body.Tags)
+	budget.Period = body.Period
+	budget.Limit = body.Limit
+	budget.Currency = body.Currency
+	budget.Rollover = body.Rollover
+}
+
+// period returns the period of the budget containing t, as the calendar
+// in t's location sees it. Weeks start on Monday.
+func period(kind string, t time.Time) (start, end time.Time) {
+	year, month, day := t.Date()
+	switch kind {
+	case PeriodWeekly:
+		start = time.Date(year, month, day-(int(t.Weekday())+6)%7, 0, 0, 0, 0, t.Location())
+		return start, start.AddDate(0, 0, 7)
+	case PeriodYearly:
+		start = time.Date(year, time.January, 1, 0, 0, 0, 0, t.Location())
+		return start, start.AddDate(1, 0, 0)
+	default:
+		start = time.Date(year, month, 1, 0, 0, 0, 0, t.Location())
+		return start, start.AddDate(0, 1, 0)
+	}
+}
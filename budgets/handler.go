@@ -0,0 +1,257 @@
+package budgets
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tirathawat/assessment/config"
+	"github.com/tirathawat/assessment/errs"
+	"github.com/tirathawat/assessment/expenses"
+	"github.com/tirathawat/assessment/logs"
+	"github.com/tirathawat/assessment/middleware"
+	"github.com/tirathawat/assessment/rates"
+	"github.com/tirathawat/assessment/users"
+	"gorm.io/gorm"
+)
+
+type Handler interface {
+	Create(c *gin.Context)
+	List(c *gin.Context)
+	Update(c *gin.Context)
+	Delete(c *gin.Context)
+	Status(c *gin.Context)
+}
+
+type handler struct {
+	store    Store
+	rates    rates.Store
+	timeZone *time.Location
+	currency string
+}
+
+func NewHandler(store Store, rateStore rates.Store, cfg *config.AppConfig) Handler {
+	return &handler{
+		store:    store,
+		rates:    rateStore,
+		timeZone: cfg.TimeZone.Get(),
+		currency: cfg.DefaultCurrency,
+	}
+}
+
+func (h *handler) Create(c *gin.Context) {
+	owner, ok := users.ID(c)
+	if !ok {
+		logs.Error().Context(c.Request.Context()).Msg("no authenticated user")
+		errs.JSON(c, http.StatusUnauthorized, middleware.ErrUnauthorized)
+		return
+	}
+
+	body, ok := h.bind(c)
+	if !ok {
+		return
+	}
+
+	budget := Budget{OwnerID: owner}
+	body.apply(&budget)
+	if err := h.store.Create(&budget); err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("failed to create budget: %s", body.Name)
+		errs.JSON(c, http.StatusInternalServerError, ErrCreateFailed)
+		return
+	}
+
+	c.JSON(http.StatusCreated, budget)
+}
+
+func (h *handler) List(c *gin.Context) {
+	owner, ok := users.ID(c)
+	if !ok {
+		logs.Error().Context(c.Request.Context()).Msg("no authenticated user")
+		errs.JSON(c, http.StatusUnauthorized, middleware.ErrUnauthorized)
+		return
+	}
+
+	budgets, err := h.store.List(owner)
+	if err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msg("failed to list budgets")
+		errs.JSON(c, http.StatusInternalServerError, ErrListFailed)
+		return
+	}
+
+	c.JSON(http.StatusOK, budgets)
+}
+
+func (h *handler) Update(c *gin.Context) {
+	owner, ok := users.ID(c)
+	if !ok {
+		logs.Error().Context(c.Request.Context()).Msg("no authenticated user")
+		errs.JSON(c, http.StatusUnauthorized, middleware.ErrUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("invalid id: %s", c.Param("id"))
+		errs.JSON(c, http.StatusBadRequest, ErrInvalidID)
+		return
+	}
+
+	body, ok := h.bind(c)
+	if !ok {
+		return
+	}
+
+	budget, err := h.store.Get(owner, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("budget not found: %d", id)
+		errs.JSON(c, http.StatusNotFound, ErrNotFound)
+		return
+	}
+	if err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("failed to get budget: %d", id)
+		errs.JSON(c, http.StatusInternalServerError, ErrUpdateFailed)
+		return
+	}
+
+	body.apply(&budget)
+	if err := h.store.Update(&budget); err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("failed to update budget: %d", id)
+		errs.JSON(c, http.StatusInternalServerError, ErrUpdateFailed)
+		return
+	}
+
+	c.JSON(http.StatusOK, budget)
+}
+
+func (h *handler) Delete(c *gin.Context) {
+	owner, ok := users.ID(c)
+	if !ok {
+		logs.Error().Context(c.Request.Context()).Msg("no authenticated user")
+		errs.JSON(c, http.StatusUnauthorized, middleware.ErrUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("invalid id: %s", c.Param("id"))
+		errs.JSON(c, http.StatusBadRequest, ErrInvalidID)
+		return
+	}
+
+	deleted, err := h.store.Delete(owner, id)
+	if err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("failed to delete budget: %d", id)
+		errs.JSON(c, http.StatusInternalServerError, ErrDeleteFailed)
+		return
+	}
+
+	if !deleted {
+		logs.Error().Context(c.Request.Context()).Msgf("budget not found: %d", id)
+		errs.JSON(c, http.StatusNotFound, ErrNotFound)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// Status reports every budget of the user in its current period, in the
+// configured time zone.
+func (h *handler) Status(c *gin.Context) {
+	owner, ok := users.ID(c)
+	if !ok {
+		logs.Error().Context(c.Request.Context()).Msg("no authenticated user")
+		errs.JSON(c, http.StatusUnauthorized, middleware.ErrUnauthorized)
+		return
+	}
+
+	budgets, err := h.store.List(owner)
+	if err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msg("failed to list budgets")
+		errs.JSON(c, http.StatusInternalServerError, ErrStatusFailed)
+		return
+	}
+
+	var table *rates.Table
+	if len(budgets) > 0 {
+		list, err := h.rates.List()
+		if err != nil {
+			logs.Error().Context(c.Request.Context()).Err(err).Msg("failed to load exchange rates")
+			errs.JSON(c, http.StatusInternalServerError, expenses.ErrConversionFailed)
+			return
+		}
+		table = rates.NewTable(h.currency, list)
+	}
+
+	at := time.Now().In(h.timeZone)
+	windows := make([]Window, len(budgets))
+	for i, budget := range budgets {
+		from, start, end := window(budget, at)
+		windows[i] = Window{BudgetID: budget.ID, Tags: budget.Tags, From: from, Start: start, End: end}
+	}
+
+	totals, err := h.store.Totals(owner, windows)
+	if err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msg("failed to total expenses of budgets")
+		errs.JSON(c, http.StatusInternalServerError, ErrStatusFailed)
+		return
+	}
+
+	statuses := make([]Status, 0, len(budgets))
+	for i, budget := range budgets {
+		// Each total is converted with the rate on the last day of its
+		// period that has passed.
+		current, previous := rates.DateOf(at), rates.DateOf(windows[i].Start.Add(-time.Nanosecond))
+		var spent, spentBefore expenses.Money
+		for _, total := range totals {
+			if total.BudgetID != budget.ID {
+				continue
+			}
+
+			on := current
+			if total.Previous {
+				on = previous
+			}
+			amount, err := expenses.ConvertAmount(table, total.Amount, total.Currency, budget.Currency, on)
+			if err != nil {
+				logs.Error().Context(c.Request.Context()).Err(err).Msgf("failed to convert %s to %s", total.Currency, budget.Currency)
+				errs.JSON(c, http.StatusUnprocessableEntity, err)
+				return
+			}
+
+			if total.Previous {
+				spentBefore += amount
+			} else {
+				spent += amount
+			}
+		}
+
+		statuses = append(statuses, newStatus(budget, at, spent, spentBefore))
+	}
+
+	c.JSON(http.StatusOK, statuses)
+}
+
+// bind reads and checks the request body, responding with an error when
+// it is invalid.
+func (h *handler) bind(c *gin.Context) (RequestBody, bool) {
+	var body RequestBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msg("failed to bind request body")
+		errs.JSON(c, http.StatusBadRequest, err)
+		return body, false
+	}
+
+	if body.Currency == "" {
+		body.Currency = h.currency
+	}
+
+	if err := body.validate(); err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("invalid request body: %v", body)
+		errs.JSON(c, http.StatusBadRequest, err)
+		return body, false
+	}
+
+	return body, true
+}
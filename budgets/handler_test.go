@@ -0,0 +1,261 @@
+//go:build unit
+// +build unit
+
+package budgets_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tirathawat/assessment/budgets"
+	"github.com/tirathawat/assessment/config"
+	"github.com/tirathawat/assessment/expenses"
+	"github.com/tirathawat/assessment/rates"
+	"github.com/tirathawat/assessment/testutils"
+	"github.com/tirathawat/assessment/users"
+	"gorm.io/gorm"
+)
+
+const ownerID = 7
+
+var cfg = &config.AppConfig{DefaultCurrency: "THB", TimeZone: config.Location{Location: time.UTC}}
+
+type MockStore struct {
+	budgets []budgets.Budget
+	totals  []budgets.Total
+	windows []budgets.Window
+	err     error
+}
+
+func (m *MockStore) Create(budget *budgets.Budget) error {
+	return m.err
+}
+
+func (m *MockStore) List(owner int) ([]budgets.Budget, error) {
+	return m.budgets, m.err
+}
+
+func (m *MockStore) Get(owner, id int) (budgets.Budget, error) {
+	for _, budget := range m.budgets {
+		if budget.ID == id {
+			return budget, m.err
+		}
+	}
+
+	return budgets.Budget{}, gorm.ErrRecordNotFound
+}
+
+func (m *MockStore) Update(budget *budgets.Budget) error {
+	return m.err
+}
+
+func (m *MockStore) Delete(owner, id int) (bool, error) {
+	_, err := m.Get(owner, id)
+	return err == nil, m.err
+}
+
+func (m *MockStore) Totals(owner int, windows []budgets.Window) ([]budgets.Total, error) {
+	m.windows = windows
+	return m.totals, m.err
+}
+
+type MockRates struct {
+	rates []rates.Rate
+}
+
+func (m *MockRates) List() ([]rates.Rate, error) {
+	return m.rates, nil
+}
+
+func (m *MockRates) Upsert(list []rates.Rate) error {
+	return nil
+}
+
+func asUser(handler gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(users.IDKey, ownerID)
+		handler(c)
+	}
+}
+
+func TestCreate(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		store          *MockStore
+		wantStatusCode int
+	}{
+		{
+			name:           "Should return 201 when create budget successfully",
+			body:           `{"name": "food", "tags": ["food"], "period": "monthly", "limit": 8000}`,
+			store:          &MockStore{},
+			wantStatusCode: http.StatusCreated,
+		},
+		{
+			name:           "Should return 400 when period is unknown",
+			body:           `{"name": "food", "tags": ["food"], "period": "daily", "limit": 8000}`,
+			store:          &MockStore{},
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:           "Should return 400 when limit is negative",
+			body:           `{"name": "food", "tags": ["food"], "period": "monthly", "limit": -1}`,
+			store:          &MockStore{},
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:           "Should return 400 when there are no tags",
+			body:           `{"name": "food", "tags": [], "period": "monthly", "limit": 8000}`,
+			store:          &MockStore{},
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:           "Should return 500 when store error",
+			body:           `{"name": "food", "tags": ["food"], "period": "monthly", "limit": 8000}`,
+			store:          &MockStore{err: errors.New("error")},
+			wantStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			httpRequest := &testutils.HTTPRequest{Method: http.MethodPost, Endpoint: "budgets", Body: test.body}
+
+			budget := &budgets.Budget{}
+			statusCode, err := httpRequest.MakeTestHTTPRequest(asUser(budgets.NewHandler(test.store, &MockRates{}, cfg).Create), budget)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if statusCode != test.wantStatusCode {
+				t.Errorf("unexpected status code: got %v want %v", statusCode, test.wantStatusCode)
+			}
+
+			if statusCode == http.StatusCreated && (budget.Currency != "THB" || budget.Limit != 800000) {
+				t.Errorf("unexpected budget: got %+v", budget)
+			}
+		})
+	}
+}
+
+func TestDelete(t *testing.T) {
+	tests := []struct {
+		name           string
+		id             string
+		wantStatusCode int
+	}{
+		{name: "Should return 204 when delete budget successfully", id: "1", wantStatusCode: http.StatusNoContent},
+		{name: "Should return 404 when budget not found", id: "2", wantStatusCode: http.StatusNotFound},
+		{name: "Should return 400 when id is not a number", id: "invalid", wantStatusCode: http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			store := &MockStore{budgets: []budgets.Budget{{ID: 1}}}
+			httpRequest := &testutils.HTTPRequest{Method: http.MethodDelete, Endpoint: "budgets"}
+
+			resp, err := httpRequest.MakeTestHTTPResponse(asUser(budgets.NewHandler(store, &MockRates{}, cfg).Delete), gin.Param{Key: "id", Value: test.id})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if resp.Code != test.wantStatusCode {
+				t.Errorf("unexpected status code: got %v want %v", resp.Code, test.wantStatusCode)
+			}
+		})
+	}
+}
+
+func TestStatus(t *testing.T) {
+	budget := budgets.Budget{ID: 1, Tags: []string{"food"}, Period: budgets.PeriodMonthly, Limit: 800000, Currency: "THB"}
+	rollover := budgets.Budget{ID: 2, Tags: []string{"travel"}, Period: budgets.PeriodMonthly, Limit: 800000, Currency: "THB", Rollover: true}
+	now := time.Now()
+
+	tests := []struct {
+		name           string
+		cfg            *config.AppConfig
+		store          *MockStore
+		rates          []rates.Rate
+		wantStatusCode int
+		wantSpent      expenses.Money
+		wantRolledOver expenses.Money
+	}{
+		{
+			name: "Should return 200 with spending converted to the budget currency",
+			store: &MockStore{budgets: []budgets.Budget{budget}, totals: []budgets.Total{
+				{BudgetID: 1, Currency: "THB", Amount: 100000},
+				{BudgetID: 1, Currency: "USD", Amount: 1000},
+				{BudgetID: 3, Currency: "THB", Amount: 500000},
+			}},
+			rates:          []rates.Rate{{Currency: "USD", EffectiveOn: rates.DateOf(now.AddDate(0, -2, 0)), Rate: 35}},
+			wantStatusCode: http.StatusOK,
+			wantSpent:      135000,
+		},
+		{
+			name: "Should roll over what was left of the previous period",
+			store: &MockStore{budgets: []budgets.Budget{rollover}, totals: []budgets.Total{
+				{BudgetID: 2, Currency: "THB", Amount: 100000},
+				{BudgetID: 2, Currency: "THB", Previous: true, Amount: 300000},
+			}},
+			wantStatusCode: http.StatusOK,
+			wantSpent:      100000,
+			wantRolledOver: 500000,
+		},
+		{
+			name: "Should fall back to UTC without a configured time zone",
+			cfg:  &config.AppConfig{DefaultCurrency: "THB"},
+			store: &MockStore{budgets: []budgets.Budget{budget}, totals: []budgets.Total{
+				{BudgetID: 1, Currency: "THB", Amount: 100000},
+			}},
+			wantStatusCode: http.StatusOK,
+			wantSpent:      100000,
+		},
+		{
+			name: "Should return 422 when a rate is missing",
+			store: &MockStore{budgets: []budgets.Budget{budget}, totals: []budgets.Total{
+				{BudgetID: 1, Currency: "USD", Amount: 1000},
+			}},
+			wantStatusCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "Should return 500 when store error",
+			store:          &MockStore{err: errors.New("error")},
+			wantStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			httpRequest := &testutils.HTTPRequest{Method: http.MethodGet, Endpoint: "budgets/status"}
+			cfg := cfg
+			if test.cfg != nil {
+				cfg = test.cfg
+			}
+
+			var statuses []budgets.Status
+			statusCode, err := httpRequest.MakeTestHTTPRequest(asUser(budgets.NewHandler(test.store, &MockRates{test.rates}, cfg).Status), &statuses)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if statusCode != test.wantStatusCode {
+				t.Errorf("unexpected status code: got %v want %v", statusCode, test.wantStatusCode)
+			}
+
+			if statusCode != http.StatusOK {
+				return
+			}
+
+			if len(statuses) != 1 || statuses[0].Spent != test.wantSpent || statuses[0].RolledOver != test.wantRolledOver {
+				t.Errorf("unexpected statuses: got %+v want spent %v rolled over %v", statuses, test.wantSpent, test.wantRolledOver)
+			}
+
+			if w := test.store.windows; len(w) != 1 || w[0].BudgetID != statuses[0].ID || !w[0].Start.Equal(statuses[0].PeriodStart) || !w[0].End.Equal(statuses[0].PeriodEnd) {
+				t.Errorf("unexpected windows: got %+v", w)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package budgets
+
+import (
+	"math"
+	"time"
+
+	"github.com/tirathawat/assessment/expenses"
+)
+
+// Status is how a budget is tracking in its current period.
+type Status struct {
+	Budget
+	PeriodStart time.Time      `json:"period_start"`
+	PeriodEnd   time.Time      `json:"period_end"`
+	RolledOver  expenses.Money `json:"rolled_over"`
+	Available   expenses.Money `json:"available"`
+	Spent       expenses.Money `json:"spent"`
+	Remaining   expenses.Money `json:"remaining"`
+	PercentUsed float64        `json:"percent_used"`
+	Projected   expenses.Money `json:"projected"`
+	OverBudget  bool           `json:"over_budget"`
+	// ProjectedOver warns that the budget will be exceeded if spending
+	// carries on at the same pace until the end of the period.
+	ProjectedOver bool `json:"projected_over"`
+}
+
+// window is the span of spending a status needs: the current period, and
+// the previous one when the budget rolls over.
+func window(budget Budget, now time.Time) (from, start, end time.Time) {
+	start, end = period(budget.Period, now)
+	from = start
+	if rollsOver(budget, start) {
+		from, _ = period(budget.Period, start.Add(-time.Nanosecond))
+	}
+
+	return from, start, end
+}
+
+// rollsOver reports whether the budget carries over from the period before
+// start. Nothing carries over into the period the budget was created in.
+func rollsOver(budget Budget, start time.Time) bool {
+	return budget.Rollover && budget.CreatedAt.Before(start)
+}
+
+// newStatus computes the status at now from what was spent in the current
+// and, for budgets that roll over, the previous period.
+func newStatus(budget Budget, now time.Time, spent, previous expenses.Money) Status {
+	start, end := period(budget.Period, now)
+	status := Status{Budget: budget, PeriodStart: start, PeriodEnd: end, Available: budget.Limit, Spent: spent}
+
+	if rollsOver(budget, start) && previous < budget.Limit {
+		status.RolledOver = budget.Limit - previous
+		status.Available += status.RolledOver
+	}
+
+	status.Remaining = status.Available - spent
+	status.PercentUsed = math.Round(float64(spent)/float64(status.Available)*100*100) / 100
+	status.OverBudget = spent > status.Available
+
+	status.Projected = spent
+	if elapsed := now.Sub(start); elapsed > 0 && elapsed < end.Sub(start) {
+		unit := float64(expenses.CurrencyUnit(budget.Currency))
+		projected := float64(spent) * float64(end.Sub(start)) / float64(elapsed)
+		status.Projected = expenses.Money(math.Round(projected/unit) * unit)
+	}
+	status.ProjectedOver = status.Projected > status.Available
+
+	return status
+}
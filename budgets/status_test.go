@@ -0,0 +1,145 @@
+//go:build unit
+// +build unit
+
+package budgets
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tirathawat/assessment/expenses"
+)
+
+func TestPeriod(t *testing.T) {
+	loc, err := time.LoadLocation("Asia/Bangkok")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	at := time.Date(2023, time.February, 15, 23, 30, 0, 0, loc)
+	tests := []struct {
+		name      string
+		period    string
+		wantStart time.Time
+		wantEnd   time.Time
+	}{
+		{
+			name:      "Should start weeks on Monday",
+			period:    PeriodWeekly,
+			wantStart: time.Date(2023, time.February, 13, 0, 0, 0, 0, loc),
+			wantEnd:   time.Date(2023, time.February, 20, 0, 0, 0, 0, loc),
+		},
+		{
+			name:      "Should cover the calendar month",
+			period:    PeriodMonthly,
+			wantStart: time.Date(2023, time.February, 1, 0, 0, 0, 0, loc),
+			wantEnd:   time.Date(2023, time.March, 1, 0, 0, 0, 0, loc),
+		},
+		{
+			name:      "Should cover the calendar year",
+			period:    PeriodYearly,
+			wantStart: time.Date(2023, time.January, 1, 0, 0, 0, 0, loc),
+			wantEnd:   time.Date(2024, time.January, 1, 0, 0, 0, 0, loc),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			start, end := period(test.period, at)
+			if !start.Equal(test.wantStart) || !end.Equal(test.wantEnd) {
+				t.Errorf("unexpected period: got %v - %v want %v - %v", start, end, test.wantStart, test.wantEnd)
+			}
+		})
+	}
+
+	t.Run("Should start the week on the Monday before a Sunday", func(t *testing.T) {
+		start, _ := period(PeriodWeekly, time.Date(2023, time.February, 19, 12, 0, 0, 0, loc))
+		if want := time.Date(2023, time.February, 13, 0, 0, 0, 0, loc); !start.Equal(want) {
+			t.Errorf("unexpected start: got %v want %v", start, want)
+		}
+	})
+}
+
+func TestNewStatus(t *testing.T) {
+	created := time.Date(2023, time.January, 10, 0, 0, 0, 0, time.UTC)
+	// Ten days into a 30 day month.
+	now := time.Date(2023, time.April, 11, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		budget        Budget
+		spent         expenses.Money
+		previous      expenses.Money
+		wantAvailable expenses.Money
+		wantPercent   float64
+		wantProjected expenses.Money
+		wantOver      bool
+		wantProjOver  bool
+	}{
+		{
+			name:          "Should project spending to the end of the period",
+			budget:        Budget{Period: PeriodMonthly, Limit: 800000, Currency: "THB", CreatedAt: created},
+			spent:         300000,
+			wantAvailable: 800000,
+			wantPercent:   37.5,
+			wantProjected: 900000,
+			wantProjOver:  true,
+		},
+		{
+			name:          "Should add what was left last period when rolling over",
+			budget:        Budget{Period: PeriodMonthly, Limit: 800000, Currency: "THB", Rollover: true, CreatedAt: created},
+			spent:         300000,
+			previous:      600000,
+			wantAvailable: 1000000,
+			wantPercent:   30,
+			wantProjected: 900000,
+		},
+		{
+			name:          "Should not roll over overspending",
+			budget:        Budget{Period: PeriodMonthly, Limit: 800000, Currency: "THB", Rollover: true, CreatedAt: created},
+			spent:         900000,
+			previous:      900000,
+			wantAvailable: 800000,
+			wantPercent:   112.5,
+			wantProjected: 2700000,
+			wantOver:      true,
+			wantProjOver:  true,
+		},
+		{
+			name:          "Should not roll over into the period the budget was created in",
+			budget:        Budget{Period: PeriodMonthly, Limit: 800000, Currency: "THB", Rollover: true, CreatedAt: now.Add(-time.Hour)},
+			spent:         100000,
+			previous:      0,
+			wantAvailable: 800000,
+			wantPercent:   12.5,
+			wantProjected: 300000,
+		},
+		{
+			name:          "Should round projections to the currency unit",
+			budget:        Budget{Period: PeriodMonthly, Limit: 1000000, Currency: "JPY", CreatedAt: created},
+			spent:         100100,
+			wantAvailable: 1000000,
+			wantPercent:   10.01,
+			wantProjected: 300300,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := newStatus(test.budget, now, test.spent, test.previous)
+
+			if got.Available != test.wantAvailable || got.PercentUsed != test.wantPercent || got.Projected != test.wantProjected {
+				t.Errorf("unexpected status: got available %v percent %v projected %v want %v %v %v",
+					got.Available, got.PercentUsed, got.Projected, test.wantAvailable, test.wantPercent, test.wantProjected)
+			}
+
+			if got.OverBudget != test.wantOver || got.ProjectedOver != test.wantProjOver {
+				t.Errorf("unexpected flags: got %v %v want %v %v", got.OverBudget, got.ProjectedOver, test.wantOver, test.wantProjOver)
+			}
+
+			if got.Remaining != got.Available-test.spent {
+				t.Errorf("unexpected remaining: got %v want %v", got.Remaining, got.Available-test.spent)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package budgets
+
+import (
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/tirathawat/assessment/expenses"
+	"gorm.io/gorm"
+)
+
+type Store interface {
+	Create(budget *Budget) error
+	List(owner int) ([]Budget, error)
+	// Get returns the owner's budget, or gorm.ErrRecordNotFound.
+	Get(owner, id int) (Budget, error)
+	Update(budget *Budget) error
+	// Delete reports false when the owner has no budget with the id.
+	Delete(owner, id int) (bool, error)
+	// Totals sums the owner's expenses in each window that carry any of its
+	// tags, by currency and by whether they were spent before Start.
+	Totals(owner int, windows []Window) ([]Total, error)
+}
+
+// Window is the span of spending the status of a budget needs: [From, End),
+// where spending before Start belongs to the previous period.
+type Window struct {
+	BudgetID   int
+	Tags       []string
+	From       time.Time
+	Start, End time.Time
+}
+
+// Total is what was spent in one currency towards a budget in its current
+// or previous period.
+type Total struct {
+	BudgetID int
+	Currency string
+	Previous bool
+	Amount   expenses.Money
+}
+
+type store struct {
+	db *gorm.DB
+}
+
+func NewStore(db *gorm.DB) Store {
+	return &store{db}
+}
+
+func (s *store) Create(budget *Budget) error {
+	return s.db.Create(budget).Error
+}
+
+func (s *store) List(owner int) ([]Budget, error) {
+	budgets := []Budget{}
+	err := s.db.Where("owner_id = ?", owner).Order("id").Find(&budgets).Error
+	return budgets, err
+}
+
+func (s *store) Get(owner, id int) (Budget, error) {
+	var budget Budget
+	err := s.db.Where("owner_id = ?", owner).First(&budget, "id = ?", id).Error
+	return budget, err
+}
+
+func (s *store) Update(budget *Budget) error {
+	return s.db.Save(budget).Error
+}
+
+func (s *store) Delete(owner, id int) (bool, error) {
+	result := s.db.Where("owner_id = ?", owner).Delete(&Budget{}, "id = ?", id)
+	return result.RowsAffected > 0, result.Error
+}
+
+// Totals joins the expenses to the windows passed as a VALUES list, so the
+// statuses of all budgets take a single query.
+func (s *store) Totals(owner int, windows []Window) ([]Total, error) {
+	totals := []Total{}
+	if len(windows) == 0 {
+		return totals, nil
+	}
+
+	rows := make([]string, len(windows))
+	args := make([]interface{}, 0, len(windows)*5)
+	for i, w := range windows {
+		rows[i] = "(?::int, ?::text[], ?::timestamptz, ?::timestamptz, ?::timestamptz)"
+		args = append(args, w.BudgetID, pq.StringArray(w.Tags), w.From, w.Start, w.End)
+	}
+
+	err := s.db.Model(&expenses.Expense{}).
+		Select("w.budget_id, expenses.currency, expenses.spent_at < w.start_at AS previous, SUM(expenses.amount) AS amount").
+		Joins("JOIN (VALUES "+strings.Join(rows, ", ")+") AS w (budget_id, tags, from_at, start_at, end_at) "+
+			"ON expenses.tags && w.tags AND expenses.spent_at >= w.from_at AND expenses.spent_at < w.end_at", args...).
+		Where("expenses.owner_id = ?", owner).
+		Group("w.budget_id, expenses.currency, previous").
+		Scan(&totals).Error
+	return totals, err
+}
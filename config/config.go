@@ -1,6 +1,8 @@
 package config
 
 import (
+	"time"
+
 	"github.com/joho/godotenv"
 	"github.com/kelseyhightower/envconfig"
 )
@@ -8,6 +10,72 @@ import (
 type AppConfig struct {
 	Port        string `envconfig:"PORT" required:"true"`
 	DatabaseURL string `envconfig:"DATABASE_URL" required:"true"`
+
+	TimeZone        Location `envconfig:"TIME_ZONE" default:"Asia/Bangkok"`
+	DefaultCurrency string   `envconfig:"DEFAULT_CURRENCY" default:"THB"`
+	DefaultLanguage string   `envconfig:"DEFAULT_LANGUAGE" default:"en"`
+	RatesFile       string   `envconfig:"RATES_FILE"`
+
+	TrashRetention time.Duration `envconfig:"TRASH_RETENTION" default:"720h"`
+	PurgeInterval  time.Duration `envconfig:"PURGE_INTERVAL" default:"1h"`
+
+	RecurringInterval time.Duration `envconfig:"RECURRING_INTERVAL" default:"1m"`
+
+	ExportTagSeparator string `envconfig:"EXPORT_TAG_SEPARATOR" default:", "`
+	ImportMaxRows      int    `envconfig:"IMPORT_MAX_ROWS" default:"10000"`
+
+	AttachmentDir     string   `envconfig:"ATTACHMENT_DIR" default:"data/attachments"`
+	AttachmentMaxSize int64    `envconfig:"ATTACHMENT_MAX_SIZE" default:"10485760"`
+	AttachmentTypes   []string `envconfig:"ATTACHMENT_TYPES" default:"image/jpeg,image/png,image/gif,image/webp,application/pdf"`
+
+	IdempotencyTTL time.Duration `envconfig:"IDEMPOTENCY_TTL" default:"24h"`
+
+	LogLevel  string `envconfig:"LOG_LEVEL" default:"info"`
+	LogFormat string `envconfig:"LOG_FORMAT" default:"json"`
+	LogOutput string `envconfig:"LOG_OUTPUT" default:"stdout"`
+	// LogContent keeps the nested content object of the original log
+	// format alongside the top-level keys.
+	LogContent bool `envconfig:"LOG_CONTENT" default:"true"`
+
+	// The file logs are written to when LOG_OUTPUT is file. It is rotated
+	// once it reaches LOG_MAX_SIZE bytes or has been open for LOG_MAX_AGE,
+	// keeping LOG_MAX_BACKUPS rotated files.
+	LogFile       string        `envconfig:"LOG_FILE" default:"data/logs/app.log"`
+	LogMaxSize    int64         `envconfig:"LOG_MAX_SIZE" default:"104857600"`
+	LogMaxAge     time.Duration `envconfig:"LOG_MAX_AGE" default:"24h"`
+	LogMaxBackups int           `envconfig:"LOG_MAX_BACKUPS" default:"7"`
+
+	JWTSecret        string `envconfig:"JWT_SECRET"`
+	JWTPublicKeyFile string `envconfig:"JWT_PUBLIC_KEY_FILE"`
+	JWTIssuer        string `envconfig:"JWT_ISSUER"`
+	LegacyTokens     bool   `envconfig:"AUTH_LEGACY_TOKENS" default:"false"`
+	LegacySubject    string `envconfig:"AUTH_LEGACY_SUBJECT" default:"legacy"`
+	// AdminSubjects are granted the admin scope whatever their token says.
+	AdminSubjects []string `envconfig:"ADMIN_SUBJECTS"`
+}
+
+// Location is an IANA time zone name such as Asia/Bangkok.
+type Location struct {
+	*time.Location
+}
+
+func (l *Location) Decode(value string) error {
+	loc, err := time.LoadLocation(value)
+	if err != nil {
+		return err
+	}
+
+	l.Location = loc
+	return nil
+}
+
+// Get returns the location, or UTC when none was configured.
+func (l Location) Get() *time.Location {
+	if l.Location == nil {
+		return time.UTC
+	}
+
+	return l.Location
 }
 
 func NewAppConfig() *AppConfig {
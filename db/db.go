@@ -1,14 +1,19 @@
 package db
 
 import (
+	"time"
+
 	"github.com/tirathawat/assessment/config"
-	"github.com/tirathawat/assessment/expenses"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
 func NewConnection(dbConfig *config.AppConfig) (db *gorm.DB, cleanup func(), err error) {
-	db, err = gorm.Open(postgres.Open(dbConfig.DatabaseURL), &gorm.Config{})
+	db, err = gorm.Open(postgres.Open(dbConfig.DatabaseURL), &gorm.Config{
+		NowFunc: func() time.Time {
+			return time.Now().Truncate(time.Microsecond)
+		},
+	})
 	if err != nil {
 		return nil, nil, err
 	}
@@ -18,6 +23,6 @@ func NewConnection(dbConfig *config.AppConfig) (db *gorm.DB, cleanup func(), err
 		_ = sqlDB.Close()
 	}
 
-	err = db.AutoMigrate(&expenses.Expense{})
+	err = migrate(db, dbConfig)
 	return db, cleanup, err
 }
@@ -0,0 +1,98 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/tirathawat/assessment/apikeys"
+	"github.com/tirathawat/assessment/attachments"
+	"github.com/tirathawat/assessment/budgets"
+	"github.com/tirathawat/assessment/config"
+	"github.com/tirathawat/assessment/expenses"
+	"github.com/tirathawat/assessment/groups"
+	"github.com/tirathawat/assessment/idempotency"
+	"github.com/tirathawat/assessment/rates"
+	"github.com/tirathawat/assessment/recurring"
+	"github.com/tirathawat/assessment/users"
+	"gorm.io/gorm"
+)
+
+// migrations run before AutoMigrate to convert data that AutoMigrate
+// cannot change safely on its own. Each one must be safe to run again.
+var migrations = []func(db *gorm.DB) error{
+	migrateAmountToNumeric,
+}
+
+// models are the tables kept up to date by AutoMigrate. Tables come after
+// the tables they reference.
+var models = []interface{}{
+	&users.User{},
+	&expenses.Expense{},
+	&expenses.History{},
+	&attachments.Blob{},
+	&attachments.Attachment{},
+	&rates.Rate{},
+	&idempotency.Record{},
+	&apikeys.Key{},
+	&groups.Group{},
+	&groups.Member{},
+	&groups.Split{},
+	&groups.Share{},
+	&budgets.Budget{},
+	&recurring.Template{},
+	&recurring.Occurrence{},
+}
+
+func migrate(db *gorm.DB, cfg *config.AppConfig) error {
+	for _, migration := range migrations {
+		if err := migration(db); err != nil {
+			return err
+		}
+	}
+
+	if err := db.AutoMigrate(models...); err != nil {
+		return err
+	}
+
+	return assignLegacyOwner(db, cfg.LegacySubject)
+}
+
+// assignLegacyOwner gives expenses created before they had owners to the
+// user that legacy tokens authenticate as, so they stay reachable.
+func assignLegacyOwner(db *gorm.DB, subject string) error {
+	var count int64
+	err := db.Unscoped().Model(&expenses.Expense{}).Where("owner_id IS NULL OR owner_id = 0").Count(&count).Error
+	if err != nil || count == 0 {
+		return err
+	}
+
+	user, err := users.NewStore(db).FindOrCreate(subject)
+	if err != nil {
+		return err
+	}
+
+	return db.Unscoped().Model(&expenses.Expense{}).
+		Where("owner_id IS NULL OR owner_id = 0").
+		Update("owner_id", user.ID).Error
+}
+
+// migrateAmountToNumeric converts amounts that older versions stored as
+// float into exact numeric values rounded to expenses.MoneyScale places.
+func migrateAmountToNumeric(db *gorm.DB) error {
+	migrator := db.Migrator()
+	if !migrator.HasTable(&expenses.Expense{}) {
+		return nil
+	}
+
+	columnTypes, err := migrator.ColumnTypes(&expenses.Expense{})
+	if err != nil {
+		return err
+	}
+
+	for _, columnType := range columnTypes {
+		if columnType.Name() == "amount" && columnType.DatabaseTypeName() == "float8" {
+			return db.Exec(fmt.Sprintf("ALTER TABLE expenses ALTER COLUMN amount TYPE numeric(19,%[1]d) USING round(amount::numeric, %[1]d)", expenses.MoneyScale)).Error
+		}
+	}
+
+	return nil
+}
@@ -1,20 +1,70 @@
 package di
 
 import (
+	"github.com/gin-gonic/gin"
+	"github.com/tirathawat/assessment/apikeys"
+	"github.com/tirathawat/assessment/attachments"
+	"github.com/tirathawat/assessment/budgets"
 	"github.com/tirathawat/assessment/config"
 	"github.com/tirathawat/assessment/db"
+	"github.com/tirathawat/assessment/errs"
 	"github.com/tirathawat/assessment/expenses"
+	"github.com/tirathawat/assessment/groups"
+	"github.com/tirathawat/assessment/idempotency"
 	"github.com/tirathawat/assessment/logs"
+	"github.com/tirathawat/assessment/middleware"
+	"github.com/tirathawat/assessment/rates"
+	"github.com/tirathawat/assessment/recurring"
 	"github.com/tirathawat/assessment/router"
 	"github.com/tirathawat/assessment/srv"
+	"github.com/tirathawat/assessment/users"
 )
 
 func InitializeApplication() (server srv.Server, cleanup func(), err error) {
-	logs.Setup()
 	appConfig := config.NewAppConfig()
+	if err := logs.Setup(appConfig); err != nil {
+		return nil, func() {}, err
+	}
+
 	database, cleanup, err := db.NewConnection(appConfig)
+	rateStore := rates.NewStore(database)
+	if err == nil && appConfig.RatesFile != "" {
+		err = rates.Seed(rateStore, appConfig.RatesFile)
+	}
+
+	var auth gin.HandlerFunc
+	if err == nil {
+		auth, err = middleware.Auth(appConfig)
+	}
+
+	var localize gin.HandlerFunc
+	if err == nil {
+		localize, err = errs.Localize(appConfig.DefaultLanguage)
+	}
+
+	expenseDB := expenses.NewDB(database)
+	keyStore := apikeys.NewStore(database)
+	recurringStore := recurring.NewStore(database)
+	attachmentStore := attachments.NewStore(database)
+	attachmentStorage := attachments.NewLocalStorage(appConfig.AttachmentDir)
 	server = srv.NewServer(appConfig, &router.Handlers{
-		Expense: expenses.NewHandler(database),
-	})
+		Expense:     expenses.NewHandler(expenseDB, appConfig),
+		Attachment:  attachments.NewHandler(attachmentStore, attachmentStorage, appConfig),
+		Group:       groups.NewHandler(groups.NewStore(database), appConfig),
+		Budget:      budgets.NewHandler(budgets.NewStore(database), rateStore, appConfig),
+		Recurring:   recurring.NewHandler(recurringStore, appConfig),
+		Rate:        rates.NewHandler(rateStore),
+		Key:         apikeys.NewHandler(keyStore),
+		Log:         logs.NewHandler(),
+		Idempotency: idempotency.Middleware(idempotency.NewStore(database), appConfig.IdempotencyTTL),
+		APIKey:      apikeys.Middleware(keyStore),
+		Auth:        auth,
+		User:        users.Middleware(users.NewStore(database)),
+		Language:    localize,
+	},
+		expenses.NewPurger(expenseDB, appConfig),
+		attachments.NewCollector(attachmentStore, attachmentStorage, appConfig),
+		recurring.NewScheduler(recurringStore, appConfig),
+	)
 	return server, cleanup, err
 }
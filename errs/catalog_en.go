@@ -0,0 +1,140 @@
+package errs
+
+// english is the catalog of English messages.
+var english = map[string]string{
+	// Messages of validation and malformed requests.
+	"validation.required":    "{field} is required",
+	"validation.max":         "{field} must be at most {param}",
+	"validation.max.string":  "{field} must be at most {param} characters",
+	"validation.max.items":   "{field} must be at most {param} items",
+	"validation.min":         "{field} must be at least {param}",
+	"validation.min.string":  "{field} must be at least {param} characters",
+	"validation.min.items":   "{field} must be at least {param} items",
+	"validation.len":         "{field} must be exactly {param}",
+	"validation.len.string":  "{field} must be exactly {param} characters",
+	"validation.len.items":   "{field} must be exactly {param} items",
+	"validation.gt":          "{field} must be greater than {param}",
+	"validation.gte":         "{field} must be at least {param}",
+	"validation.lt":          "{field} must be less than {param}",
+	"validation.lte":         "{field} must be at most {param}",
+	"validation.email":       "invalid email format",
+	"validation.oneof":       "{field} must be one of {param}",
+	"validation.iso4217":     "{field} must be an ISO 4217 currency code",
+	"validation.invalid":     "{field} is not valid",
+	"validation.type":        "{field} must be {type}",
+	"type.string":            "a string",
+	"type.boolean":           "a boolean",
+	"type.integer":           "an integer",
+	"type.number":            "a number",
+	"type.array":             "an array",
+	"type.object":            "an object",
+	"type.value":             "a valid value",
+	"request.invalid_fields": "request has invalid fields",
+	"request.syntax":         "invalid JSON at offset {offset}",
+	"request.empty":          "request body is required",
+	"request.unexpected_end": "invalid JSON: unexpected end of input",
+
+	// Registered errors, by code.
+	"AMOUNT_NOT_POSITIVE":              "amount must be positive",
+	"AMOUNT_PRECISION":                 "amount has more decimal places than the currency allows",
+	"AMOUNT_TOO_MANY_DECIMALS":         "amount cannot have more than 2 decimal places",
+	"API_KEY_ADMIN_BY_KEY":             "an API key cannot create API keys with the admin scope",
+	"API_KEY_CREATE_FAILED":            "failed to create API key",
+	"API_KEY_LIST_FAILED":              "failed to list API keys",
+	"API_KEY_LOOKUP_FAILED":            "failed to look up API key",
+	"API_KEY_NOT_FOUND":                "API key not found",
+	"API_KEY_REVOKE_FAILED":            "failed to revoke API key",
+	"API_KEY_SCOPE_DENIED":             "cannot create an API key with scopes you were not granted",
+	"ATTACHMENT_DELETE_FAILED":         "failed to delete attachment",
+	"ATTACHMENT_DOWNLOAD_FAILED":       "failed to download attachment",
+	"ATTACHMENT_LIST_FAILED":           "failed to list attachments",
+	"ATTACHMENT_NOT_FOUND":             "attachment not found",
+	"ATTACHMENT_TOO_LARGE":             "attachment is too large",
+	"ATTACHMENT_UPLOAD_FAILED":         "failed to upload attachment",
+	"BUDGET_CREATE_FAILED":             "failed to create budget",
+	"BUDGET_DELETE_FAILED":             "failed to delete budget",
+	"BUDGET_LIST_FAILED":               "failed to list budgets",
+	"BUDGET_NOT_FOUND":                 "budget not found",
+	"BUDGET_STATUS_FAILED":             "failed to compute budget status",
+	"BUDGET_UPDATE_FAILED":             "failed to update budget",
+	"CURRENCY_MISMATCH":                "expense currency must match the group currency",
+	"CURSOR_WITH_OFFSET":               "cursor cannot be combined with offset",
+	"DUPLICATE_MEMBER":                 "member names must be unique within a group",
+	"ENDS_BEFORE_STARTS":               "ends_at must not be before starts_at",
+	"EXCHANGE_RATE_LIST_FAILED":        "failed to list exchange rates",
+	"EXCHANGE_RATE_NOT_FOUND":          "exchange rate not found",
+	"EXCHANGE_RATE_SAVE_FAILED":        "failed to save exchange rates",
+	"EXPENSE_CONVERSION_FAILED":        "failed to convert expenses",
+	"EXPENSE_CREATE_FAILED":            "failed to create expense",
+	"EXPENSE_DELETE_FAILED":            "failed to delete expense",
+	"EXPENSE_EXPORT_FAILED":            "failed to export expenses",
+	"EXPENSE_GET_FAILED":               "failed to get expense",
+	"EXPENSE_HISTORY_FAILED":           "failed to get expense history",
+	"EXPENSE_IMPORT_FAILED":            "failed to import expenses",
+	"EXPENSE_LIST_FAILED":              "failed to list expenses",
+	"EXPENSE_NOT_FOUND":                "expense not found",
+	"EXPENSE_NOT_IN_TRASH":             "expense not found in trash",
+	"EXPENSE_PRECONDITION_FAILED":      "expense has been modified, fetch it again before updating",
+	"EXPENSE_RESTORE_FAILED":           "failed to restore expense",
+	"EXPENSE_REVERT_FAILED":            "failed to revert expense",
+	"EXPENSE_SPLIT_LOCKED":             "the amount and currency of an expense shared in a group cannot be changed",
+	"EXPENSE_SUMMARY_FAILED":           "failed to summarize expenses",
+	"EXPENSE_UPDATE_FAILED":            "failed to update expense",
+	"EXPENSE_VERSION_CONFLICT":         "expense was modified by another request",
+	"EXPENSE_VERSION_NOT_FOUND":        "version not found in expense history",
+	"GROUP_BALANCES_FAILED":            "failed to compute balances",
+	"GROUP_CREATE_FAILED":              "failed to create group",
+	"GROUP_EXPENSE_ADD_FAILED":         "failed to add expense",
+	"GROUP_GET_FAILED":                 "failed to get group",
+	"GROUP_LIST_FAILED":                "failed to list groups",
+	"GROUP_NOT_FOUND":                  "group not found",
+	"IDEMPOTENCY_KEY_IN_PROGRESS":      "a request with this idempotency key is still in progress",
+	"IDEMPOTENCY_KEY_REUSED":           "idempotency key was already used with a different request",
+	"IDEMPOTENCY_STORE_FAILED":         "failed to store idempotency key",
+	"ID_MISMATCH":                      "id mismatch",
+	"IMPORT_EMPTY":                     "nothing to import",
+	"IMPORT_TOO_LARGE":                 "too many rows to import",
+	"INSUFFICIENT_SCOPE":               "insufficient scope",
+	"INVALID_AMOUNT":                   "invalid amount",
+	"INVALID_AMOUNT_RANGE":             "min_amount cannot be greater than max_amount",
+	"INVALID_API_KEY":                  "invalid API key",
+	"INVALID_BUDGET_LIMIT":             "limit must be a positive amount",
+	"INVALID_CURSOR":                   "invalid cursor",
+	"INVALID_DATE":                     "invalid date",
+	"INVALID_DATE_RANGE":               "from cannot be after to",
+	"INVALID_ID":                       "invalid id",
+	"INVALID_IDEMPOTENCY_KEY":          "idempotency key must be between 1 and 255 characters",
+	"INVALID_IMPORT_HEADER":            "csv header must include title, amount, note and tags",
+	"INVALID_LOG_LEVEL":                "invalid log level",
+	"INVALID_PATCH":                    "invalid patch document",
+	"INVALID_SORT":                     "invalid sort",
+	"INVALID_TIME_ZONE":                "invalid time zone",
+	"INVALID_TOKEN":                    "invalid token",
+	"MEMBER_ADD_FAILED":                "failed to add member",
+	"MEMBER_EXISTS":                    "a member with this name is already in the group",
+	"MISSING_FILE":                     "multipart field \"file\" is required",
+	"PATCH_CONFLICT":                   "patch cannot be applied to expense",
+	"RECURRING_TEMPLATE_CREATE_FAILED": "failed to create recurring template",
+	"RECURRING_TEMPLATE_DELETE_FAILED": "failed to delete recurring template",
+	"RECURRING_TEMPLATE_GET_FAILED":    "failed to get recurring template",
+	"RECURRING_TEMPLATE_LIST_FAILED":   "failed to list recurring templates",
+	"RECURRING_TEMPLATE_NOT_FOUND":     "recurring template not found",
+	"SPENT_AT_IN_FUTURE":               "spent_at cannot be in the future",
+	"SPENT_AT_REQUIRED":                "spent_at cannot be removed",
+	"SPLIT_AMOUNT_NOT_POSITIVE":        "group expenses must have a positive amount",
+	"SPLIT_DUPLICATE_MEMBER":           "a member can appear only once in a split",
+	"SPLIT_EMPTY":                      "split must give a share to at least one member",
+	"SPLIT_NEGATIVE":                   "split values cannot be negative",
+	"SPLIT_PERCENT_TOTAL":              "split percentages must add up to 100",
+	"SPLIT_TOTAL_MISMATCH":             "split amounts must add up to the expense amount",
+	"STARTS_TOO_EARLY":                 "starts_at must not be more than 90 days in the past",
+	"TOKEN_INVALID_ISSUER":             "token has an invalid issuer",
+	"TOKEN_MISSING_EXPIRY":             "token has no expiry",
+	"TOKEN_MISSING_SUBJECT":            "token has no subject",
+	"UNAUTHORIZED":                     "unauthorized",
+	"UNKNOWN_MEMBER":                   "payer and split members must belong to the group",
+	"UNSUPPORTED_ATTACHMENT_TYPE":      "attachment type is not allowed",
+	"UNSUPPORTED_IMPORT_FORMAT":        "unsupported import format, use csv or jsonl",
+	"UNSUPPORTED_PATCH":                "unsupported patch content type, use application/merge-patch+json or application/json-patch+json",
+	"USER_LOOKUP_FAILED":               "failed to look up user",
+}
@@ -0,0 +1,140 @@
+package errs
+
+// thai is the catalog of Thai messages.
+var thai = map[string]string{
+	// Messages of validation and malformed requests.
+	"validation.required":    "ต้องระบุ {field}",
+	"validation.max":         "{field} ต้องไม่เกิน {param}",
+	"validation.max.string":  "{field} ต้องยาวไม่เกิน {param} ตัวอักษร",
+	"validation.max.items":   "{field} ต้องมีไม่เกิน {param} รายการ",
+	"validation.min":         "{field} ต้องไม่น้อยกว่า {param}",
+	"validation.min.string":  "{field} ต้องยาวอย่างน้อย {param} ตัวอักษร",
+	"validation.min.items":   "{field} ต้องมีอย่างน้อย {param} รายการ",
+	"validation.len":         "{field} ต้องเท่ากับ {param}",
+	"validation.len.string":  "{field} ต้องยาว {param} ตัวอักษรพอดี",
+	"validation.len.items":   "{field} ต้องมี {param} รายการพอดี",
+	"validation.gt":          "{field} ต้องมากกว่า {param}",
+	"validation.gte":         "{field} ต้องไม่น้อยกว่า {param}",
+	"validation.lt":          "{field} ต้องน้อยกว่า {param}",
+	"validation.lte":         "{field} ต้องไม่เกิน {param}",
+	"validation.email":       "รูปแบบอีเมลไม่ถูกต้อง",
+	"validation.oneof":       "{field} ต้องเป็นค่าใดค่าหนึ่งใน {param}",
+	"validation.iso4217":     "{field} ต้องเป็นรหัสสกุลเงินตาม ISO 4217",
+	"validation.invalid":     "{field} ไม่ถูกต้อง",
+	"validation.type":        "{field} ต้องเป็น{type}",
+	"type.string":            "ข้อความ",
+	"type.boolean":           "ค่าจริงหรือเท็จ",
+	"type.integer":           "จำนวนเต็ม",
+	"type.number":            "ตัวเลข",
+	"type.array":             "อาร์เรย์",
+	"type.object":            "ออบเจกต์",
+	"type.value":             "ค่าที่ถูกต้อง",
+	"request.invalid_fields": "คำขอมีข้อมูลที่ไม่ถูกต้อง",
+	"request.syntax":         "JSON ไม่ถูกต้องที่ตำแหน่ง {offset}",
+	"request.empty":          "ต้องระบุเนื้อหาของคำขอ",
+	"request.unexpected_end": "JSON ไม่ถูกต้อง: ข้อมูลสิ้นสุดก่อนกำหนด",
+
+	// Registered errors, by code.
+	"AMOUNT_NOT_POSITIVE":              "จำนวนเงินต้องมากกว่าศูนย์",
+	"AMOUNT_PRECISION":                 "จำนวนเงินมีทศนิยมมากกว่าที่สกุลเงินรองรับ",
+	"AMOUNT_TOO_MANY_DECIMALS":         "จำนวนเงินมีทศนิยมได้ไม่เกิน 2 ตำแหน่ง",
+	"API_KEY_ADMIN_BY_KEY":             "API key ไม่สามารถสร้าง API key ที่มีสิทธิ์ผู้ดูแลระบบได้",
+	"API_KEY_CREATE_FAILED":            "สร้าง API key ไม่สำเร็จ",
+	"API_KEY_LIST_FAILED":              "ดึงรายการ API key ไม่สำเร็จ",
+	"API_KEY_LOOKUP_FAILED":            "ตรวจสอบ API key ไม่สำเร็จ",
+	"API_KEY_NOT_FOUND":                "ไม่พบ API key",
+	"API_KEY_REVOKE_FAILED":            "เพิกถอน API key ไม่สำเร็จ",
+	"API_KEY_SCOPE_DENIED":             "ไม่สามารถสร้าง API key ที่มีสิทธิ์เกินกว่าที่คุณได้รับ",
+	"ATTACHMENT_DELETE_FAILED":         "ลบไฟล์แนบไม่สำเร็จ",
+	"ATTACHMENT_DOWNLOAD_FAILED":       "ดาวน์โหลดไฟล์แนบไม่สำเร็จ",
+	"ATTACHMENT_LIST_FAILED":           "ดึงรายการไฟล์แนบไม่สำเร็จ",
+	"ATTACHMENT_NOT_FOUND":             "ไม่พบไฟล์แนบ",
+	"ATTACHMENT_TOO_LARGE":             "ไฟล์แนบมีขนาดใหญ่เกินไป",
+	"ATTACHMENT_UPLOAD_FAILED":         "อัปโหลดไฟล์แนบไม่สำเร็จ",
+	"BUDGET_CREATE_FAILED":             "สร้างงบประมาณไม่สำเร็จ",
+	"BUDGET_DELETE_FAILED":             "ลบงบประมาณไม่สำเร็จ",
+	"BUDGET_LIST_FAILED":               "ดึงรายการงบประมาณไม่สำเร็จ",
+	"BUDGET_NOT_FOUND":                 "ไม่พบงบประมาณ",
+	"BUDGET_STATUS_FAILED":             "คำนวณสถานะงบประมาณไม่สำเร็จ",
+	"BUDGET_UPDATE_FAILED":             "แก้ไขงบประมาณไม่สำเร็จ",
+	"CURRENCY_MISMATCH":                "สกุลเงินของค่าใช้จ่ายต้องตรงกับสกุลเงินของกลุ่ม",
+	"CURSOR_WITH_OFFSET":               "ใช้ cursor ร่วมกับ offset ไม่ได้",
+	"DUPLICATE_MEMBER":                 "ชื่อสมาชิกในกลุ่มต้องไม่ซ้ำกัน",
+	"ENDS_BEFORE_STARTS":               "ends_at ต้องไม่อยู่ก่อน starts_at",
+	"EXCHANGE_RATE_LIST_FAILED":        "ดึงรายการอัตราแลกเปลี่ยนไม่สำเร็จ",
+	"EXCHANGE_RATE_NOT_FOUND":          "ไม่พบอัตราแลกเปลี่ยน",
+	"EXCHANGE_RATE_SAVE_FAILED":        "บันทึกอัตราแลกเปลี่ยนไม่สำเร็จ",
+	"EXPENSE_CONVERSION_FAILED":        "แปลงสกุลเงินของค่าใช้จ่ายไม่สำเร็จ",
+	"EXPENSE_CREATE_FAILED":            "สร้างค่าใช้จ่ายไม่สำเร็จ",
+	"EXPENSE_DELETE_FAILED":            "ลบค่าใช้จ่ายไม่สำเร็จ",
+	"EXPENSE_EXPORT_FAILED":            "ส่งออกค่าใช้จ่ายไม่สำเร็จ",
+	"EXPENSE_GET_FAILED":               "ดึงข้อมูลค่าใช้จ่ายไม่สำเร็จ",
+	"EXPENSE_HISTORY_FAILED":           "ดึงประวัติของค่าใช้จ่ายไม่สำเร็จ",
+	"EXPENSE_IMPORT_FAILED":            "นำเข้าค่าใช้จ่ายไม่สำเร็จ",
+	"EXPENSE_LIST_FAILED":              "ดึงรายการค่าใช้จ่ายไม่สำเร็จ",
+	"EXPENSE_NOT_FOUND":                "ไม่พบค่าใช้จ่าย",
+	"EXPENSE_NOT_IN_TRASH":             "ไม่พบค่าใช้จ่ายในถังขยะ",
+	"EXPENSE_PRECONDITION_FAILED":      "ค่าใช้จ่ายถูกแก้ไขไปแล้ว กรุณาดึงข้อมูลใหม่ก่อนแก้ไข",
+	"EXPENSE_RESTORE_FAILED":           "กู้คืนค่าใช้จ่ายไม่สำเร็จ",
+	"EXPENSE_REVERT_FAILED":            "ย้อนค่าใช้จ่ายกลับไม่สำเร็จ",
+	"EXPENSE_SPLIT_LOCKED":             "ไม่สามารถเปลี่ยนจำนวนเงินหรือสกุลเงินของค่าใช้จ่ายที่หารกันในกลุ่มได้",
+	"EXPENSE_SUMMARY_FAILED":           "สรุปค่าใช้จ่ายไม่สำเร็จ",
+	"EXPENSE_UPDATE_FAILED":            "แก้ไขค่าใช้จ่ายไม่สำเร็จ",
+	"EXPENSE_VERSION_CONFLICT":         "ค่าใช้จ่ายถูกแก้ไขโดยคำขออื่น",
+	"EXPENSE_VERSION_NOT_FOUND":        "ไม่พบเวอร์ชันนี้ในประวัติของค่าใช้จ่าย",
+	"GROUP_BALANCES_FAILED":            "คำนวณยอดคงเหลือไม่สำเร็จ",
+	"GROUP_CREATE_FAILED":              "สร้างกลุ่มไม่สำเร็จ",
+	"GROUP_EXPENSE_ADD_FAILED":         "เพิ่มค่าใช้จ่ายไม่สำเร็จ",
+	"GROUP_GET_FAILED":                 "ดึงข้อมูลกลุ่มไม่สำเร็จ",
+	"GROUP_LIST_FAILED":                "ดึงรายการกลุ่มไม่สำเร็จ",
+	"GROUP_NOT_FOUND":                  "ไม่พบกลุ่ม",
+	"IDEMPOTENCY_KEY_IN_PROGRESS":      "คำขอที่ใช้ idempotency key นี้ยังดำเนินการอยู่",
+	"IDEMPOTENCY_KEY_REUSED":           "idempotency key นี้ถูกใช้กับคำขออื่นไปแล้ว",
+	"IDEMPOTENCY_STORE_FAILED":         "บันทึก idempotency key ไม่สำเร็จ",
+	"ID_MISMATCH":                      "id ไม่ตรงกัน",
+	"IMPORT_EMPTY":                     "ไม่มีข้อมูลให้นำเข้า",
+	"IMPORT_TOO_LARGE":                 "จำนวนแถวที่นำเข้ามากเกินไป",
+	"INSUFFICIENT_SCOPE":               "สิทธิ์ไม่เพียงพอ",
+	"INVALID_AMOUNT":                   "จำนวนเงินไม่ถูกต้อง",
+	"INVALID_AMOUNT_RANGE":             "min_amount ต้องไม่มากกว่า max_amount",
+	"INVALID_API_KEY":                  "API key ไม่ถูกต้อง",
+	"INVALID_BUDGET_LIMIT":             "วงเงินต้องเป็นจำนวนที่มากกว่าศูนย์",
+	"INVALID_CURSOR":                   "cursor ไม่ถูกต้อง",
+	"INVALID_DATE":                     "วันที่ไม่ถูกต้อง",
+	"INVALID_DATE_RANGE":               "from ต้องไม่อยู่หลัง to",
+	"INVALID_ID":                       "id ไม่ถูกต้อง",
+	"INVALID_IDEMPOTENCY_KEY":          "idempotency key ต้องยาว 1 ถึง 255 ตัวอักษร",
+	"INVALID_IMPORT_HEADER":            "หัวตาราง csv ต้องมี title, amount, note และ tags",
+	"INVALID_LOG_LEVEL":                "ระดับของ log ไม่ถูกต้อง",
+	"INVALID_PATCH":                    "เอกสาร patch ไม่ถูกต้อง",
+	"INVALID_SORT":                     "การเรียงลำดับไม่ถูกต้อง",
+	"INVALID_TIME_ZONE":                "เขตเวลาไม่ถูกต้อง",
+	"INVALID_TOKEN":                    "โทเคนไม่ถูกต้อง",
+	"MEMBER_ADD_FAILED":                "เพิ่มสมาชิกไม่สำเร็จ",
+	"MEMBER_EXISTS":                    "มีสมาชิกชื่อนี้อยู่ในกลุ่มแล้ว",
+	"MISSING_FILE":                     "ต้องแนบไฟล์ในฟิลด์ \"file\" ของ multipart",
+	"PATCH_CONFLICT":                   "ไม่สามารถใช้ patch กับค่าใช้จ่ายนี้ได้",
+	"RECURRING_TEMPLATE_CREATE_FAILED": "สร้างรายการค่าใช้จ่ายประจำไม่สำเร็จ",
+	"RECURRING_TEMPLATE_DELETE_FAILED": "ลบรายการค่าใช้จ่ายประจำไม่สำเร็จ",
+	"RECURRING_TEMPLATE_GET_FAILED":    "ดึงข้อมูลรายการค่าใช้จ่ายประจำไม่สำเร็จ",
+	"RECURRING_TEMPLATE_LIST_FAILED":   "ดึงรายการค่าใช้จ่ายประจำไม่สำเร็จ",
+	"RECURRING_TEMPLATE_NOT_FOUND":     "ไม่พบรายการค่าใช้จ่ายประจำ",
+	"SPENT_AT_IN_FUTURE":               "spent_at ต้องไม่เป็นเวลาในอนาคต",
+	"SPENT_AT_REQUIRED":                "ลบ spent_at ไม่ได้",
+	"SPLIT_AMOUNT_NOT_POSITIVE":        "ค่าใช้จ่ายของกลุ่มต้องมีจำนวนเงินมากกว่าศูนย์",
+	"SPLIT_DUPLICATE_MEMBER":           "สมาชิกแต่ละคนปรากฏในการแบ่งได้เพียงครั้งเดียว",
+	"SPLIT_EMPTY":                      "การแบ่งต้องมีสมาชิกอย่างน้อยหนึ่งคนที่ได้รับส่วนแบ่ง",
+	"SPLIT_NEGATIVE":                   "ค่าในการแบ่งต้องไม่ติดลบ",
+	"SPLIT_PERCENT_TOTAL":              "เปอร์เซ็นต์ในการแบ่งต้องรวมกันได้ 100",
+	"SPLIT_TOTAL_MISMATCH":             "จำนวนเงินในการแบ่งต้องรวมกันเท่ากับจำนวนเงินของค่าใช้จ่าย",
+	"STARTS_TOO_EARLY":                 "starts_at ต้องไม่ย้อนหลังเกิน 90 วัน",
+	"TOKEN_INVALID_ISSUER":             "ผู้ออกโทเคนไม่ถูกต้อง",
+	"TOKEN_MISSING_EXPIRY":             "โทเคนไม่มีวันหมดอายุ",
+	"TOKEN_MISSING_SUBJECT":            "โทเคนไม่มี subject",
+	"UNAUTHORIZED":                     "ไม่ได้รับอนุญาต",
+	"UNKNOWN_MEMBER":                   "ผู้จ่ายและสมาชิกในการแบ่งต้องอยู่ในกลุ่ม",
+	"UNSUPPORTED_ATTACHMENT_TYPE":      "ไม่อนุญาตให้แนบไฟล์ประเภทนี้",
+	"UNSUPPORTED_IMPORT_FORMAT":        "ไม่รองรับรูปแบบไฟล์นำเข้านี้ กรุณาใช้ csv หรือ jsonl",
+	"UNSUPPORTED_PATCH":                "ไม่รองรับ content type ของ patch นี้ กรุณาใช้ application/merge-patch+json หรือ application/json-patch+json",
+	"USER_LOOKUP_FAILED":               "ตรวจสอบผู้ใช้ไม่สำเร็จ",
+}
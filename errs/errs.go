@@ -1,41 +1,144 @@
 package errs
 
 import (
-	"fmt"
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
 
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
 	"github.com/iancoleman/strcase"
-	"gopkg.in/go-playground/validator.v9"
 )
 
+// Error turns err into a response body in English. Validation errors and
+// JSON type errors are keyed by field, any other error by "error".
 func Error(err error) map[string]interface{} {
+	return ErrorIn(English, err)
+}
+
+// ErrorIn is Error with the messages in lang.
+func ErrorIn(lang string, err error) map[string]interface{} {
 	result := make(map[string]interface{})
-	if errCast, ok := err.(validator.ValidationErrors); ok {
-		for _, e := range errCast {
-			result[strcase.ToLowerCamel(e.Field())] = validationErrorToText(e)
+
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		for _, e := range validationErrs {
+			result[field(e.Field())] = validationErrorToText(lang, e)
+		}
+
+		return result
+	}
+
+	// gin keeps only the failing elements of a slice, not their indexes,
+	// so the fields of every element are merged.
+	var sliceErrs binding.SliceValidationError
+	if errors.As(err, &sliceErrs) {
+		for _, e := range sliceErrs {
+			for name, message := range ErrorIn(lang, e) {
+				result[name] = message
+			}
 		}
 
 		return result
 	}
 
-	result["error"] = err.Error()
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		name := field(typeErr.Field)
+		result[name] = Translate(lang, "validation.type", map[string]string{
+			"field": name,
+			"type":  Translate(lang, jsonType(typeErr.Type), nil),
+		})
+		return result
+	}
+
+	var syntaxErr *json.SyntaxError
+	switch {
+	case errors.As(err, &syntaxErr):
+		result["error"] = Translate(lang, "request.syntax", map[string]string{"offset": strconv.FormatInt(syntaxErr.Offset, 10)})
+	case errors.Is(err, io.EOF):
+		result["error"] = Translate(lang, "request.empty", nil)
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		result["error"] = Translate(lang, "request.unexpected_end", nil)
+	default:
+		result["error"] = message(lang, err)
+	}
+
 	return result
 }
 
-func validationErrorToText(e validator.FieldError) string {
+// message translates a registered error, keeping whatever a wrapping error
+// added around its message. Other errors are returned as they are.
+func message(lang string, err error) string {
+	sentinel, e, ok := find(err)
+	if !ok {
+		return err.Error()
+	}
+
+	return strings.Replace(err.Error(), sentinel.Error(), Translate(lang, e.code, nil), 1)
+}
+
+// field converts a struct field name, or a dotted JSON path, to the lower
+// camel case used in responses, keeping any slice index.
+func field(name string) string {
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		index := ""
+		if at := strings.Index(part, "["); at >= 0 {
+			part, index = part[:at], part[at:]
+		}
+		parts[i] = strcase.ToLowerCamel(part) + index
+	}
+
+	return strings.Join(parts, ".")
+}
+
+func validationErrorToText(lang string, e validator.FieldError) string {
+	key := "validation." + e.Tag()
 	switch e.Tag() {
-	case "required":
-		return fmt.Sprintf("%s is required", strcase.ToLowerCamel(e.Field()))
-	case "max":
-		return fmt.Sprintf("%s cannot be longer than %s", strcase.ToLowerCamel(e.Field()), e.Param())
-	case "min":
-		return fmt.Sprintf("%s must be longer than %s", strcase.ToLowerCamel(e.Field()), e.Param())
-	case "email":
-		return "invalid email format"
-	case "len":
-		return fmt.Sprintf("%s must be %s characters long", strcase.ToLowerCamel(e.Field()), e.Param())
-	case "oneof":
-		return fmt.Sprintf("%s must be %s", strcase.ToLowerCamel(e.Field()), e.Param())
-	}
-
-	return fmt.Sprintf("%s is not valid", strcase.ToLowerCamel(e.Field()))
+	case "max", "min", "len":
+		key += unit(e.Kind())
+	case "required", "gt", "gte", "lt", "lte", "email", "oneof", "iso4217":
+	default:
+		key = "validation.invalid"
+	}
+
+	return Translate(lang, key, map[string]string{"field": field(e.Field()), "param": e.Param()})
+}
+
+// unit names what min, max and len count for a field of the kind.
+func unit(kind reflect.Kind) string {
+	switch kind {
+	case reflect.String:
+		return ".string"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return ".items"
+	}
+
+	return ""
+}
+
+// jsonType returns the catalog key naming the JSON type expected for a Go
+// type.
+func jsonType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "type.string"
+	case reflect.Bool:
+		return "type.boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "type.integer"
+	case reflect.Float32, reflect.Float64:
+		return "type.number"
+	case reflect.Slice, reflect.Array:
+		return "type.array"
+	case reflect.Map, reflect.Struct:
+		return "type.object"
+	}
+
+	return "type.value"
 }
@@ -0,0 +1,212 @@
+//go:build unit
+// +build unit
+
+package errs_test
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/tirathawat/assessment/errs"
+)
+
+type body struct {
+	Title    string   `json:"title" binding:"required,max=5"`
+	Note     string   `json:"note" binding:"omitempty,min=3"`
+	Code     string   `json:"code" binding:"omitempty,len=2"`
+	Amount   int      `json:"amount" binding:"omitempty,gt=0,lte=100"`
+	Quantity int      `json:"quantity" binding:"omitempty,gte=2,lt=10"`
+	Email    string   `json:"email" binding:"omitempty,email"`
+	Kind     string   `json:"kind" binding:"omitempty,oneof=food travel"`
+	Currency string   `json:"currency" binding:"omitempty,iso4217"`
+	SpentAt  string   `json:"spent_at" binding:"omitempty,datetime=2006-01-02"`
+	Tags     []string `json:"tags" binding:"omitempty,max=2,dive,min=2"`
+}
+
+func valid() body {
+	return body{Title: "lunch"}
+}
+
+func TestErrorValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		body func(b *body)
+		want map[string]interface{}
+	}{
+		{
+			name: "required",
+			body: func(b *body) { b.Title = "" },
+			want: map[string]interface{}{"title": "title is required"},
+		},
+		{
+			name: "max of a string",
+			body: func(b *body) { b.Title = "dinner" },
+			want: map[string]interface{}{"title": "title must be at most 5 characters"},
+		},
+		{
+			name: "max of a slice",
+			body: func(b *body) { b.Tags = []string{"food", "work", "trip"} },
+			want: map[string]interface{}{"tags": "tags must be at most 2 items"},
+		},
+		{
+			name: "min",
+			body: func(b *body) { b.Note = "ok" },
+			want: map[string]interface{}{"note": "note must be at least 3 characters"},
+		},
+		{
+			name: "min of a slice element",
+			body: func(b *body) { b.Tags = []string{"food", "a"} },
+			want: map[string]interface{}{"tags[1]": "tags[1] must be at least 2 characters"},
+		},
+		{
+			name: "len",
+			body: func(b *body) { b.Code = "abc" },
+			want: map[string]interface{}{"code": "code must be exactly 2 characters"},
+		},
+		{
+			name: "gt",
+			body: func(b *body) { b.Amount = -1 },
+			want: map[string]interface{}{"amount": "amount must be greater than 0"},
+		},
+		{
+			name: "lte",
+			body: func(b *body) { b.Amount = 101 },
+			want: map[string]interface{}{"amount": "amount must be at most 100"},
+		},
+		{
+			name: "gte",
+			body: func(b *body) { b.Quantity = 1 },
+			want: map[string]interface{}{"quantity": "quantity must be at least 2"},
+		},
+		{
+			name: "lt",
+			body: func(b *body) { b.Quantity = 10 },
+			want: map[string]interface{}{"quantity": "quantity must be less than 10"},
+		},
+		{
+			name: "email",
+			body: func(b *body) { b.Email = "alice" },
+			want: map[string]interface{}{"email": "invalid email format"},
+		},
+		{
+			name: "oneof",
+			body: func(b *body) { b.Kind = "rent" },
+			want: map[string]interface{}{"kind": "kind must be one of food travel"},
+		},
+		{
+			name: "iso4217",
+			body: func(b *body) { b.Currency = "ABC" },
+			want: map[string]interface{}{"currency": "currency must be an ISO 4217 currency code"},
+		},
+		{
+			name: "other tags",
+			body: func(b *body) { b.SpentAt = "yesterday" },
+			want: map[string]interface{}{"spentAt": "spentAt is not valid"},
+		},
+		{
+			name: "several fields",
+			body: func(b *body) { b.Title, b.Currency = "", "ABC" },
+			want: map[string]interface{}{
+				"title":    "title is required",
+				"currency": "currency must be an ISO 4217 currency code",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			b := valid()
+			test.body(&b)
+
+			err := binding.Validator.ValidateStruct(&b)
+			if err == nil {
+				t.Fatal("expected a validation error")
+			}
+
+			if got := errs.Error(err); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("unexpected error: got %v want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestErrorValidationOfSlice(t *testing.T) {
+	bodies := []body{valid(), {Title: ""}, {Title: "lunch", Currency: "ABC"}}
+
+	want := map[string]interface{}{
+		"title":    "title is required",
+		"currency": "currency must be an ISO 4217 currency code",
+	}
+	if got := errs.Error(binding.Validator.ValidateStruct(bodies)); !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected error: got %v want %v", got, want)
+	}
+}
+
+func TestErrorJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		want map[string]interface{}
+	}{
+		{
+			name: "Should key a string given for a number by field",
+			json: `{"title":"lunch","amount":"ten"}`,
+			want: map[string]interface{}{"amount": "amount must be an integer"},
+		},
+		{
+			name: "Should key a number given for a string by field",
+			json: `{"title":1}`,
+			want: map[string]interface{}{"title": "title must be a string"},
+		},
+		{
+			name: "Should key a string given for an array by field",
+			json: `{"tags":"food"}`,
+			want: map[string]interface{}{"tags": "tags must be an array"},
+		},
+		{
+			name: "Should convert the JSON name of a field",
+			json: `{"spent_at":true}`,
+			want: map[string]interface{}{"spentAt": "spentAt must be a string"},
+		},
+		{
+			name: "Should return the offset of a syntax error",
+			json: `{"title":"lunch",}`,
+			want: map[string]interface{}{"error": "invalid JSON at offset 18"},
+		},
+		{
+			name: "Should report a truncated body",
+			json: `{"title":"lunch"`,
+			want: map[string]interface{}{"error": "invalid JSON: unexpected end of input"},
+		},
+		{
+			name: "Should report an empty body",
+			json: ``,
+			want: map[string]interface{}{"error": "request body is required"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var b body
+			err := json.NewDecoder(strings.NewReader(test.json)).Decode(&b)
+			if err == nil {
+				t.Fatal("expected a decoding error")
+			}
+
+			if got := errs.Error(err); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("unexpected error: got %v want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestErrorOther(t *testing.T) {
+	want := map[string]interface{}{"error": "failed"}
+	if got := errs.Error(errors.New("failed")); !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected error: got %v want %v", got, want)
+	}
+}
@@ -0,0 +1,7 @@
+//go:build unit
+// +build unit
+
+package errs
+
+// Catalogs exposes the message catalogs to the tests of errs_test.
+var Catalogs = catalogs
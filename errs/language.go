@@ -0,0 +1,101 @@
+package errs
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/text/language"
+)
+
+// LanguageKey is the context key of the language responses are written in.
+const LanguageKey = "language"
+
+const (
+	English = "en"
+	Thai    = "th"
+)
+
+var ErrUnsupportedLanguage = errors.New("unsupported language")
+
+// catalogs holds the messages of each supported language by key. Registered
+// errors are keyed by their code.
+var catalogs = map[string]map[string]string{
+	English: english,
+	Thai:    thai,
+}
+
+// Languages returns the supported languages.
+func Languages() []string {
+	languages := make([]string, 0, len(catalogs))
+	for lang := range catalogs {
+		languages = append(languages, lang)
+	}
+	sort.Strings(languages)
+
+	return languages
+}
+
+// Translate returns the message of key in lang, with each {name} in it
+// replaced by args[name]. It falls back to English and then to the key
+// itself.
+func Translate(lang, key string, args map[string]string) string {
+	message, ok := catalogs[lang][key]
+	if !ok {
+		message, ok = english[key]
+	}
+	if !ok {
+		return key
+	}
+
+	for name, value := range args {
+		message = strings.ReplaceAll(message, "{"+name+"}", value)
+	}
+
+	return message
+}
+
+// Localize picks the language of the responses to a request from its
+// Accept-Language header, using fallback when it asks for none of the
+// supported languages.
+func Localize(fallback string) (gin.HandlerFunc, error) {
+	if _, ok := catalogs[fallback]; !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedLanguage, fallback)
+	}
+
+	// The matcher picks its first language when nothing matches.
+	languages := []string{fallback}
+	for _, lang := range Languages() {
+		if lang != fallback {
+			languages = append(languages, lang)
+		}
+	}
+
+	tags := make([]language.Tag, len(languages))
+	for i, lang := range languages {
+		tags[i] = language.Make(lang)
+	}
+	matcher := language.NewMatcher(tags)
+
+	return func(c *gin.Context) {
+		lang := fallback
+		if header := c.GetHeader("Accept-Language"); header != "" {
+			_, index := language.MatchStrings(matcher, header)
+			lang = languages[index]
+		}
+
+		c.Set(LanguageKey, lang)
+		c.Next()
+	}, nil
+}
+
+// Language returns the language picked by Localize, or English.
+func Language(c *gin.Context) string {
+	if lang := c.GetString(LanguageKey); lang != "" {
+		return lang
+	}
+
+	return English
+}
@@ -0,0 +1,154 @@
+//go:build unit
+// +build unit
+
+package errs_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/tirathawat/assessment/errs"
+
+	_ "github.com/tirathawat/assessment/apikeys"
+	_ "github.com/tirathawat/assessment/attachments"
+	_ "github.com/tirathawat/assessment/budgets"
+	"github.com/tirathawat/assessment/expenses"
+	_ "github.com/tirathawat/assessment/groups"
+	_ "github.com/tirathawat/assessment/idempotency"
+	_ "github.com/tirathawat/assessment/logs"
+	_ "github.com/tirathawat/assessment/middleware"
+	_ "github.com/tirathawat/assessment/rates"
+	_ "github.com/tirathawat/assessment/recurring"
+	_ "github.com/tirathawat/assessment/users"
+)
+
+func TestLocalize(t *testing.T) {
+	tests := []struct {
+		name     string
+		fallback string
+		header   string
+		want     string
+	}{
+		{name: "Should use the fallback without Accept-Language", fallback: errs.Thai, want: errs.Thai},
+		{name: "Should pick Thai", fallback: errs.English, header: "th-TH,th;q=0.9,en;q=0.8", want: errs.Thai},
+		{name: "Should pick English", fallback: errs.Thai, header: "en-US", want: errs.English},
+		{name: "Should pick the preferred supported language", fallback: errs.English, header: "fr-FR, th;q=0.5, en;q=0.2", want: errs.Thai},
+		{name: "Should use the fallback for an unsupported language", fallback: errs.Thai, header: "ja", want: errs.Thai},
+		{name: "Should use the fallback for a malformed header", fallback: errs.Thai, header: ";;;", want: errs.Thai},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			localize, err := errs.Localize(test.fallback)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			gin.SetMode(gin.TestMode)
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+			if test.header != "" {
+				c.Request.Header.Set("Accept-Language", test.header)
+			}
+
+			localize(c)
+
+			if got := errs.Language(c); got != test.want {
+				t.Errorf("unexpected language: got %v want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestLocalizeUnsupportedFallback(t *testing.T) {
+	if _, err := errs.Localize("fr"); !errors.Is(err, errs.ErrUnsupportedLanguage) {
+		t.Errorf("unexpected error: got %v want %v", err, errs.ErrUnsupportedLanguage)
+	}
+}
+
+func TestCatalogs(t *testing.T) {
+	codes := errs.Codes()
+	if len(codes) == 0 {
+		t.Fatal("expected registered error codes")
+	}
+
+	for _, lang := range errs.Languages() {
+		catalog := errs.Catalogs[lang]
+
+		t.Run(fmt.Sprintf("Should translate every error code in %s", lang), func(t *testing.T) {
+			for _, code := range codes {
+				if catalog[code] == "" {
+					t.Errorf("missing %s message for code %s", lang, code)
+				}
+			}
+		})
+
+		t.Run(fmt.Sprintf("Should have the same keys in %s as in English", lang), func(t *testing.T) {
+			for key := range errs.Catalogs[errs.English] {
+				if catalog[key] == "" {
+					t.Errorf("missing %s message for key %s", lang, key)
+				}
+			}
+			for key := range catalog {
+				if _, ok := errs.Catalogs[errs.English][key]; !ok {
+					t.Errorf("unknown %s message for key %s", lang, key)
+				}
+			}
+		})
+	}
+}
+
+func TestNewProblemInThai(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantDetail string
+		wantErrors map[string]interface{}
+	}{
+		{
+			name:       "Should translate a registered error and keep what wraps it",
+			err:        fmt.Errorf("%w: 42", errNotFound),
+			wantDetail: "ไม่พบค่าใช้จ่าย: 42",
+		},
+		{
+			name:       "Should translate a registered error wrapped with a prefix",
+			err:        fmt.Errorf("line 3: %w", errNotFound),
+			wantDetail: "line 3: ไม่พบค่าใช้จ่าย",
+		},
+		{
+			name:       "Should translate an amount with too many decimals",
+			err:        json.Unmarshal([]byte(`1.234`), new(expenses.Money)),
+			wantDetail: "จำนวนเงินมีทศนิยมได้ไม่เกิน 2 ตำแหน่ง",
+		},
+		{
+			name:       "Should translate validation messages",
+			err:        binding.Validator.ValidateStruct(&body{Title: "dinner", Currency: "ABC"}),
+			wantDetail: "คำขอมีข้อมูลที่ไม่ถูกต้อง",
+			wantErrors: map[string]interface{}{
+				"title":    "title ต้องยาวไม่เกิน 5 ตัวอักษร",
+				"currency": "currency ต้องเป็นรหัสสกุลเงินตาม ISO 4217",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			problem := errs.NewProblem(errs.Thai, http.StatusBadRequest, test.err)
+			if problem.Detail != test.wantDetail {
+				t.Errorf("unexpected detail: got %v want %v", problem.Detail, test.wantDetail)
+			}
+
+			for field, want := range test.wantErrors {
+				if got := problem.Errors[field]; got != want {
+					t.Errorf("unexpected error of %s: got %v want %v", field, got, want)
+				}
+			}
+		})
+	}
+}
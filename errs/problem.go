@@ -0,0 +1,96 @@
+package errs
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+const ContentType = "application/problem+json"
+
+const (
+	CodeValidationFailed = "VALIDATION_FAILED"
+	CodeMalformedRequest = "MALFORMED_REQUEST"
+)
+
+// Problem is an RFC 7807 problem details body, extended with a stable code
+// and the errors of each invalid field.
+type Problem struct {
+	Type     string                 `json:"type"`
+	Title    string                 `json:"title"`
+	Status   int                    `json:"status"`
+	Detail   string                 `json:"detail,omitempty"`
+	Instance string                 `json:"instance,omitempty"`
+	Code     string                 `json:"code"`
+	Errors   map[string]interface{} `json:"errors,omitempty"`
+}
+
+// NewProblem describes err in lang. A registered error uses its own status
+// and code; status applies to any other error, such as a binding error.
+func NewProblem(lang string, status int, err error) Problem {
+	problem := Problem{Type: "about:blank"}
+	if registered, code, ok := Lookup(err); ok {
+		problem.Status, problem.Code, problem.Detail = registered, code, message(lang, err)
+	} else {
+		problem.Status, problem.Code = status, codeOf(status)
+		switch {
+		case isValidation(err):
+			problem.Code = CodeValidationFailed
+			problem.Detail = Translate(lang, "request.invalid_fields", nil)
+			problem.Errors = ErrorIn(lang, err)
+		case isMalformed(err):
+			problem.Code = CodeMalformedRequest
+			problem.Detail, _ = ErrorIn(lang, err)["error"].(string)
+		case status < http.StatusInternalServerError:
+			problem.Detail = err.Error()
+		}
+	}
+
+	problem.Title = http.StatusText(problem.Status)
+	return problem
+}
+
+// JSON responds with err as an application/problem+json body in the
+// language of the request.
+func JSON(c *gin.Context, status int, err error) {
+	problem := NewProblem(Language(c), status, err)
+	problem.Instance = c.Request.URL.Path
+	c.Header("Content-Type", ContentType)
+	c.Header("Content-Language", Language(c))
+	c.JSON(problem.Status, problem)
+}
+
+// Abort responds like JSON and stops the remaining handlers.
+func Abort(c *gin.Context, status int, err error) {
+	c.Abort()
+	JSON(c, status, err)
+}
+
+func isValidation(err error) bool {
+	var validationErrs validator.ValidationErrors
+	var sliceErrs binding.SliceValidationError
+	var typeErr *json.UnmarshalTypeError
+	return errors.As(err, &validationErrs) || errors.As(err, &sliceErrs) ||
+		(errors.As(err, &typeErr) && typeErr.Field != "")
+}
+
+func isMalformed(err error) bool {
+	var syntaxErr *json.SyntaxError
+	return errors.As(err, &syntaxErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// codeOf derives a code from the status text, such as BAD_REQUEST.
+func codeOf(status int) string {
+	text := http.StatusText(status)
+	if text == "" {
+		return "ERROR"
+	}
+
+	return strings.ToUpper(strings.NewReplacer(" ", "_", "-", "_", "'", "").Replace(text))
+}
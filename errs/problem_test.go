@@ -0,0 +1,101 @@
+//go:build unit
+// +build unit
+
+package errs_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/tirathawat/assessment/errs"
+)
+
+var errNotFound = errs.New(http.StatusNotFound, "EXPENSE_NOT_FOUND", "expense not found")
+
+func TestNewProblem(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		err    error
+		want   errs.Problem
+	}{
+		{
+			name:   "Should use the status and code of a registered error",
+			status: http.StatusInternalServerError,
+			err:    errNotFound,
+			want:   errs.Problem{Type: "about:blank", Title: "Not Found", Status: http.StatusNotFound, Detail: "expense not found", Code: "EXPENSE_NOT_FOUND"},
+		},
+		{
+			name:   "Should find a registered error wrapped in another",
+			status: http.StatusInternalServerError,
+			err:    fmt.Errorf("%w: 42", errNotFound),
+			want:   errs.Problem{Type: "about:blank", Title: "Not Found", Status: http.StatusNotFound, Detail: "expense not found: 42", Code: "EXPENSE_NOT_FOUND"},
+		},
+		{
+			name:   "Should list the invalid fields of a validation error",
+			status: http.StatusBadRequest,
+			err:    binding.Validator.ValidateStruct(&body{}),
+			want: errs.Problem{Type: "about:blank", Title: "Bad Request", Status: http.StatusBadRequest, Detail: "request has invalid fields", Code: errs.CodeValidationFailed,
+				Errors: map[string]interface{}{"title": "title is required"}},
+		},
+		{
+			name:   "Should describe a malformed body",
+			status: http.StatusBadRequest,
+			err:    json.Unmarshal([]byte(`{"title":`), &body{}),
+			want:   errs.Problem{Type: "about:blank", Title: "Bad Request", Status: http.StatusBadRequest, Detail: "invalid JSON at offset 9", Code: errs.CodeMalformedRequest},
+		},
+		{
+			name:   "Should derive the code of any other error from the status",
+			status: http.StatusRequestEntityTooLarge,
+			err:    errors.New("http: request body too large"),
+			want:   errs.Problem{Type: "about:blank", Title: "Request Entity Too Large", Status: http.StatusRequestEntityTooLarge, Detail: "http: request body too large", Code: "REQUEST_ENTITY_TOO_LARGE"},
+		},
+		{
+			name:   "Should not expose the message of an unregistered server error",
+			status: http.StatusInternalServerError,
+			err:    errors.New("pq: connection refused"),
+			want:   errs.Problem{Type: "about:blank", Title: "Internal Server Error", Status: http.StatusInternalServerError, Code: "INTERNAL_SERVER_ERROR"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := errs.NewProblem(errs.English, test.status, test.err); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("unexpected problem: got %+v want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestJSON(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Request = httptest.NewRequest(http.MethodGet, "/expenses/42", nil)
+
+	errs.JSON(c, http.StatusInternalServerError, errNotFound)
+
+	if recorder.Code != http.StatusNotFound {
+		t.Errorf("unexpected status code: got %v want %v", recorder.Code, http.StatusNotFound)
+	}
+
+	if got := recorder.Header().Get("Content-Type"); got != errs.ContentType {
+		t.Errorf("unexpected content type: got %v want %v", got, errs.ContentType)
+	}
+
+	var problem errs.Problem
+	if err := json.Unmarshal(recorder.Body.Bytes(), &problem); err != nil {
+		t.Fatal(err)
+	}
+
+	if problem.Instance != "/expenses/42" || problem.Code != "EXPENSE_NOT_FOUND" {
+		t.Errorf("unexpected problem: got %+v", problem)
+	}
+}
@@ -0,0 +1,72 @@
+package errs
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+)
+
+type entry struct {
+	status int
+	code   string
+}
+
+var (
+	mu       sync.RWMutex
+	registry = map[error]entry{}
+)
+
+// New returns a sentinel error with the message, registered with the HTTP
+// status and the stable code clients can match on.
+func New(status int, code, message string) error {
+	err := errors.New(message)
+	Register(err, status, code)
+	return err
+}
+
+// Register maps err to the HTTP status and code responses use for it, and
+// for any error wrapping it.
+func Register(err error, status int, code string) {
+	mu.Lock()
+	defer mu.Unlock()
+	registry[err] = entry{status: status, code: code}
+}
+
+// Lookup returns the status and code of the first registered error in the
+// chain of err.
+func Lookup(err error) (status int, code string, ok bool) {
+	_, e, ok := find(err)
+	return e.status, e.code, ok
+}
+
+// Codes returns the code of every registered error.
+func Codes() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	seen := make(map[string]bool, len(registry))
+	codes := make([]string, 0, len(registry))
+	for _, e := range registry {
+		if !seen[e.code] {
+			seen[e.code] = true
+			codes = append(codes, e.code)
+		}
+	}
+
+	return codes
+}
+
+// find returns the first registered error in the chain of err.
+func find(err error) (error, entry, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	for ; err != nil; err = errors.Unwrap(err) {
+		if !reflect.TypeOf(err).Comparable() {
+			continue
+		}
+		if e, found := registry[err]; found {
+			return err, e, true
+		}
+	}
+
+	return nil, entry{}, false
+}
@@ -0,0 +1,67 @@
+package expenses
+
+import (
+	"math"
+	"time"
+
+	"github.com/tirathawat/assessment/rates"
+)
+
+// zeroDecimalCurrencies have no minor unit in ISO 4217, so their amounts
+// must be whole numbers.
+var zeroDecimalCurrencies = map[string]bool{
+	"BIF": true, "CLP": true, "DJF": true, "GNF": true, "ISK": true,
+	"JPY": true, "KMF": true, "KRW": true, "PYG": true, "RWF": true,
+	"UGX": true, "UYI": true, "VND": true, "VUV": true, "XAF": true,
+	"XOF": true, "XPF": true,
+}
+
+// CurrencyUnit is the smallest amount the currency can express.
+func CurrencyUnit(currency string) Money {
+	if zeroDecimalCurrencies[currency] {
+		return moneyUnit
+	}
+
+	return 1
+}
+
+func validateAmount(amount Money, currency string) error {
+	if amount%CurrencyUnit(currency) != 0 {
+		return ErrAmountPrecision
+	}
+
+	return nil
+}
+
+// Conversion is an amount expressed in a currency the client asked for.
+type Conversion struct {
+	Amount   Money  `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// Convert fills Converted on every expense using the rate effective on the
+// day the expense was spent in loc.
+func Convert(expenses []Expense, table *rates.Table, currency string, loc *time.Location) error {
+	for i, expense := range expenses {
+		amount, err := ConvertAmount(table, expense.Amount, expense.Currency, currency, rates.DateOf(expense.SpentAt.In(loc)))
+		if err != nil {
+			return err
+		}
+
+		expenses[i].Converted = &Conversion{Amount: amount, Currency: currency}
+	}
+
+	return nil
+}
+
+// ConvertAmount expresses amount in to with the rate effective on the day,
+// rounded to the smallest unit of to.
+func ConvertAmount(table *rates.Table, amount Money, from, to string, on rates.Date) (Money, error) {
+	factor, err := table.Factor(from, to, on)
+	if err != nil {
+		return 0, err
+	}
+
+	unit := float64(CurrencyUnit(to))
+	return Money(math.Round(float64(amount)*factor/unit) * unit), nil
+}
@@ -0,0 +1,58 @@
+package expenses
+
+import "gorm.io/gorm"
+
+// DB is the subset of gorm used by the handler. Methods that build a query
+// return DB instead of *gorm.DB so the whole chain can be mocked in tests.
+type DB interface {
+	Create(value interface{}) *gorm.DB
+	CreateInBatches(value interface{}, batchSize int) *gorm.DB
+	First(dest interface{}, conds ...interface{}) *gorm.DB
+	Save(value interface{}) *gorm.DB
+	Find(dest interface{}, conds ...interface{}) *gorm.DB
+	Delete(value interface{}, conds ...interface{}) *gorm.DB
+	Update(column string, value interface{}) *gorm.DB
+	Count(count *int64) *gorm.DB
+	Scan(dest interface{}) *gorm.DB
+	FindInBatches(dest interface{}, batchSize int, fc func(tx *gorm.DB, batch int) error) *gorm.DB
+	Model(value interface{}) DB
+	Where(query interface{}, args ...interface{}) DB
+	Select(query interface{}, args ...interface{}) DB
+	Unscoped() DB
+	Scopes(funcs ...func(*gorm.DB) *gorm.DB) DB
+	Transaction(fc func(tx DB) error) error
+}
+
+type gormDB struct {
+	*gorm.DB
+}
+
+func NewDB(db *gorm.DB) DB {
+	return &gormDB{db}
+}
+
+func (db *gormDB) Model(value interface{}) DB {
+	return &gormDB{db.DB.Model(value)}
+}
+
+func (db *gormDB) Where(query interface{}, args ...interface{}) DB {
+	return &gormDB{db.DB.Where(query, args...)}
+}
+
+func (db *gormDB) Select(query interface{}, args ...interface{}) DB {
+	return &gormDB{db.DB.Select(query, args...)}
+}
+
+func (db *gormDB) Unscoped() DB {
+	return &gormDB{db.DB.Unscoped()}
+}
+
+func (db *gormDB) Scopes(funcs ...func(*gorm.DB) *gorm.DB) DB {
+	return &gormDB{db.DB.Scopes(funcs...)}
+}
+
+func (db *gormDB) Transaction(fc func(tx DB) error) error {
+	return db.DB.Transaction(func(tx *gorm.DB) error {
+		return fc(&gormDB{tx})
+	})
+}
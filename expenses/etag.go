@@ -0,0 +1,108 @@
+package expenses
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tirathawat/assessment/errs"
+)
+
+const (
+	ETagHeader        = "ETag"
+	IfMatchHeader     = "If-Match"
+	IfNoneMatchHeader = "If-None-Match"
+)
+
+var (
+	ErrPreconditionFailed = errs.New(http.StatusPreconditionFailed, "EXPENSE_PRECONDITION_FAILED", "expense has been modified, fetch it again before updating")
+	ErrVersionConflict    = errs.New(http.StatusConflict, "EXPENSE_VERSION_CONFLICT", "expense was modified by another request")
+)
+
+// ETag identifies the stored version of the expense. It changes on every
+// update, so it can be sent back in If-Match to detect lost updates.
+func (e Expense) ETag() string {
+	return fmt.Sprintf(`"%d"`, e.Version)
+}
+
+// matchETag reports whether an If-Match or If-None-Match header lists etag.
+// With strong comparison, as If-Match requires, weak validators never match;
+// otherwise they are compared by their opaque tag only.
+func matchETag(header, etag string, strong bool) bool {
+	for _, value := range strings.Split(header, ",") {
+		value = strings.TrimSpace(value)
+		if value == "*" {
+			return true
+		}
+		if strings.HasPrefix(value, "W/") {
+			if strong {
+				continue
+			}
+			value = strings.TrimPrefix(value, "W/")
+		}
+		if value == etag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// preconditionMet checks If-Match against the expense as currently stored.
+// Requests without the header are always allowed.
+func preconditionMet(c *gin.Context, e Expense) bool {
+	header := c.GetHeader(IfMatchHeader)
+	return header == "" || matchETag(header, e.ETag(), true)
+}
+
+// save writes the expense and bumps its version, but only if the stored
+// version is still the one that was read and the change is allowed by
+// checkSplit. The change from before is added to the history of the
+// expense in the same transaction.
+func (h *handler) save(before Expense, expense *Expense, action, actor string) error {
+	version := expense.Version
+	expense.Version++
+
+	err := h.db.Transaction(func(tx DB) error {
+		if err := checkSplit(tx, before, *expense); err != nil {
+			return err
+		}
+
+		result := tx.Where("version = ?", version).Select("*").Save(expense)
+		if result.Error == nil && result.RowsAffected == 0 {
+			result.Error = ErrVersionConflict
+		}
+		if result.Error != nil {
+			return result.Error
+		}
+
+		history := NewHistory(action, actor, &before, expense)
+		return tx.Create(&history).Error
+	})
+
+	if err != nil {
+		expense.Version = version
+	}
+
+	return err
+}
+
+// saveStatus maps an error from save to a response status. A version
+// conflict is reported as a failed precondition when the client sent one.
+func saveStatus(c *gin.Context, err error) (int, error) {
+	if errors.Is(err, ErrSplitLocked) {
+		return http.StatusConflict, err
+	}
+
+	if !errors.Is(err, ErrVersionConflict) {
+		return http.StatusInternalServerError, ErrUpdateFailed
+	}
+
+	if c.GetHeader(IfMatchHeader) != "" {
+		return http.StatusPreconditionFailed, ErrPreconditionFailed
+	}
+
+	return http.StatusConflict, err
+}
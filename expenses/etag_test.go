@@ -0,0 +1,34 @@
+//go:build unit
+// +build unit
+
+package expenses
+
+import "testing"
+
+func TestMatchETag(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		strong bool
+		want   bool
+	}{
+		{name: "Should match the same tag", header: `"2"`, want: true},
+		{name: "Should match any tag in a list", header: `"1", "2"`, want: true},
+		{name: "Should match a weak tag", header: `W/"2"`, want: true},
+		{name: "Should match wildcard", header: `*`, want: true},
+		{name: "Should not match another tag", header: `"1"`, want: false},
+		{name: "Should not match an unquoted tag", header: `2`, want: false},
+		{name: "Should match the same tag strongly", header: `"2"`, strong: true, want: true},
+		{name: "Should match wildcard strongly", header: `*`, strong: true, want: true},
+		{name: "Should not match a weak tag strongly", header: `W/"2"`, strong: true, want: false},
+		{name: "Should match a strong tag after a weak one", header: `W/"2", "2"`, strong: true, want: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := matchETag(test.header, Expense{Version: 2}.ETag(), test.strong); got != test.want {
+				t.Errorf("unexpected match for %s: got %v want %v", test.header, got, test.want)
+			}
+		})
+	}
+}
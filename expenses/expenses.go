@@ -1,18 +1,87 @@
 package expenses
 
-import "github.com/lib/pq"
+import (
+	"time"
+
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+)
 
 type Expense struct {
-	ID     int            `gorm:"primary_key" json:"id" binding:"required"`
-	Title  string         `gorm:"type:text" json:"title" binding:"required"`
-	Amount float64        `gorm:"type:float" json:"amount" binding:"required"`
-	Note   string         `gorm:"type:text" json:"note" binding:"required"`
-	Tags   pq.StringArray `gorm:"type:text[]" json:"tags" binding:"required"`
+	ID       int            `gorm:"primary_key" json:"id" binding:"required"`
+	Title    string         `gorm:"type:text" json:"title" binding:"required"`
+	Amount   Money          `gorm:"type:numeric(19,2)" json:"amount" binding:"required"`
+	Currency string         `gorm:"type:char(3);not null;default:'THB'" json:"currency" binding:"omitempty,iso4217"`
+	Note     string         `gorm:"type:text" json:"note" binding:"required"`
+	Tags     pq.StringArray `gorm:"type:text[]" json:"tags" binding:"required"`
+	SpentAt  time.Time      `gorm:"not null;default:CURRENT_TIMESTAMP;index" json:"spent_at"`
+	Version  int            `gorm:"not null;default:1" json:"version"`
+	OwnerID  int            `gorm:"index" json:"owner_id"`
+
+	CreatedAt time.Time      `gorm:"not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt time.Time      `gorm:"not null;default:CURRENT_TIMESTAMP" json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+
+	Converted *Conversion `gorm:"-" json:"converted,omitempty"`
+}
+
+// In returns a copy of the expense with its timestamps expressed in loc.
+// Zero timestamps are left untouched so they keep rendering as zero.
+func (e Expense) In(loc *time.Location) Expense {
+	in := func(t time.Time) time.Time {
+		if t.IsZero() {
+			return t
+		}
+		return t.In(loc)
+	}
+
+	e.SpentAt = in(e.SpentAt)
+	e.CreatedAt = in(e.CreatedAt)
+	e.UpdatedAt = in(e.UpdatedAt)
+	if e.DeletedAt.Valid {
+		e.DeletedAt.Time = in(e.DeletedAt.Time)
+	}
+
+	return e
 }
 
 type CreateRequestBody struct {
-	Title  string   `json:"title" binding:"required"`
-	Amount float64  `json:"amount" binding:"required"`
-	Note   string   `json:"note" binding:"required"`
-	Tags   []string `json:"tags" binding:"required"`
+	Title    string     `json:"title" binding:"required"`
+	Amount   Money      `json:"amount" binding:"required"`
+	Currency string     `json:"currency" binding:"omitempty,iso4217"`
+	Note     string     `json:"note" binding:"required"`
+	Tags     []string   `json:"tags" binding:"required"`
+	SpentAt  *time.Time `json:"spent_at"`
+}
+
+// maxClockSkew tolerates clients whose clocks run slightly ahead of ours.
+const maxClockSkew = 5 * time.Minute
+
+// Validate checks the rules binding cannot express, given the current time.
+func (body *CreateRequestBody) Validate(now time.Time) error {
+	if body.SpentAt != nil && body.SpentAt.After(now.Add(maxClockSkew)) {
+		return ErrSpentAtInFuture
+	}
+
+	return validateAmount(body.Amount, body.Currency)
+}
+
+// Expense builds a new expense from the body, spent now unless the body
+// says otherwise.
+func (body *CreateRequestBody) Expense(now time.Time) Expense {
+	expense := Expense{
+		Title:    body.Title,
+		Amount:   body.Amount,
+		Currency: body.Currency,
+		Note:     body.Note,
+		Tags:     pq.StringArray(body.Tags),
+		SpentAt:  now,
+		Version:  1,
+	}
+
+	if body.SpentAt != nil {
+		expense.SpentAt = *body.SpentAt
+	}
+
+	return expense
 }
@@ -0,0 +1,185 @@
+package expenses
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+const (
+	exportFormatCSV  = "csv"
+	exportFormatXLSX = "xlsx"
+
+	// exportBatchSize is the number of rows loaded from the database at a
+	// time, so an export never holds the whole table in memory.
+	exportBatchSize = 500
+)
+
+type ExportQuery struct {
+	Filter
+
+	Format       string  `form:"format" binding:"omitempty,oneof=csv xlsx"`
+	TagSeparator *string `form:"tag_separator" binding:"omitempty,max=10"`
+}
+
+var exportColumns = []string{"id", "title", "amount", "currency", "note", "tags", "spent_at", "created_at", "updated_at"}
+
+// sheet receives exported rows one at a time.
+type sheet interface {
+	Write(values []interface{}) error
+	Flush() error
+	Close() error
+}
+
+func newSheet(format string, w io.Writer) (sheet, error) {
+	if format == exportFormatXLSX {
+		return newXLSXSheet(w)
+	}
+
+	return &csvSheet{w: csv.NewWriter(w)}, nil
+}
+
+func exportContentType(format string) string {
+	if format == exportFormatXLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+
+	return "text/csv; charset=utf-8"
+}
+
+func toRow(columns []string) []interface{} {
+	row := make([]interface{}, len(columns))
+	for i, column := range columns {
+		row[i] = column
+	}
+
+	return row
+}
+
+func exportRow(e Expense, loc *time.Location, tagSeparator string) []interface{} {
+	e = e.In(loc)
+	return []interface{}{
+		e.ID,
+		escapeCell(e.Title),
+		e.Amount,
+		e.Currency,
+		escapeCell(e.Note),
+		escapeCell(strings.Join(e.Tags, tagSeparator)),
+		e.SpentAt.Format(time.RFC3339),
+		e.CreatedAt.Format(time.RFC3339),
+		e.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+// escapeCell prefixes text that a spreadsheet would run as a formula with
+// a quote, so opening an export cannot run what a user typed.
+func escapeCell(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+
+	return s
+}
+
+// unescapeCell removes the quote escapeCell added, so an export can be
+// imported back unchanged.
+func unescapeCell(s string) string {
+	if len(s) > 1 && s[0] == '\'' && strings.ContainsRune("=+-@\t\r", rune(s[1])) {
+		return s[1:]
+	}
+
+	return s
+}
+
+type csvSheet struct {
+	w *csv.Writer
+}
+
+func (s *csvSheet) Write(values []interface{}) error {
+	record := make([]string, len(values))
+	for i, value := range values {
+		switch v := value.(type) {
+		case string:
+			record[i] = v
+		default:
+			record[i] = fmt.Sprint(v)
+		}
+	}
+
+	if err := s.w.Write(record); err != nil {
+		return err
+	}
+
+	// Flush every row so the response is streamed instead of buffered.
+	s.w.Flush()
+	return s.w.Error()
+}
+
+func (s *csvSheet) Flush() error {
+	s.w.Flush()
+	return s.w.Error()
+}
+
+func (s *csvSheet) Close() error {
+	return nil
+}
+
+// xlsxSheet writes rows through excelize's stream writer, which spills to a
+// temporary file instead of keeping every row in memory. The workbook is
+// only written to w on Flush because xlsx is a zip archive.
+type xlsxSheet struct {
+	w    io.Writer
+	file *excelize.File
+	sw   *excelize.StreamWriter
+	row  int
+}
+
+const xlsxSheetName = "Expenses"
+
+func newXLSXSheet(w io.Writer) (*xlsxSheet, error) {
+	file := excelize.NewFile()
+	if err := file.SetSheetName("Sheet1", xlsxSheetName); err != nil {
+		return nil, err
+	}
+
+	sw, err := file.NewStreamWriter(xlsxSheetName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &xlsxSheet{w: w, file: file, sw: sw}, nil
+}
+
+func (s *xlsxSheet) Write(values []interface{}) error {
+	s.row++
+	cell, err := excelize.CoordinatesToCellName(1, s.row)
+	if err != nil {
+		return err
+	}
+
+	row := make([]interface{}, len(values))
+	for i, value := range values {
+		if amount, ok := value.(Money); ok {
+			value = float64(amount) / moneyUnit
+		}
+		row[i] = value
+	}
+
+	return s.sw.SetRow(cell, row)
+}
+
+func (s *xlsxSheet) Flush() error {
+	if err := s.sw.Flush(); err != nil {
+		return err
+	}
+
+	return s.file.Write(s.w)
+}
+
+func (s *xlsxSheet) Close() error {
+	return s.file.Close()
+}
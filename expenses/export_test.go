@@ -0,0 +1,61 @@
+//go:build unit
+// +build unit
+
+package expenses
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestExportImportRoundTrip(t *testing.T) {
+	tests := []struct {
+		name    string
+		expense Expense
+	}{
+		{
+			name:    "Should keep a title starting with =",
+			expense: Expense{Title: "=SUM(A1:A2)", Amount: 1000, Currency: "THB", Note: "@note", Tags: []string{"+food", "drink"}},
+		},
+		{
+			name:    "Should keep a title starting with -",
+			expense: Expense{Title: "-refund", Amount: 2550, Currency: "THB", Note: "note", Tags: []string{"food"}},
+		},
+		{
+			name:    "Should keep a title starting with a quote",
+			expense: Expense{Title: "'quoted'", Amount: 1000, Currency: "THB", Note: "note", Tags: []string{"food"}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			s, err := newSheet(exportFormatCSV, &buf)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err := s.Write(toRow(exportColumns)); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err := s.Write(exportRow(test.expense, time.UTC, ",")); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			records, err := readCSV(&buf, time.UTC, ",", 0)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(records) != 1 || records[0].err != nil {
+				t.Fatalf("unexpected records: %+v", records)
+			}
+
+			body := records[0].body
+			got := Expense{Title: body.Title, Amount: body.Amount, Currency: body.Currency, Note: body.Note, Tags: body.Tags}
+			if !reflect.DeepEqual(got, test.expense) {
+				t.Errorf("unexpected expense: got %+v want %+v", got, test.expense)
+			}
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package expenses
+
+import (
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+)
+
+const (
+	dateLayout = "2006-01-02"
+
+	tagsMatchAny = "any"
+	tagsMatchAll = "all"
+)
+
+// Filter narrows the expenses returned by List. Every condition is bound as
+// a query argument so user input never reaches the SQL text.
+type Filter struct {
+	Tags      []string `form:"tags"`
+	TagsMatch string   `form:"tags_match" binding:"omitempty,oneof=any all"`
+	MinAmount string   `form:"min_amount"`
+	MaxAmount string   `form:"max_amount"`
+	Query     string   `form:"q" binding:"omitempty,max=100"`
+	From      string   `form:"from"`
+	To        string   `form:"to"`
+
+	minAmount *Money
+	maxAmount *Money
+	from      time.Time
+	to        time.Time
+}
+
+// prepare validates the filter and resolves from and to, which accept either
+// an RFC 3339 timestamp or a plain date interpreted in loc. A plain to date
+// includes the whole day.
+func (f *Filter) prepare(loc *time.Location) error {
+	var err error
+	if f.minAmount, err = parseAmount(f.MinAmount); err != nil {
+		return err
+	}
+
+	if f.maxAmount, err = parseAmount(f.MaxAmount); err != nil {
+		return err
+	}
+
+	if f.minAmount != nil && f.maxAmount != nil && *f.minAmount > *f.maxAmount {
+		return ErrInvalidAmountRange
+	}
+
+	if f.from, err = parseDate(f.From, loc, false); err != nil {
+		return err
+	}
+
+	if f.to, err = parseDate(f.To, loc, true); err != nil {
+		return err
+	}
+
+	if !f.from.IsZero() && !f.to.IsZero() && !f.from.Before(f.to) {
+		return ErrInvalidDateRange
+	}
+
+	return nil
+}
+
+func parseAmount(value string) (*Money, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	amount, err := ParseMoney(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return &amount, nil
+}
+
+// parseDate returns the start of the given instant or day. When end is set
+// the result is an exclusive upper bound instead.
+func parseDate(value string, loc *time.Location, end bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		if end {
+			t = t.Add(time.Microsecond)
+		}
+		return t, nil
+	}
+
+	t, err := time.ParseInLocation(dateLayout, value, loc)
+	if err != nil {
+		return time.Time{}, ErrInvalidDate
+	}
+
+	if end {
+		t = t.AddDate(0, 0, 1)
+	}
+
+	return t, nil
+}
+
+// tags accepts both repeated and comma separated values,
+// e.g. tags=food&tags=beverage or tags=food,beverage.
+func (f *Filter) tags() []string {
+	var tags []string
+	for _, value := range f.Tags {
+		for _, tag := range strings.Split(value, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				tags = append(tags, tag)
+			}
+		}
+	}
+
+	return tags
+}
+
+func (f *Filter) scope(db *gorm.DB) *gorm.DB {
+	if tags := f.tags(); len(tags) > 0 {
+		if f.TagsMatch == tagsMatchAll {
+			db = db.Where("tags @> ?", pq.StringArray(tags))
+		} else {
+			db = db.Where("tags && ?", pq.StringArray(tags))
+		}
+	}
+
+	if f.minAmount != nil {
+		db = db.Where("amount >= ?", *f.minAmount)
+	}
+
+	if f.maxAmount != nil {
+		db = db.Where("amount <= ?", *f.maxAmount)
+	}
+
+	if f.Query != "" {
+		pattern := "%" + escapeLike(f.Query) + "%"
+		db = db.Where("title ILIKE ? OR note ILIKE ?", pattern, pattern)
+	}
+
+	if !f.from.IsZero() {
+		db = db.Where("spent_at >= ?", f.from)
+	}
+
+	if !f.to.IsZero() {
+		db = db.Where("spent_at < ?", f.to)
+	}
+
+	return db
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
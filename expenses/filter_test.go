@@ -0,0 +1,126 @@
+//go:build unit
+// +build unit
+
+package expenses
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func TestFilterScope(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter Filter
+		want   string
+	}{
+		{
+			name:   "Should match any tag by default",
+			filter: Filter{Tags: []string{"food, beverage"}},
+			want:   `WHERE tags && '{"food","beverage"}'`,
+		},
+		{
+			name:   "Should match all tags when requested",
+			filter: Filter{Tags: []string{"food", "beverage"}, TagsMatch: tagsMatchAll},
+			want:   `WHERE tags @> '{"food","beverage"}'`,
+		},
+		{
+			name:   "Should filter by amount range",
+			filter: Filter{MinAmount: "10", MaxAmount: "20.5"},
+			want:   `WHERE amount >= '10.00' AND amount <= '20.50'`,
+		},
+		{
+			name:   "Should escape wildcards in text search",
+			filter: Filter{Query: "50%_off"},
+			want:   `WHERE (title ILIKE '%50\%\_off%' OR note ILIKE '%50\%\_off%')`,
+		},
+		{
+			name:   "Should not add conditions when filter is empty",
+			filter: Filter{Tags: []string{" , "}},
+			want:   `FROM "expenses" WHERE "expenses"."deleted_at" IS NULL`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := test.filter.prepare(time.UTC); err != nil {
+				t.Fatal(err)
+			}
+
+			sql := dryRunDB(t).ToSQL(func(tx *gorm.DB) *gorm.DB {
+				return tx.Scopes(test.filter.scope).Find(&[]Expense{})
+			})
+
+			if !strings.Contains(sql, test.want) {
+				t.Errorf("unexpected sql: got %s want %s", sql, test.want)
+			}
+		})
+	}
+}
+
+func TestFilterPrepare(t *testing.T) {
+	bangkok, err := time.LoadLocation("Asia/Bangkok")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		filter   Filter
+		wantFrom time.Time
+		wantTo   time.Time
+		wantErr  error
+	}{
+		{
+			name:     "Should include the whole day when dates are given",
+			filter:   Filter{From: "2023-01-01", To: "2023-01-31"},
+			wantFrom: time.Date(2023, 1, 1, 0, 0, 0, 0, bangkok),
+			wantTo:   time.Date(2023, 2, 1, 0, 0, 0, 0, bangkok),
+		},
+		{
+			name:     "Should accept RFC 3339 timestamps",
+			filter:   Filter{From: "2023-01-01T10:00:00Z"},
+			wantFrom: time.Date(2023, 1, 1, 10, 0, 0, 0, time.UTC),
+		},
+		{
+			name:    "Should return error when date is invalid",
+			filter:  Filter{From: "01/02/2023"},
+			wantErr: ErrInvalidDate,
+		},
+		{
+			name:    "Should return error when from is after to",
+			filter:  Filter{From: "2023-02-01", To: "2023-01-01"},
+			wantErr: ErrInvalidDateRange,
+		},
+		{
+			name:    "Should return error when min_amount is greater than max_amount",
+			filter:  Filter{MinAmount: "20", MaxAmount: "10"},
+			wantErr: ErrInvalidAmountRange,
+		},
+		{
+			name:    "Should return error when amount has too many decimal places",
+			filter:  Filter{MinAmount: "0.001"},
+			wantErr: ErrTooManyFractionals,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.filter.prepare(bangkok)
+			if err != test.wantErr {
+				t.Fatalf("unexpected error: got %v want %v", err, test.wantErr)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if !test.filter.from.Equal(test.wantFrom) || !test.filter.to.Equal(test.wantTo) {
+				t.Errorf("unexpected range: got %v - %v want %v - %v", test.filter.from, test.filter.to, test.wantFrom, test.wantTo)
+			}
+		})
+	}
+}
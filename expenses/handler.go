@@ -2,150 +2,934 @@ package expenses
 
 import (
 	"errors"
+	"fmt"
+	"io"
+	"mime"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 	"github.com/lib/pq"
+	"github.com/tirathawat/assessment/config"
 	"github.com/tirathawat/assessment/errs"
 	"github.com/tirathawat/assessment/logs"
+	"github.com/tirathawat/assessment/middleware"
+	"github.com/tirathawat/assessment/rates"
 	"gorm.io/gorm"
 )
 
 var (
-	ErrCreateFailed = errors.New("failed to create expense")
-	ErrInvalidID    = errors.New("invalid id")
-	ErrIDMismatch   = errors.New("id mismatch")
-	ErrNotFound     = errors.New("expense not found")
-	ErrGetFailed    = errors.New("failed to get expense")
-	ErrUpdateFailed = errors.New("failed to update expense")
-	ErrListFailed   = errors.New("failed to list expenses")
+	ErrCreateFailed  = errs.New(http.StatusInternalServerError, "EXPENSE_CREATE_FAILED", "failed to create expense")
+	ErrInvalidID     = errs.New(http.StatusBadRequest, "INVALID_ID", "invalid id")
+	ErrIDMismatch    = errs.New(http.StatusBadRequest, "ID_MISMATCH", "id mismatch")
+	ErrNotFound      = errs.New(http.StatusNotFound, "EXPENSE_NOT_FOUND", "expense not found")
+	ErrGetFailed     = errs.New(http.StatusInternalServerError, "EXPENSE_GET_FAILED", "failed to get expense")
+	ErrUpdateFailed  = errs.New(http.StatusInternalServerError, "EXPENSE_UPDATE_FAILED", "failed to update expense")
+	ErrListFailed    = errs.New(http.StatusInternalServerError, "EXPENSE_LIST_FAILED", "failed to list expenses")
+	ErrDeleteFailed  = errs.New(http.StatusInternalServerError, "EXPENSE_DELETE_FAILED", "failed to delete expense")
+	ErrRestoreFailed = errs.New(http.StatusInternalServerError, "EXPENSE_RESTORE_FAILED", "failed to restore expense")
+	ErrNotInTrash    = errs.New(http.StatusNotFound, "EXPENSE_NOT_IN_TRASH", "expense not found in trash")
+	ErrInvalidSort   = errs.New(http.StatusBadRequest, "INVALID_SORT", "invalid sort")
+	ErrInvalidCursor = errs.New(http.StatusBadRequest, "INVALID_CURSOR", "invalid cursor")
+
+	ErrCursorWithOffset   = errs.New(http.StatusBadRequest, "CURSOR_WITH_OFFSET", "cursor cannot be combined with offset")
+	ErrInvalidAmountRange = errs.New(http.StatusBadRequest, "INVALID_AMOUNT_RANGE", "min_amount cannot be greater than max_amount")
+	ErrInvalidDateRange   = errs.New(http.StatusBadRequest, "INVALID_DATE_RANGE", "from cannot be after to")
+	ErrInvalidDate        = errs.New(http.StatusBadRequest, "INVALID_DATE", "invalid date")
+	ErrInvalidTimeZone    = errs.New(http.StatusBadRequest, "INVALID_TIME_ZONE", "invalid time zone")
+	ErrSpentAtInFuture    = errs.New(http.StatusBadRequest, "SPENT_AT_IN_FUTURE", "spent_at cannot be in the future")
+	ErrAmountPrecision    = errs.New(http.StatusBadRequest, "AMOUNT_PRECISION", "amount has more decimal places than the currency allows")
+	ErrConversionFailed   = errs.New(http.StatusInternalServerError, "EXPENSE_CONVERSION_FAILED", "failed to convert expenses")
+	ErrSummaryFailed      = errs.New(http.StatusInternalServerError, "EXPENSE_SUMMARY_FAILED", "failed to summarize expenses")
+	ErrExportFailed       = errs.New(http.StatusInternalServerError, "EXPENSE_EXPORT_FAILED", "failed to export expenses")
+	ErrImportFailed       = errs.New(http.StatusInternalServerError, "EXPENSE_IMPORT_FAILED", "failed to import expenses")
 )
 
 type Handler interface {
 	Create(c *gin.Context)
 	Get(c *gin.Context)
 	Update(c *gin.Context)
+	Patch(c *gin.Context)
 	List(c *gin.Context)
-}
-
-type DB interface {
-	Create(value interface{}) *gorm.DB
-	First(dest interface{}, conds ...interface{}) *gorm.DB
-	Save(value interface{}) *gorm.DB
-	Find(dest interface{}, conds ...interface{}) *gorm.DB
+	Delete(c *gin.Context)
+	Trash(c *gin.Context)
+	Restore(c *gin.Context)
+	Summary(c *gin.Context)
+	Export(c *gin.Context)
+	Import(c *gin.Context)
+	History(c *gin.Context)
+	Revert(c *gin.Context)
 }
 
 type handler struct {
-	db DB
+	db           DB
+	timeZone     *time.Location
+	currency     string
+	tagSeparator string
+	maxImport    int
 }
 
-func NewHandler(db DB) Handler {
-	return &handler{db}
+func NewHandler(db DB, cfg *config.AppConfig) Handler {
+	return &handler{
+		db:           db,
+		timeZone:     cfg.TimeZone.Get(),
+		currency:     cfg.DefaultCurrency,
+		tagSeparator: cfg.ExportTagSeparator,
+		maxImport:    cfg.ImportMaxRows,
+	}
 }
 
 func (h *handler) Create(c *gin.Context) {
+	loc, err := h.location(c)
+	if err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("invalid time zone: %s", c.GetHeader(TimeZoneHeader))
+		errs.JSON(c, http.StatusBadRequest, err)
+		return
+	}
+
+	owner, err := h.owner(c)
+	if err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msg("no authenticated user")
+		errs.JSON(c, http.StatusUnauthorized, err)
+		return
+	}
+
 	var body CreateRequestBody
 	if err := c.ShouldBindJSON(&body); err != nil {
-		logs.Error().Err(err).Msg("failed to bind request body")
-		c.JSON(http.StatusBadRequest, errs.Error(err))
+		logs.Error().Context(c.Request.Context()).Err(err).Msg("failed to bind request body")
+		errs.JSON(c, http.StatusBadRequest, err)
 		return
 	}
 
-	expense := Expense{
-		Title:  body.Title,
-		Amount: body.Amount,
-		Note:   body.Note,
-		Tags:   pq.StringArray(body.Tags),
+	if body.Currency == "" {
+		body.Currency = h.currency
+	}
+
+	now := Now()
+	if err := body.Validate(now); err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("invalid request body: %v", body)
+		errs.JSON(c, http.StatusBadRequest, err)
+		return
 	}
 
-	if err := h.db.Create(&expense).Error; err != nil {
-		logs.Error().Err(err).Msgf("failed to create expense: %v", expense)
-		c.JSON(http.StatusInternalServerError, errs.Error(ErrCreateFailed))
+	expense := body.Expense(now.In(loc))
+	expense.OwnerID = owner
+	err = h.db.Transaction(func(tx DB) error {
+		if err := tx.Create(&expense).Error; err != nil {
+			return err
+		}
+
+		history := NewHistory(ActionCreate, middleware.Actor(c), nil, &expense)
+		return tx.Create(&history).Error
+	})
+	if err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("failed to create expense: %v", expense)
+		errs.JSON(c, http.StatusInternalServerError, ErrCreateFailed)
 		return
 	}
 
-	c.JSON(http.StatusCreated, expense)
+	c.Header(ETagHeader, expense.ETag())
+	c.JSON(http.StatusCreated, expense.In(loc))
 }
 
 func (h *handler) Get(c *gin.Context) {
 	var expense Expense
 
+	loc, err := h.location(c)
+	if err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("invalid time zone: %s", c.GetHeader(TimeZoneHeader))
+		errs.JSON(c, http.StatusBadRequest, err)
+		return
+	}
+
+	owner, err := h.owner(c)
+	if err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msg("no authenticated user")
+		errs.JSON(c, http.StatusUnauthorized, err)
+		return
+	}
+
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		logs.Error().Err(err).Msgf("invalid id: %s", c.Param("id"))
-		c.JSON(http.StatusBadRequest, errs.Error(ErrInvalidID))
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("invalid id: %s", c.Param("id"))
+		errs.JSON(c, http.StatusBadRequest, ErrInvalidID)
 		return
 	}
 
-	err = h.db.First(&expense, "id = ?", id).Error
+	err = h.db.Where("owner_id = ?", owner).First(&expense, "id = ?", id).Error
 	if err == nil {
-		c.JSON(http.StatusOK, expense)
+		c.Header(ETagHeader, expense.ETag())
+		if header := c.GetHeader(IfNoneMatchHeader); header != "" && matchETag(header, expense.ETag(), false) {
+			c.Status(http.StatusNotModified)
+			return
+		}
+
+		c.JSON(http.StatusOK, expense.In(loc))
 		return
 	}
 
 	if errors.Is(err, gorm.ErrRecordNotFound) {
-		logs.Error().Err(err).Msgf("expense not found: %d", id)
-		c.JSON(http.StatusNotFound, errs.Error(ErrNotFound))
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("expense not found: %d", id)
+		errs.JSON(c, http.StatusNotFound, ErrNotFound)
 		return
 	}
 
-	logs.Error().Err(err).Msgf("failed to get expense: %d", id)
-	c.JSON(http.StatusInternalServerError, errs.Error(ErrGetFailed))
+	logs.Error().Context(c.Request.Context()).Err(err).Msgf("failed to get expense: %d", id)
+	errs.JSON(c, http.StatusInternalServerError, ErrGetFailed)
 }
 
 func (h *handler) Update(c *gin.Context) {
+	loc, err := h.location(c)
+	if err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("invalid time zone: %s", c.GetHeader(TimeZoneHeader))
+		errs.JSON(c, http.StatusBadRequest, err)
+		return
+	}
+
+	owner, err := h.owner(c)
+	if err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msg("no authenticated user")
+		errs.JSON(c, http.StatusUnauthorized, err)
+		return
+	}
+
 	var body Expense
 	if err := c.ShouldBindJSON(&body); err != nil {
-		logs.Error().Err(err).Msg("failed to bind request body")
-		c.JSON(http.StatusBadRequest, errs.Error(err))
+		logs.Error().Context(c.Request.Context()).Err(err).Msg("failed to bind request body")
+		errs.JSON(c, http.StatusBadRequest, err)
 		return
 	}
 
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		logs.Error().Err(err).Msgf("invalid id: %s", c.Param("id"))
-		c.JSON(http.StatusBadRequest, errs.Error(ErrInvalidID))
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("invalid id: %s", c.Param("id"))
+		errs.JSON(c, http.StatusBadRequest, ErrInvalidID)
 		return
 	}
 
 	if body.ID != id {
-		logs.Error().Err(err).Msgf("id mismatch: %d != %d", id, body.ID)
-		c.JSON(http.StatusBadRequest, errs.Error(ErrIDMismatch))
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("id mismatch: %d != %d", id, body.ID)
+		errs.JSON(c, http.StatusBadRequest, ErrIDMismatch)
+		return
+	}
+
+	var expense Expense
+	err = h.db.Where("owner_id = ?", owner).First(&expense, "id = ?", id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("expense not found: %d", id)
+		errs.JSON(c, http.StatusNotFound, ErrNotFound)
+		return
+	}
+
+	if err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("failed to get expense: %d", id)
+		errs.JSON(c, http.StatusInternalServerError, ErrGetFailed)
+		return
+	}
+
+	if !preconditionMet(c, expense) {
+		logs.Error().Context(c.Request.Context()).Err(ErrPreconditionFailed).Msgf("stale If-Match %s for expense %d at %s", c.GetHeader(IfMatchHeader), id, expense.ETag())
+		errs.JSON(c, http.StatusPreconditionFailed, ErrPreconditionFailed)
+		return
+	}
+
+	before := expense
+	expense.Title = body.Title
+	expense.Amount = body.Amount
+	expense.Note = body.Note
+	expense.Tags = body.Tags
+	if !body.SpentAt.IsZero() {
+		expense.SpentAt = body.SpentAt
+	}
+
+	if body.Currency != "" {
+		expense.Currency = body.Currency
+	}
+
+	merged := CreateRequestBody{Amount: expense.Amount, Currency: expense.Currency, SpentAt: &expense.SpentAt}
+	if err := merged.Validate(Now()); err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("invalid updated expense: %v", expense)
+		errs.JSON(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := h.save(before, &expense, ActionUpdate, middleware.Actor(c)); err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("failed to update expense: %v", expense)
+		status, err := saveStatus(c, err)
+		errs.JSON(c, status, err)
+		return
+	}
+
+	c.Header(ETagHeader, expense.ETag())
+	c.JSON(http.StatusOK, expense.In(loc))
+}
+
+func (h *handler) Patch(c *gin.Context) {
+	loc, err := h.location(c)
+	if err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("invalid time zone: %s", c.GetHeader(TimeZoneHeader))
+		errs.JSON(c, http.StatusBadRequest, err)
+		return
+	}
+
+	owner, err := h.owner(c)
+	if err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msg("no authenticated user")
+		errs.JSON(c, http.StatusUnauthorized, err)
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("invalid id: %s", c.Param("id"))
+		errs.JSON(c, http.StatusBadRequest, ErrInvalidID)
+		return
+	}
+
+	patch, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msg("failed to read patch")
+		errs.JSON(c, http.StatusBadRequest, ErrInvalidPatch)
 		return
 	}
 
 	var expense Expense
-	err = h.db.First(&expense, "id = ?", id).Error
+	err = h.db.Where("owner_id = ?", owner).First(&expense, "id = ?", id).Error
 	if errors.Is(err, gorm.ErrRecordNotFound) {
-		logs.Error().Err(err).Msgf("expense not found: %d", id)
-		c.JSON(http.StatusNotFound, errs.Error(ErrNotFound))
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("expense not found: %d", id)
+		errs.JSON(c, http.StatusNotFound, ErrNotFound)
 		return
 	}
 
 	if err != nil {
-		logs.Error().Err(err).Msgf("failed to get expense: %d", id)
-		c.JSON(http.StatusInternalServerError, errs.Error(ErrGetFailed))
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("failed to get expense: %d", id)
+		errs.JSON(c, http.StatusInternalServerError, ErrGetFailed)
+		return
+	}
+
+	if !preconditionMet(c, expense) {
+		logs.Error().Context(c.Request.Context()).Err(ErrPreconditionFailed).Msgf("stale If-Match %s for expense %d at %s", c.GetHeader(IfMatchHeader), id, expense.ETag())
+		errs.JSON(c, http.StatusPreconditionFailed, ErrPreconditionFailed)
+		return
+	}
+
+	body, err := applyPatch(c.ContentType(), expense, patch)
+	if err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("failed to patch expense: %d", id)
+		errs.JSON(c, patchStatus(err), err)
+		return
+	}
+
+	// Removing the currency falls back to the default, as when creating.
+	if body.Currency == "" {
+		body.Currency = h.currency
+	}
+
+	if err := binding.Validator.ValidateStruct(&body); err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("invalid patched expense: %v", body)
+		errs.JSON(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := body.Validate(Now()); err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("invalid patched expense: %v", body)
+		errs.JSON(c, http.StatusBadRequest, err)
 		return
 	}
 
-	if err := h.db.Save(&body).Error; err != nil {
-		logs.Error().Err(err).Msgf("failed to update expense: %v", body)
-		c.JSON(http.StatusInternalServerError, errs.Error(ErrUpdateFailed))
+	before := expense
+	expense.Title = body.Title
+	expense.Amount = body.Amount
+	expense.Currency = body.Currency
+	expense.Note = body.Note
+	expense.Tags = pq.StringArray(body.Tags)
+	expense.SpentAt = *body.SpentAt
+
+	if err := h.save(before, &expense, ActionUpdate, middleware.Actor(c)); err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("failed to update expense: %v", expense)
+		status, err := saveStatus(c, err)
+		errs.JSON(c, status, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, body)
+	c.Header(ETagHeader, expense.ETag())
+	c.JSON(http.StatusOK, expense.In(loc))
 }
 
 func (h *handler) List(c *gin.Context) {
+	loc, err := h.location(c)
+	if err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("invalid time zone: %s", c.GetHeader(TimeZoneHeader))
+		errs.JSON(c, http.StatusBadRequest, err)
+		return
+	}
+
+	owner, err := h.owner(c)
+	if err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msg("no authenticated user")
+		errs.JSON(c, http.StatusUnauthorized, err)
+		return
+	}
+
+	var query ListQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msg("failed to bind query")
+		errs.JSON(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := query.Filter.prepare(loc); err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("invalid list filter: %v", query.Filter)
+		errs.JSON(c, http.StatusBadRequest, err)
+		return
+	}
+
+	page, err := newPage(query)
+	if err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("invalid list query: %v", query)
+		errs.JSON(c, http.StatusBadRequest, err)
+		return
+	}
+
+	var total int64
+	if err := h.db.Model(&Expense{}).Where("owner_id = ?", owner).Scopes(query.Filter.scope).Count(&total).Error; err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msg("failed to count expenses")
+		errs.JSON(c, http.StatusInternalServerError, ErrListFailed)
+		return
+	}
+
+	var expenses []Expense
+	if err := h.db.Where("owner_id = ?", owner).Scopes(query.Filter.scope, page.scope).Find(&expenses).Error; err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msg("failed to list expenses")
+		errs.JSON(c, http.StatusInternalServerError, ErrListFailed)
+		return
+	}
+
+	expenses, hasPrev, hasNext := page.results(expenses)
+	if query.Currency != "" {
+		if status, err := h.convert(expenses, query.Currency); err != nil {
+			logs.Error().Context(c.Request.Context()).Err(err).Msgf("failed to convert expenses to %s", query.Currency)
+			errs.JSON(c, status, err)
+			return
+		}
+	}
+
+	if links := page.links(c.Request.URL, expenses, hasPrev, hasNext); len(links) > 0 {
+		c.Header("Link", strings.Join(links, ", "))
+	}
+
+	c.Header("X-Total-Count", strconv.FormatInt(total, 10))
+	c.JSON(http.StatusOK, localize(expenses, loc))
+}
+
+func (h *handler) Summary(c *gin.Context) {
+	loc, err := h.location(c)
+	if err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("invalid time zone: %s", c.GetHeader(TimeZoneHeader))
+		errs.JSON(c, http.StatusBadRequest, err)
+		return
+	}
+
+	owner, err := h.owner(c)
+	if err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msg("no authenticated user")
+		errs.JSON(c, http.StatusUnauthorized, err)
+		return
+	}
+
+	var query SummaryQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msg("failed to bind query")
+		errs.JSON(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := query.Filter.prepare(loc); err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("invalid summary filter: %v", query.Filter)
+		errs.JSON(c, http.StatusBadRequest, err)
+		return
+	}
+
+	currency := query.Currency
+	if currency == "" {
+		currency = h.currency
+	}
+
+	s := summary{query: query, owner: owner, base: h.currency, currency: currency, loc: loc}
+	groups := []SummaryGroup{}
+	if err := h.db.Scopes(s.scope).Scan(&groups).Error; err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("failed to summarize expenses by %s", query.GroupBy)
+		errs.JSON(c, http.StatusInternalServerError, ErrSummaryFailed)
+		return
+	}
+
+	for _, group := range groups {
+		if group.Unconverted > 0 {
+			err := fmt.Errorf("%w: %d expenses in %s cannot be converted to %s", rates.ErrRateNotFound, group.Unconverted, group.Key, currency)
+			logs.Error().Context(c.Request.Context()).Err(err).Msg("failed to summarize expenses")
+			errs.JSON(c, http.StatusUnprocessableEntity, err)
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, Summary{GroupBy: query.GroupBy, Currency: currency, Groups: groups})
+}
+
+func (h *handler) Export(c *gin.Context) {
+	loc, err := h.location(c)
+	if err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("invalid time zone: %s", c.GetHeader(TimeZoneHeader))
+		errs.JSON(c, http.StatusBadRequest, err)
+		return
+	}
+
+	owner, err := h.owner(c)
+	if err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msg("no authenticated user")
+		errs.JSON(c, http.StatusUnauthorized, err)
+		return
+	}
+
+	var query ExportQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msg("failed to bind query")
+		errs.JSON(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := query.Filter.prepare(loc); err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("invalid export filter: %v", query.Filter)
+		errs.JSON(c, http.StatusBadRequest, err)
+		return
+	}
+
+	format := query.Format
+	if format == "" {
+		format = exportFormatCSV
+	}
+
+	tagSeparator := h.tagSeparator
+	if query.TagSeparator != nil {
+		tagSeparator = *query.TagSeparator
+	}
+
+	sheet, err := newSheet(format, c.Writer)
+	if err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("failed to create %s sheet", format)
+		errs.JSON(c, http.StatusInternalServerError, ErrExportFailed)
+		return
+	}
+	defer sheet.Close()
+
+	// The response is committed by the first row, so headers are only sent
+	// once the first batch has been loaded successfully.
+	started := false
+	start := func() error {
+		started = true
+		filename := fmt.Sprintf("expenses-%s.%s", Now().In(loc).Format("20060102"), format)
+		c.Header("Content-Type", exportContentType(format))
+		c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+		c.Status(http.StatusOK)
+		return sheet.Write(toRow(exportColumns))
+	}
+
+	var batch []Expense
+	err = h.db.Where("owner_id = ?", owner).Scopes(query.Filter.scope).FindInBatches(&batch, exportBatchSize, func(tx *gorm.DB, n int) error {
+		if !started {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+
+		for _, expense := range batch {
+			if err := sheet.Write(exportRow(expense, loc, tagSeparator)); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}).Error
+	if err == nil && !started {
+		err = start()
+	}
+	if err == nil {
+		err = sheet.Flush()
+	}
+
+	if err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("failed to export expenses as %s", format)
+		if !started {
+			errs.JSON(c, http.StatusInternalServerError, ErrExportFailed)
+			return
+		}
+
+		c.Abort()
+	}
+}
+
+func (h *handler) Import(c *gin.Context) {
+	loc, err := h.location(c)
+	if err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("invalid time zone: %s", c.GetHeader(TimeZoneHeader))
+		errs.JSON(c, http.StatusBadRequest, err)
+		return
+	}
+
+	owner, err := h.owner(c)
+	if err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msg("no authenticated user")
+		errs.JSON(c, http.StatusUnauthorized, err)
+		return
+	}
+
+	var query ImportQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msg("failed to bind query")
+		errs.JSON(c, http.StatusBadRequest, err)
+		return
+	}
+
+	format, err := importFormat(query.Format, c.ContentType())
+	if err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("unsupported import content type: %s", c.ContentType())
+		errs.JSON(c, http.StatusUnsupportedMediaType, err)
+		return
+	}
+
+	tagSeparator := h.tagSeparator
+	if query.TagSeparator != nil {
+		tagSeparator = *query.TagSeparator
+	}
+
+	records, err := readImport(format, c.Request.Body, loc, tagSeparator, h.maxImport)
+	if errors.Is(err, ErrImportTooLarge) {
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("import exceeds %d rows", h.maxImport)
+		errs.JSON(c, http.StatusRequestEntityTooLarge, err)
+		return
+	}
+	if err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("failed to read %s import", format)
+		errs.JSON(c, http.StatusBadRequest, err)
+		return
+	}
+
+	report, expenses := h.check(records, owner, Now().In(loc), query.DryRun, errs.Language(c))
+	if query.DryRun || len(expenses) == 0 {
+		c.JSON(http.StatusOK, report)
+		return
+	}
+
+	err = h.db.Transaction(func(tx DB) error {
+		if err := tx.CreateInBatches(&expenses, importBatchSize).Error; err != nil {
+			return err
+		}
+
+		histories := make([]History, len(expenses))
+		for i := range expenses {
+			histories[i] = NewHistory(ActionCreate, middleware.Actor(c), nil, &expenses[i])
+		}
+		return tx.CreateInBatches(&histories, importBatchSize).Error
+	})
+	if err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("failed to import %d expenses", len(expenses))
+		errs.JSON(c, http.StatusInternalServerError, ErrImportFailed)
+		return
+	}
+
+	created := 0
+	for i := range report.Rows {
+		if report.Rows[i].Status == importAccepted {
+			report.Rows[i].ID = expenses[created].ID
+			created++
+		}
+	}
+
+	c.JSON(http.StatusCreated, report)
+}
+
+// convert loads the exchange rates and fills Converted on every expense.
+// It returns the status to respond with when the conversion fails.
+func (h *handler) convert(expenses []Expense, currency string) (int, error) {
+	var list []rates.Rate
+	if err := h.db.Find(&list).Error; err != nil {
+		logs.Error().Err(err).Msg("failed to load exchange rates")
+		return http.StatusInternalServerError, ErrConversionFailed
+	}
+
+	if err := Convert(expenses, rates.NewTable(h.currency, list), currency, h.timeZone); err != nil {
+		return http.StatusUnprocessableEntity, err
+	}
+
+	return http.StatusOK, nil
+}
+
+func (h *handler) Delete(c *gin.Context) {
+	owner, err := h.owner(c)
+	if err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msg("no authenticated user")
+		errs.JSON(c, http.StatusUnauthorized, err)
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("invalid id: %s", c.Param("id"))
+		errs.JSON(c, http.StatusBadRequest, ErrInvalidID)
+		return
+	}
+
+	err = h.db.Transaction(func(tx DB) error {
+		var expense Expense
+		if err := tx.Where("owner_id = ?", owner).First(&expense, "id = ?", id).Error; err != nil {
+			return err
+		}
+
+		result := tx.Delete(&expense)
+		if result.Error == nil && result.RowsAffected == 0 {
+			result.Error = gorm.ErrRecordNotFound
+		}
+		if result.Error != nil {
+			return result.Error
+		}
+
+		history := NewHistory(ActionDelete, middleware.Actor(c), &expense, nil)
+		return tx.Create(&history).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("expense not found: %d", id)
+		errs.JSON(c, http.StatusNotFound, ErrNotFound)
+		return
+	}
+
+	if err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("failed to delete expense: %d", id)
+		errs.JSON(c, http.StatusInternalServerError, ErrDeleteFailed)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *handler) Trash(c *gin.Context) {
+	loc, err := h.location(c)
+	if err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("invalid time zone: %s", c.GetHeader(TimeZoneHeader))
+		errs.JSON(c, http.StatusBadRequest, err)
+		return
+	}
+
+	owner, err := h.owner(c)
+	if err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msg("no authenticated user")
+		errs.JSON(c, http.StatusUnauthorized, err)
+		return
+	}
+
 	var expenses []Expense
-	err := h.db.Find(&expenses).Error
+	err = h.db.Unscoped().Where("owner_id = ?", owner).Find(&expenses, "deleted_at IS NOT NULL").Error
+	if err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msg("failed to list deleted expenses")
+		errs.JSON(c, http.StatusInternalServerError, ErrListFailed)
+		return
+	}
+
+	c.JSON(http.StatusOK, localize(expenses, loc))
+}
+
+func (h *handler) Restore(c *gin.Context) {
+	loc, err := h.location(c)
+	if err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("invalid time zone: %s", c.GetHeader(TimeZoneHeader))
+		errs.JSON(c, http.StatusBadRequest, err)
+		return
+	}
+
+	owner, err := h.owner(c)
+	if err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msg("no authenticated user")
+		errs.JSON(c, http.StatusUnauthorized, err)
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("invalid id: %s", c.Param("id"))
+		errs.JSON(c, http.StatusBadRequest, ErrInvalidID)
+		return
+	}
+
+	var expense Expense
+	err = h.db.Transaction(func(tx DB) error {
+		result := tx.Unscoped().Model(&Expense{}).Where("id = ? AND owner_id = ? AND deleted_at IS NOT NULL", id, owner).Update("deleted_at", nil)
+		if result.Error == nil && result.RowsAffected == 0 {
+			result.Error = ErrNotInTrash
+		}
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if err := tx.Where("owner_id = ?", owner).First(&expense, "id = ?", id).Error; err != nil {
+			return err
+		}
+
+		history := NewHistory(ActionRestore, middleware.Actor(c), nil, &expense)
+		return tx.Create(&history).Error
+	})
+	if errors.Is(err, ErrNotInTrash) {
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("expense not found in trash: %d", id)
+		errs.JSON(c, http.StatusNotFound, ErrNotInTrash)
+		return
+	}
+
+	if err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("failed to restore expense: %d", id)
+		errs.JSON(c, http.StatusInternalServerError, ErrRestoreFailed)
+		return
+	}
+
+	c.JSON(http.StatusOK, expense.In(loc))
+}
+
+// History lists the changes made to the expense, oldest first. It stays
+// available while the expense is in the trash and after it is purged.
+func (h *handler) History(c *gin.Context) {
+	loc, err := h.location(c)
+	if err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("invalid time zone: %s", c.GetHeader(TimeZoneHeader))
+		errs.JSON(c, http.StatusBadRequest, err)
+		return
+	}
+
+	owner, err := h.owner(c)
+	if err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msg("no authenticated user")
+		errs.JSON(c, http.StatusUnauthorized, err)
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("invalid id: %s", c.Param("id"))
+		errs.JSON(c, http.StatusBadRequest, ErrInvalidID)
+		return
+	}
+
+	var entries []History
+	err = h.db.Where("owner_id = ? AND expense_id = ?", owner, id).Scopes(orderByID).Find(&entries).Error
+	if err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("failed to get history of expense: %d", id)
+		errs.JSON(c, http.StatusInternalServerError, ErrHistoryFailed)
+		return
+	}
+
+	// Expenses created before history was recorded have none, which is not
+	// the same as not existing.
+	if len(entries) == 0 {
+		err = h.db.Unscoped().Where("owner_id = ?", owner).First(&Expense{}, "id = ?", id).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			logs.Error().Context(c.Request.Context()).Err(err).Msgf("expense not found: %d", id)
+			errs.JSON(c, http.StatusNotFound, ErrNotFound)
+			return
+		}
+
+		if err != nil {
+			logs.Error().Context(c.Request.Context()).Err(err).Msgf("failed to get expense: %d", id)
+			errs.JSON(c, http.StatusInternalServerError, ErrHistoryFailed)
+			return
+		}
+
+		entries = []History{}
+	}
+
+	for i := range entries {
+		entries[i] = entries[i].In(loc)
+		entries[i].diff()
+	}
+
+	c.JSON(http.StatusOK, entries)
+}
+
+// Revert restores the content the expense had at a version from its
+// history. The revert is itself a new version, so it can be undone too.
+func (h *handler) Revert(c *gin.Context) {
+	loc, err := h.location(c)
+	if err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("invalid time zone: %s", c.GetHeader(TimeZoneHeader))
+		errs.JSON(c, http.StatusBadRequest, err)
+		return
+	}
+
+	owner, err := h.owner(c)
+	if err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msg("no authenticated user")
+		errs.JSON(c, http.StatusUnauthorized, err)
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		logs.Error().Err(err).Msg("failed to list expenses")
-		c.JSON(http.StatusInternalServerError, ErrListFailed)
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("invalid id: %s", c.Param("id"))
+		errs.JSON(c, http.StatusBadRequest, ErrInvalidID)
 		return
 	}
 
-	c.JSON(http.StatusOK, expenses)
+	var body RevertRequestBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msg("failed to bind request body")
+		errs.JSON(c, http.StatusBadRequest, err)
+		return
+	}
+
+	var expense Expense
+	err = h.db.Where("owner_id = ?", owner).First(&expense, "id = ?", id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("expense not found: %d", id)
+		errs.JSON(c, http.StatusNotFound, ErrNotFound)
+		return
+	}
+
+	if err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("failed to get expense: %d", id)
+		errs.JSON(c, http.StatusInternalServerError, ErrGetFailed)
+		return
+	}
+
+	if !preconditionMet(c, expense) {
+		logs.Error().Context(c.Request.Context()).Err(ErrPreconditionFailed).Msgf("stale If-Match %s for expense %d at %s", c.GetHeader(IfMatchHeader), id, expense.ETag())
+		errs.JSON(c, http.StatusPreconditionFailed, ErrPreconditionFailed)
+		return
+	}
+
+	var entry History
+	err = h.db.Where("owner_id = ? AND expense_id = ? AND version = ? AND after IS NOT NULL", owner, id, body.Version).First(&entry).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("version %d not found for expense: %d", body.Version, id)
+		errs.JSON(c, http.StatusNotFound, ErrVersionNotFound)
+		return
+	}
+
+	if err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("failed to get history of expense: %d", id)
+		errs.JSON(c, http.StatusInternalServerError, ErrRevertFailed)
+		return
+	}
+
+	before := expense
+	entry.After.apply(&expense)
+	if err := h.save(before, &expense, ActionRevert, middleware.Actor(c)); err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("failed to revert expense %d to version %d", id, body.Version)
+		status, err := saveStatus(c, err)
+		errs.JSON(c, status, err)
+		return
+	}
+
+	c.Header(ETagHeader, expense.ETag())
+	c.JSON(http.StatusOK, expense.In(loc))
+}
+
+func orderByID(db *gorm.DB) *gorm.DB {
+	return db.Order("id")
 }
@@ -3,12 +3,20 @@ package expenses
 const (
 	Endpoint = "expenses"
 
-	CreateBody        = `{"title":"test expense","amount":100,"note":"test note","tags":["tag1","tag2"]}`
-	InvalidCreateBody = `{"title":"test expense","amount":100,"note":"test note","tags":["tag1","tag2"]`
+	CreateBody              = `{"title":"test expense","amount":100,"note":"test note","tags":["tag1","tag2"]}`
+	InvalidCreateBody       = `{"title":"test expense","amount":100,"note":"test note","tags":["tag1","tag2"]`
+	FractionalYenCreateBody = `{"title":"test expense","amount":100.5,"currency":"JPY","note":"test note","tags":["tag1","tag2"]}`
+	FutureCreateBody        = `{"title":"test expense","amount":100,"note":"test note","tags":["tag1","tag2"],"spent_at":"2999-01-01T00:00:00Z"}`
+
+	USDCreateBody = `{"title":"test expense","amount":100,"currency":"USD","note":"test note","tags":["tag1","tag2"],"spent_at":"2023-01-15T00:00:00Z"}`
+	RatesBody     = `[{"currency":"USD","effective_on":"2023-01-01","rate":35}]`
 
 	UpdateBody        = `{"id":1,"title":"test expense update","amount":200,"note":"test note update","tags":["tag1","tag2"]}`
 	InvalidUpdateBody = `{"title":"test expense","amount":100,"note":"test note","tags":["tag1","tag2"]`
 
+	MergePatchBody = `{"title":"test expense patch","note":"test note patch"}`
+	JSONPatchBody  = `[{"op":"remove","path":"/tags/0"},{"op":"add","path":"/tags/-","value":"tag3"}]`
+
 	Token        = "January 2, 2006"
 	InvalidToken = "invalid token"
 )
@@ -4,31 +4,73 @@
 package expenses_test
 
 import (
+	"bytes"
+	"encoding/csv"
+	"errors"
 	"fmt"
+	"io"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
 	"github.com/lib/pq"
+	"github.com/tirathawat/assessment/apikeys"
+	"github.com/tirathawat/assessment/attachments"
+	"github.com/tirathawat/assessment/budgets"
 	"github.com/tirathawat/assessment/config"
 	"github.com/tirathawat/assessment/db"
+	"github.com/tirathawat/assessment/errs"
 	"github.com/tirathawat/assessment/expenses"
+	"github.com/tirathawat/assessment/groups"
+	"github.com/tirathawat/assessment/idempotency"
+	"github.com/tirathawat/assessment/logs"
+	"github.com/tirathawat/assessment/middleware"
+	"github.com/tirathawat/assessment/rates"
+	"github.com/tirathawat/assessment/recurring"
 	"github.com/tirathawat/assessment/router"
 	"github.com/tirathawat/assessment/testutils"
+	"github.com/tirathawat/assessment/users"
 	"gorm.io/gorm"
 )
 
 func setup() (endpoint string, cleanup func(), err error) {
-	database, dbCleanup, err := setupDatabase(config.NewAppConfig())
+	appConfig := config.NewAppConfig()
+	database, dbCleanup, err := setupDatabase(appConfig)
+	if err != nil {
+		return "", dbCleanup, err
+	}
+
+	auth, err := middleware.Auth(appConfig)
+	if err != nil {
+		return "", dbCleanup, err
+	}
+
+	localize, err := errs.Localize(appConfig.DefaultLanguage)
 	if err != nil {
 		return "", dbCleanup, err
 	}
 
 	r := gin.Default()
 	router.Register(r, &router.Handlers{
-		Expense: expenses.NewHandler(database),
+		Expense:     expenses.NewHandler(expenses.NewDB(database), appConfig),
+		Attachment:  attachments.NewHandler(attachments.NewStore(database), attachments.NewLocalStorage(appConfig.AttachmentDir), appConfig),
+		Group:       groups.NewHandler(groups.NewStore(database), appConfig),
+		Budget:      budgets.NewHandler(budgets.NewStore(database), rates.NewStore(database), appConfig),
+		Recurring:   recurring.NewHandler(recurring.NewStore(database), appConfig),
+		Rate:        rates.NewHandler(rates.NewStore(database)),
+		Key:         apikeys.NewHandler(apikeys.NewStore(database)),
+		Log:         logs.NewHandler(),
+		Idempotency: idempotency.Middleware(idempotency.NewStore(database), appConfig.IdempotencyTTL),
+		APIKey:      apikeys.Middleware(apikeys.NewStore(database)),
+		Auth:        auth,
+		User:        users.Middleware(users.NewStore(database)),
+		Language:    localize,
 	})
 
 	server := httptest.NewServer(r)
@@ -46,17 +88,48 @@ func setupDatabase(appConfig *config.AppConfig) (database *gorm.DB, cleanup func
 		return nil, cleanup, err
 	}
 
-	if err = database.Migrator().DropTable(&expenses.Expense{}); err != nil {
+	if err = database.Migrator().DropTable(&recurring.Occurrence{}, &recurring.Template{}, &budgets.Budget{}, &groups.Share{}, &groups.Split{}, &groups.Member{}, &groups.Group{}, &apikeys.Key{}, &attachments.Attachment{}, &attachments.Blob{}, &expenses.History{}, &expenses.Expense{}, &users.User{}, &rates.Rate{}, &idempotency.Record{}); err != nil {
 		return nil, cleanup, err
 	}
 
-	if err = database.AutoMigrate(&expenses.Expense{}); err != nil {
+	if err = database.AutoMigrate(&users.User{}, &expenses.Expense{}, &expenses.History{}, &rates.Rate{}, &idempotency.Record{}, &apikeys.Key{},
+		&groups.Group{}, &groups.Member{}, &groups.Split{}, &groups.Share{}, &budgets.Budget{},
+		&recurring.Template{}, &recurring.Occurrence{}, &attachments.Blob{}, &attachments.Attachment{}); err != nil {
 		return nil, cleanup, err
 	}
 
 	return database, cleanup, nil
 }
 
+// signedToken returns a bearer token for subject signed with the test secret.
+func signedToken(t *testing.T, subject string, scopes ...string) string {
+	appConfig := config.NewAppConfig()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, struct {
+		jwt.RegisteredClaims
+		Scope string `json:"scope,omitempty"`
+	}{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   subject,
+			Issuer:    appConfig.JWTIssuer,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+		Scope: strings.Join(scopes, " "),
+	}).SignedString([]byte(appConfig.JWTSecret))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return "Bearer " + token
+}
+
+func withoutTimestamps(expense *expenses.Expense) *expenses.Expense {
+	e := *expense
+	e.SpentAt = time.Time{}
+	e.CreatedAt = time.Time{}
+	e.UpdatedAt = time.Time{}
+	return &e
+}
+
 func TestITCreate(t *testing.T) {
 	endpoint, cleanup, err := setup()
 	if err != nil {
@@ -76,11 +149,14 @@ func TestITCreate(t *testing.T) {
 			requestBody: expenses.CreateBody,
 			token:       expenses.Token,
 			want: &expenses.Expense{
-				ID:     1,
-				Title:  "test expense",
-				Amount: 100,
-				Note:   "test note",
-				Tags:   pq.StringArray([]string{"tag1", "tag2"}),
+				ID:       1,
+				Title:    "test expense",
+				Amount:   10000,
+				Currency: "THB",
+				Note:     "test note",
+				Tags:     pq.StringArray([]string{"tag1", "tag2"}),
+				Version:  1,
+				OwnerID:  1,
 			},
 			wantStatusCode: http.StatusCreated,
 		},
@@ -122,7 +198,11 @@ func TestITCreate(t *testing.T) {
 				t.Fatal(err)
 			}
 
-			if statusCode == http.StatusCreated && !reflect.DeepEqual(createdExpense, test.want) {
+			if statusCode == http.StatusCreated && createdExpense.SpentAt.IsZero() {
+				t.Errorf("expected spent_at to default to now")
+			}
+
+			if statusCode == http.StatusCreated && !reflect.DeepEqual(withoutTimestamps(createdExpense), test.want) {
 				t.Errorf("unexpected expense created: got %v want %v", createdExpense, test.want)
 			}
 
@@ -296,14 +376,25 @@ func TestITUpdate(t *testing.T) {
 		}
 
 		want := &expenses.Expense{
-			ID:     createdExpense.ID,
-			Title:  "test expense update",
-			Amount: 200,
-			Note:   "test note update",
-			Tags:   pq.StringArray([]string{"tag1", "tag2"}),
+			ID:       createdExpense.ID,
+			Title:    "test expense update",
+			Amount:   20000,
+			Currency: "THB",
+			Note:     "test note update",
+			Tags:     pq.StringArray([]string{"tag1", "tag2"}),
+			Version:  2,
+			OwnerID:  1,
+		}
+
+		if !updatedExpense.SpentAt.Equal(createdExpense.SpentAt) || !updatedExpense.CreatedAt.Equal(createdExpense.CreatedAt) {
+			t.Errorf("unexpected timestamps changed: got %v want %v", updatedExpense, createdExpense)
+		}
+
+		if updatedExpense.UpdatedAt.Before(createdExpense.UpdatedAt) {
+			t.Errorf("expected updated_at to move forward: got %v want after %v", updatedExpense.UpdatedAt, createdExpense.UpdatedAt)
 		}
 
-		if !reflect.DeepEqual(updatedExpense, want) {
+		if !reflect.DeepEqual(withoutTimestamps(updatedExpense), want) {
 			t.Errorf("unexpected expense created: got %v want %v", updatedExpense, want)
 		}
 	})
@@ -457,4 +548,1331 @@ func TestITList(t *testing.T) {
 			t.Errorf("unexpected expenses: got %v want %v", gettedExpenses, createdExpenses)
 		}
 	})
+
+	t.Run("Should return 200 when list expenses with limit and sort", func(t *testing.T) {
+		httpRequest := &testutils.HTTPRequest{
+			Method:   http.MethodGet,
+			Endpoint: fmt.Sprintf("%s/?limit=1&sort=-id", endpoint),
+			Body:     "",
+			Token:    expenses.Token,
+		}
+
+		gettedExpenses := []*expenses.Expense{}
+		statusCode, err := httpRequest.MakeHTTPRequest(&gettedExpenses)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if statusCode != http.StatusOK {
+			t.Errorf("unexpected status code: got %v want %v", statusCode, http.StatusOK)
+		}
+
+		if len(gettedExpenses) != 1 || gettedExpenses[0].ID != 2 {
+			t.Errorf("unexpected expenses: got %v want only expense 2", gettedExpenses)
+		}
+	})
+
+	t.Run("Should return 200 when list expenses within date range", func(t *testing.T) {
+		today := time.Now().In(config.NewAppConfig().TimeZone.Location).Format("2006-01-02")
+		tests := []struct {
+			query   string
+			wantLen int
+		}{
+			{query: "from=" + today + "&to=" + today, wantLen: 2},
+			{query: "to=2000-01-01", wantLen: 0},
+			{query: "from=2999-01-01T00:00:00Z", wantLen: 0},
+		}
+
+		for _, test := range tests {
+			httpRequest := &testutils.HTTPRequest{
+				Method:   http.MethodGet,
+				Endpoint: fmt.Sprintf("%s/?%s", endpoint, test.query),
+				Body:     "",
+				Token:    expenses.Token,
+			}
+
+			gettedExpenses := []*expenses.Expense{}
+			statusCode, err := httpRequest.MakeHTTPRequest(&gettedExpenses)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if statusCode != http.StatusOK {
+				t.Errorf("unexpected status code for %s: got %v want %v", test.query, statusCode, http.StatusOK)
+			}
+
+			if len(gettedExpenses) != test.wantLen {
+				t.Errorf("unexpected expenses for %s: got %d want %d", test.query, len(gettedExpenses), test.wantLen)
+			}
+		}
+	})
+
+	t.Run("Should return 200 when list expenses with filters", func(t *testing.T) {
+		tests := []struct {
+			query   string
+			wantLen int
+		}{
+			{query: "tags=tag1,other", wantLen: 2},
+			{query: "tags=tag1,other&tags_match=all", wantLen: 0},
+			{query: "min_amount=50&max_amount=150", wantLen: 2},
+			{query: "min_amount=150", wantLen: 0},
+			{query: "q=NOTE", wantLen: 2},
+			{query: "q=%25", wantLen: 0},
+		}
+
+		for _, test := range tests {
+			httpRequest := &testutils.HTTPRequest{
+				Method:   http.MethodGet,
+				Endpoint: fmt.Sprintf("%s/?%s", endpoint, test.query),
+				Body:     "",
+				Token:    expenses.Token,
+			}
+
+			gettedExpenses := []*expenses.Expense{}
+			statusCode, err := httpRequest.MakeHTTPRequest(&gettedExpenses)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if statusCode != http.StatusOK {
+				t.Errorf("unexpected status code for %s: got %v want %v", test.query, statusCode, http.StatusOK)
+			}
+
+			if len(gettedExpenses) != test.wantLen {
+				t.Errorf("unexpected expenses for %s: got %d want %d", test.query, len(gettedExpenses), test.wantLen)
+			}
+		}
+	})
+}
+
+func TestITDelete(t *testing.T) {
+	endpoint, cleanup, err := setup()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+
+	t.Run("Should move expense to trash and restore it", func(t *testing.T) {
+		httpRequest := &testutils.HTTPRequest{
+			Method:   http.MethodPost,
+			Endpoint: fmt.Sprintf("%s/", endpoint),
+			Body:     expenses.CreateBody,
+			Token:    expenses.Token,
+		}
+
+		createdExpense := &expenses.Expense{}
+		statusCode, err := httpRequest.MakeHTTPRequest(createdExpense)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if statusCode != http.StatusCreated {
+			t.Errorf("unexpected status code: got %v want %v", statusCode, http.StatusCreated)
+		}
+
+		httpRequest = &testutils.HTTPRequest{
+			Method:   http.MethodDelete,
+			Endpoint: fmt.Sprintf("%s/%d", endpoint, createdExpense.ID),
+			Token:    expenses.Token,
+		}
+
+		statusCode, err = httpRequest.MakeHTTPRequest(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if statusCode != http.StatusNoContent {
+			t.Errorf("unexpected status code: got %v want %v", statusCode, http.StatusNoContent)
+		}
+
+		httpRequest = &testutils.HTTPRequest{
+			Method:   http.MethodGet,
+			Endpoint: fmt.Sprintf("%s/%d", endpoint, createdExpense.ID),
+			Token:    expenses.Token,
+		}
+
+		statusCode, err = httpRequest.MakeHTTPRequest(&expenses.Expense{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if statusCode != http.StatusNotFound {
+			t.Errorf("unexpected status code: got %v want %v", statusCode, http.StatusNotFound)
+		}
+
+		httpRequest = &testutils.HTTPRequest{
+			Method:   http.MethodGet,
+			Endpoint: fmt.Sprintf("%s/trash", endpoint),
+			Token:    expenses.Token,
+		}
+
+		trash := []*expenses.Expense{}
+		statusCode, err = httpRequest.MakeHTTPRequest(&trash)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if statusCode != http.StatusOK {
+			t.Errorf("unexpected status code: got %v want %v", statusCode, http.StatusOK)
+		}
+
+		if len(trash) != 1 || trash[0].ID != createdExpense.ID {
+			t.Errorf("unexpected trash: got %v want expense %d", trash, createdExpense.ID)
+		}
+
+		httpRequest = &testutils.HTTPRequest{
+			Method:   http.MethodPost,
+			Endpoint: fmt.Sprintf("%s/%d/restore", endpoint, createdExpense.ID),
+			Token:    expenses.Token,
+		}
+
+		restoredExpense := &expenses.Expense{}
+		statusCode, err = httpRequest.MakeHTTPRequest(restoredExpense)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if statusCode != http.StatusOK {
+			t.Errorf("unexpected status code: got %v want %v", statusCode, http.StatusOK)
+		}
+
+		restoredExpense.UpdatedAt = createdExpense.UpdatedAt
+		if !reflect.DeepEqual(restoredExpense, createdExpense) {
+			t.Errorf("unexpected expense restored: got %v want %v", restoredExpense, createdExpense)
+		}
+	})
+
+	t.Run("Should return 404 when delete expense with not found id", func(t *testing.T) {
+		httpRequest := &testutils.HTTPRequest{
+			Method:   http.MethodDelete,
+			Endpoint: fmt.Sprintf("%s/999", endpoint),
+			Token:    expenses.Token,
+		}
+
+		statusCode, err := httpRequest.MakeHTTPRequest(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if statusCode != http.StatusNotFound {
+			t.Errorf("unexpected status code: got %v want %v", statusCode, http.StatusNotFound)
+		}
+	})
+
+	t.Run("Should return 404 when restore expense that is not in trash", func(t *testing.T) {
+		httpRequest := &testutils.HTTPRequest{
+			Method:   http.MethodPost,
+			Endpoint: fmt.Sprintf("%s/999/restore", endpoint),
+			Token:    expenses.Token,
+		}
+
+		statusCode, err := httpRequest.MakeHTTPRequest(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if statusCode != http.StatusNotFound {
+			t.Errorf("unexpected status code: got %v want %v", statusCode, http.StatusNotFound)
+		}
+	})
+}
+
+func TestITConvert(t *testing.T) {
+	endpoint, cleanup, err := setup()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+
+	t.Run("Should return converted amounts when list expenses with currency", func(t *testing.T) {
+		httpRequest := &testutils.HTTPRequest{
+			Method:   http.MethodPut,
+			Endpoint: fmt.Sprintf("%s/rates/", strings.TrimSuffix(endpoint, "/"+expenses.Endpoint)),
+			Body:     expenses.RatesBody,
+			Token:    expenses.Token,
+		}
+
+		statusCode, err := httpRequest.MakeHTTPRequest(&[]rates.Rate{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if statusCode != http.StatusOK {
+			t.Errorf("unexpected status code: got %v want %v", statusCode, http.StatusOK)
+		}
+
+		httpRequest = &testutils.HTTPRequest{
+			Method:   http.MethodPost,
+			Endpoint: fmt.Sprintf("%s/", endpoint),
+			Body:     expenses.USDCreateBody,
+			Token:    expenses.Token,
+		}
+
+		statusCode, err = httpRequest.MakeHTTPRequest(&expenses.Expense{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if statusCode != http.StatusCreated {
+			t.Errorf("unexpected status code: got %v want %v", statusCode, http.StatusCreated)
+		}
+
+		httpRequest = &testutils.HTTPRequest{
+			Method:   http.MethodGet,
+			Endpoint: fmt.Sprintf("%s/?currency=THB", endpoint),
+			Token:    expenses.Token,
+		}
+
+		gettedExpenses := []*expenses.Expense{}
+		statusCode, err = httpRequest.MakeHTTPRequest(&gettedExpenses)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if statusCode != http.StatusOK {
+			t.Errorf("unexpected status code: got %v want %v", statusCode, http.StatusOK)
+		}
+
+		want := &expenses.Conversion{Amount: 350000, Currency: "THB"}
+		if len(gettedExpenses) != 1 || !reflect.DeepEqual(gettedExpenses[0].Converted, want) {
+			t.Errorf("unexpected expenses: got %v want converted %v", gettedExpenses, want)
+		}
+	})
+}
+
+func TestITSummary(t *testing.T) {
+	endpoint, cleanup, err := setup()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+
+	t.Run("Should return totals per tag converted to requested currency", func(t *testing.T) {
+		httpRequest := &testutils.HTTPRequest{
+			Method:   http.MethodPut,
+			Endpoint: fmt.Sprintf("%s/rates/", strings.TrimSuffix(endpoint, "/"+expenses.Endpoint)),
+			Body:     expenses.RatesBody,
+			Token:    expenses.Token,
+		}
+
+		statusCode, err := httpRequest.MakeHTTPRequest(&[]rates.Rate{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if statusCode != http.StatusOK {
+			t.Errorf("unexpected status code: got %v want %v", statusCode, http.StatusOK)
+		}
+
+		httpRequest = &testutils.HTTPRequest{
+			Method:   http.MethodPost,
+			Endpoint: fmt.Sprintf("%s/", endpoint),
+			Body:     expenses.USDCreateBody,
+			Token:    expenses.Token,
+		}
+
+		statusCode, err = httpRequest.MakeHTTPRequest(&expenses.Expense{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if statusCode != http.StatusCreated {
+			t.Errorf("unexpected status code: got %v want %v", statusCode, http.StatusCreated)
+		}
+
+		httpRequest = &testutils.HTTPRequest{
+			Method:   http.MethodGet,
+			Endpoint: fmt.Sprintf("%s/summary?group_by=tag&currency=THB&from=2023-01-01&to=2023-01-31", endpoint),
+			Token:    expenses.Token,
+		}
+
+		var summary expenses.Summary
+		statusCode, err = httpRequest.MakeHTTPRequest(&summary)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if statusCode != http.StatusOK {
+			t.Errorf("unexpected status code: got %v want %v", statusCode, http.StatusOK)
+		}
+
+		want := expenses.Summary{
+			GroupBy:  "tag",
+			Currency: "THB",
+			Groups: []expenses.SummaryGroup{
+				{Key: "tag1", Count: 1, Total: 350000, Average: 350000, Min: 350000, Max: 350000},
+				{Key: "tag2", Count: 1, Total: 350000, Average: 350000, Min: 350000, Max: 350000},
+			},
+		}
+		if !reflect.DeepEqual(summary, want) {
+			t.Errorf("unexpected summary: got %v want %v", summary, want)
+		}
+	})
+}
+
+func TestITExport(t *testing.T) {
+	endpoint, cleanup, err := setup()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+
+	t.Run("Should stream filtered expenses as csv", func(t *testing.T) {
+		for _, body := range []string{expenses.CreateBody, expenses.USDCreateBody} {
+			httpRequest := &testutils.HTTPRequest{
+				Method:   http.MethodPost,
+				Endpoint: fmt.Sprintf("%s/", endpoint),
+				Body:     body,
+				Token:    expenses.Token,
+			}
+
+			statusCode, err := httpRequest.MakeHTTPRequest(&expenses.Expense{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if statusCode != http.StatusCreated {
+				t.Errorf("unexpected status code: got %v want %v", statusCode, http.StatusCreated)
+			}
+		}
+
+		request, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/export?format=csv&to=2023-01-31&tag_separator=%%7C", endpoint), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		request.Header.Set("Authorization", expenses.Token)
+		request.Header.Set(expenses.TimeZoneHeader, "UTC")
+
+		resp, err := http.DefaultClient.Do(request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("unexpected status code: got %v want %v", resp.StatusCode, http.StatusOK)
+		}
+
+		rows, err := csv.NewReader(resp.Body).ReadAll()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(rows) != 2 || !reflect.DeepEqual(rows[1][1:7], []string{"test expense", "100.00", "USD", "test note", "tag1|tag2", "2023-01-15T00:00:00Z"}) {
+			t.Errorf("unexpected rows: got %v", rows)
+		}
+	})
+}
+
+func TestITImport(t *testing.T) {
+	endpoint, cleanup, err := setup()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+
+	t.Run("Should insert accepted rows and skip rejected ones", func(t *testing.T) {
+		httpRequest := &testutils.HTTPRequest{
+			Method:   http.MethodPost,
+			Endpoint: fmt.Sprintf("%s/import", endpoint),
+			Body:     "title,amount,note,tags\ncoffee,80,morning,food\nbad,abc,note,food\n",
+			Token:    expenses.Token,
+			Headers:  map[string]string{"Content-Type": "text/csv"},
+		}
+
+		var report expenses.ImportReport
+		statusCode, err := httpRequest.MakeHTTPRequest(&report)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if statusCode != http.StatusCreated {
+			t.Errorf("unexpected status code: got %v want %v", statusCode, http.StatusCreated)
+		}
+
+		if report.Accepted != 1 || report.Rejected != 1 || report.Rows[0].ID == 0 {
+			t.Errorf("unexpected report: %+v", report)
+		}
+
+		httpRequest = &testutils.HTTPRequest{
+			Method:   http.MethodGet,
+			Endpoint: fmt.Sprintf("%s/%d", endpoint, report.Rows[0].ID),
+			Token:    expenses.Token,
+		}
+
+		var expense expenses.Expense
+		statusCode, err = httpRequest.MakeHTTPRequest(&expense)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if statusCode != http.StatusOK || expense.Title != "coffee" || expense.Amount != 8000 {
+			t.Errorf("unexpected expense: got %v status %v", expense, statusCode)
+		}
+	})
+}
+
+func TestITPatch(t *testing.T) {
+	endpoint, cleanup, err := setup()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+
+	t.Run("Should only change patched fields", func(t *testing.T) {
+		httpRequest := &testutils.HTTPRequest{
+			Method:   http.MethodPost,
+			Endpoint: fmt.Sprintf("%s/", endpoint),
+			Body:     expenses.CreateBody,
+			Token:    expenses.Token,
+		}
+
+		var created expenses.Expense
+		statusCode, err := httpRequest.MakeHTTPRequest(&created)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if statusCode != http.StatusCreated {
+			t.Errorf("unexpected status code: got %v want %v", statusCode, http.StatusCreated)
+		}
+
+		patches := []struct {
+			contentType string
+			body        string
+		}{
+			{contentType: expenses.MergePatchContentType, body: expenses.MergePatchBody},
+			{contentType: expenses.JSONPatchContentType, body: expenses.JSONPatchBody},
+		}
+
+		var patched expenses.Expense
+		for _, patch := range patches {
+			httpRequest = &testutils.HTTPRequest{
+				Method:   http.MethodPatch,
+				Endpoint: fmt.Sprintf("%s/%d", endpoint, created.ID),
+				Body:     patch.body,
+				Token:    expenses.Token,
+				Headers:  map[string]string{"Content-Type": patch.contentType},
+			}
+
+			statusCode, err = httpRequest.MakeHTTPRequest(&patched)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if statusCode != http.StatusOK {
+				t.Errorf("unexpected status code: got %v want %v", statusCode, http.StatusOK)
+			}
+		}
+
+		want := &expenses.Expense{
+			ID:       created.ID,
+			Title:    "test expense patch",
+			Amount:   created.Amount,
+			Currency: created.Currency,
+			Note:     "test note patch",
+			Tags:     pq.StringArray([]string{"tag2", "tag3"}),
+			Version:  3,
+			OwnerID:  1,
+		}
+		if got := withoutTimestamps(&patched); !reflect.DeepEqual(got, want) {
+			t.Errorf("unexpected expense: got %v want %v", got, want)
+		}
+
+		if !patched.SpentAt.Equal(created.SpentAt) {
+			t.Errorf("unexpected spent_at: got %v want %v", patched.SpentAt, created.SpentAt)
+		}
+	})
+}
+
+func TestITHistory(t *testing.T) {
+	endpoint, cleanup, err := setup()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+
+	var created, updated expenses.Expense
+	httpRequest := &testutils.HTTPRequest{
+		Method:   http.MethodPost,
+		Endpoint: fmt.Sprintf("%s/", endpoint),
+		Body:     expenses.CreateBody,
+		Token:    expenses.Token,
+	}
+	if _, err := httpRequest.MakeHTTPRequest(&created); err != nil {
+		t.Fatal(err)
+	}
+
+	httpRequest = &testutils.HTTPRequest{
+		Method:   http.MethodPut,
+		Endpoint: fmt.Sprintf("%s/%d", endpoint, created.ID),
+		Body:     expenses.UpdateBody,
+		Token:    expenses.Token,
+	}
+	if _, err := httpRequest.MakeHTTPRequest(&updated); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Should list the create and update of the expense", func(t *testing.T) {
+		httpRequest := &testutils.HTTPRequest{
+			Method:   http.MethodGet,
+			Endpoint: fmt.Sprintf("%s/%d/history", endpoint, created.ID),
+			Token:    expenses.Token,
+		}
+
+		var entries []expenses.History
+		statusCode, err := httpRequest.MakeHTTPRequest(&entries)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if statusCode != http.StatusOK {
+			t.Errorf("unexpected status code: got %v want %v", statusCode, http.StatusOK)
+		}
+
+		if len(entries) != 2 || entries[0].Action != expenses.ActionCreate || entries[1].Action != expenses.ActionUpdate {
+			t.Fatalf("unexpected history: got %+v", entries)
+		}
+
+		if entries[1].Before == nil || entries[1].Before.Title != created.Title || entries[1].After.Title != updated.Title {
+			t.Errorf("unexpected update entry: got %+v", entries[1])
+		}
+	})
+
+	t.Run("Should revert the expense to its first version", func(t *testing.T) {
+		httpRequest := &testutils.HTTPRequest{
+			Method:   http.MethodPost,
+			Endpoint: fmt.Sprintf("%s/%d/revert", endpoint, created.ID),
+			Body:     `{"version": 1}`,
+			Token:    expenses.Token,
+		}
+
+		var reverted expenses.Expense
+		statusCode, err := httpRequest.MakeHTTPRequest(&reverted)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if statusCode != http.StatusOK {
+			t.Errorf("unexpected status code: got %v want %v", statusCode, http.StatusOK)
+		}
+
+		if reverted.Title != created.Title || reverted.Note != created.Note || reverted.Version != updated.Version+1 {
+			t.Errorf("unexpected expense: got %+v", reverted)
+		}
+	})
+}
+
+func TestITConditionalRequests(t *testing.T) {
+	endpoint, cleanup, err := setup()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+
+	do := func(method, url, body string, headers map[string]string) *http.Response {
+		request, err := http.NewRequest(method, url, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		request.Header.Set("Content-Type", "application/json")
+		request.Header.Set("Authorization", expenses.Token)
+		for key, value := range headers {
+			request.Header.Set(key, value)
+		}
+
+		resp, err := http.DefaultClient.Do(request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+
+		return resp
+	}
+
+	t.Run("Should reject updates with a stale ETag", func(t *testing.T) {
+		resp := do(http.MethodPost, fmt.Sprintf("%s/", endpoint), expenses.CreateBody, nil)
+		if resp.StatusCode != http.StatusCreated || resp.Header.Get(expenses.ETagHeader) != `"1"` {
+			t.Fatalf("unexpected create response: %v %v", resp.StatusCode, resp.Header.Get(expenses.ETagHeader))
+		}
+
+		url := fmt.Sprintf("%s/1", endpoint)
+		resp = do(http.MethodPut, url, expenses.UpdateBody, map[string]string{expenses.IfMatchHeader: `"1"`})
+		if resp.StatusCode != http.StatusOK || resp.Header.Get(expenses.ETagHeader) != `"2"` {
+			t.Errorf("unexpected update response: %v %v", resp.StatusCode, resp.Header.Get(expenses.ETagHeader))
+		}
+
+		resp = do(http.MethodPut, url, expenses.UpdateBody, map[string]string{expenses.IfMatchHeader: `"1"`})
+		if resp.StatusCode != http.StatusPreconditionFailed {
+			t.Errorf("unexpected status code: got %v want %v", resp.StatusCode, http.StatusPreconditionFailed)
+		}
+
+		resp = do(http.MethodPatch, url, expenses.MergePatchBody, map[string]string{
+			expenses.IfMatchHeader: `"1"`,
+			"Content-Type":         expenses.MergePatchContentType,
+		})
+		if resp.StatusCode != http.StatusPreconditionFailed {
+			t.Errorf("unexpected status code: got %v want %v", resp.StatusCode, http.StatusPreconditionFailed)
+		}
+
+		resp = do(http.MethodGet, url, "", map[string]string{expenses.IfNoneMatchHeader: `"2"`})
+		if resp.StatusCode != http.StatusNotModified {
+			t.Errorf("unexpected status code: got %v want %v", resp.StatusCode, http.StatusNotModified)
+		}
+	})
+}
+
+func TestITIdempotency(t *testing.T) {
+	endpoint, cleanup, err := setup()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+
+	t.Run("Should create an expense once for retried requests", func(t *testing.T) {
+		var created []expenses.Expense
+		for i := 0; i < 2; i++ {
+			httpRequest := &testutils.HTTPRequest{
+				Method:   http.MethodPost,
+				Endpoint: fmt.Sprintf("%s/", endpoint),
+				Body:     expenses.CreateBody,
+				Token:    expenses.Token,
+				Headers:  map[string]string{idempotency.Header: "retry-key"},
+			}
+
+			var expense expenses.Expense
+			statusCode, err := httpRequest.MakeHTTPRequest(&expense)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if statusCode != http.StatusCreated {
+				t.Errorf("unexpected status code: got %v want %v", statusCode, http.StatusCreated)
+			}
+			created = append(created, expense)
+		}
+
+		if !reflect.DeepEqual(created[0], created[1]) {
+			t.Errorf("unexpected replayed expense: got %v want %v", created[1], created[0])
+		}
+
+		httpRequest := &testutils.HTTPRequest{
+			Method:   http.MethodPost,
+			Endpoint: fmt.Sprintf("%s/", endpoint),
+			Body:     expenses.USDCreateBody,
+			Token:    expenses.Token,
+			Headers:  map[string]string{idempotency.Header: "retry-key"},
+		}
+
+		statusCode, err := httpRequest.MakeHTTPRequest(&expenses.Expense{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if statusCode != http.StatusUnprocessableEntity {
+			t.Errorf("unexpected status code: got %v want %v", statusCode, http.StatusUnprocessableEntity)
+		}
+
+		httpRequest = &testutils.HTTPRequest{
+			Method:   http.MethodGet,
+			Endpoint: fmt.Sprintf("%s/", endpoint),
+			Token:    expenses.Token,
+		}
+
+		var list []expenses.Expense
+		statusCode, err = httpRequest.MakeHTTPRequest(&list)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if statusCode != http.StatusOK || len(list) != 1 {
+			t.Errorf("unexpected expenses: got %d with status %v want 1", len(list), statusCode)
+		}
+	})
+}
+
+func TestITAuth(t *testing.T) {
+	endpoint, cleanup, err := setup()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+
+	appConfig := config.NewAppConfig()
+	t.Run("Should accept a signed bearer token", func(t *testing.T) {
+		httpRequest := &testutils.HTTPRequest{
+			Method:   http.MethodPost,
+			Endpoint: fmt.Sprintf("%s/", endpoint),
+			Body:     expenses.CreateBody,
+			Token:    signedToken(t, "user-1"),
+		}
+
+		statusCode, err := httpRequest.MakeHTTPRequest(&expenses.Expense{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if statusCode != http.StatusCreated {
+			t.Errorf("unexpected status code: got %v want %v", statusCode, http.StatusCreated)
+		}
+	})
+
+	t.Run("Should reject a token signed with another secret", func(t *testing.T) {
+		forged, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
+			Subject:   "user-1",
+			Issuer:    appConfig.JWTIssuer,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		}).SignedString([]byte("forged"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		httpRequest := &testutils.HTTPRequest{
+			Method:   http.MethodGet,
+			Endpoint: fmt.Sprintf("%s/", endpoint),
+			Token:    "Bearer " + forged,
+		}
+
+		statusCode, err := httpRequest.MakeHTTPRequest(&[]expenses.Expense{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if statusCode != http.StatusUnauthorized {
+			t.Errorf("unexpected status code: got %v want %v", statusCode, http.StatusUnauthorized)
+		}
+	})
+}
+
+func TestITOwnership(t *testing.T) {
+	endpoint, cleanup, err := setup()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+
+	alice := signedToken(t, "alice")
+	bob := signedToken(t, "bob")
+
+	created := &expenses.Expense{}
+	httpRequest := &testutils.HTTPRequest{
+		Method:   http.MethodPost,
+		Endpoint: fmt.Sprintf("%s/", endpoint),
+		Body:     expenses.CreateBody,
+		Token:    alice,
+	}
+	if _, err := httpRequest.MakeHTTPRequest(created); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		token          string
+		wantStatusCode int
+	}{
+		{
+			name:           "Should return 404 when another user gets the expense",
+			method:         http.MethodGet,
+			path:           fmt.Sprintf("/%d", created.ID),
+			token:          bob,
+			wantStatusCode: http.StatusNotFound,
+		},
+		{
+			name:           "Should return 404 when another user updates the expense",
+			method:         http.MethodPut,
+			path:           fmt.Sprintf("/%d", created.ID),
+			body:           expenses.UpdateBody,
+			token:          bob,
+			wantStatusCode: http.StatusNotFound,
+		},
+		{
+			name:           "Should return 404 when another user deletes the expense",
+			method:         http.MethodDelete,
+			path:           fmt.Sprintf("/%d", created.ID),
+			token:          bob,
+			wantStatusCode: http.StatusNotFound,
+		},
+		{
+			name:           "Should return 200 when the owner gets the expense",
+			method:         http.MethodGet,
+			path:           fmt.Sprintf("/%d", created.ID),
+			token:          alice,
+			wantStatusCode: http.StatusOK,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			httpRequest := &testutils.HTTPRequest{
+				Method:   test.method,
+				Endpoint: endpoint + test.path,
+				Body:     test.body,
+				Token:    test.token,
+			}
+
+			statusCode, err := httpRequest.MakeHTTPRequest(&expenses.Expense{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if statusCode != test.wantStatusCode {
+				t.Errorf("unexpected status code: got %v want %v", statusCode, test.wantStatusCode)
+			}
+		})
+	}
+
+	t.Run("Should list only the expenses of the user", func(t *testing.T) {
+		httpRequest := &testutils.HTTPRequest{
+			Method:   http.MethodGet,
+			Endpoint: fmt.Sprintf("%s/", endpoint),
+			Token:    bob,
+		}
+
+		var list []expenses.Expense
+		statusCode, err := httpRequest.MakeHTTPRequest(&list)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if statusCode != http.StatusOK || len(list) != 0 {
+			t.Errorf("unexpected expenses: got %v %v want 200 []", statusCode, list)
+		}
+	})
+}
+
+func TestITAPIKeys(t *testing.T) {
+	endpoint, cleanup, err := setup()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+
+	keysEndpoint := strings.TrimSuffix(endpoint, expenses.Endpoint) + "api-keys"
+	token := signedToken(t, "alice", middleware.ScopeAdmin)
+
+	created := &apikeys.CreatedKey{}
+	httpRequest := &testutils.HTTPRequest{
+		Method:   http.MethodPost,
+		Endpoint: fmt.Sprintf("%s/", keysEndpoint),
+		Body:     `{"name": "reports", "scopes": ["expenses:read"]}`,
+		Token:    token,
+	}
+	statusCode, err := httpRequest.MakeHTTPRequest(created)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if statusCode != http.StatusCreated || created.Secret == "" {
+		t.Fatalf("unexpected key created: got %v %v", statusCode, created)
+	}
+
+	tests := []struct {
+		name           string
+		method         string
+		body           string
+		wantStatusCode int
+	}{
+		{
+			name:           "Should allow reads with a read key",
+			method:         http.MethodGet,
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name:           "Should return 403 when writing with a read key",
+			method:         http.MethodPost,
+			body:           expenses.CreateBody,
+			wantStatusCode: http.StatusForbidden,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			httpRequest := &testutils.HTTPRequest{
+				Method:   test.method,
+				Endpoint: fmt.Sprintf("%s/", endpoint),
+				Body:     test.body,
+				Headers:  map[string]string{apikeys.Header: created.Secret},
+			}
+
+			statusCode, err := httpRequest.MakeHTTPRequest(&[]expenses.Expense{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if statusCode != test.wantStatusCode {
+				t.Errorf("unexpected status code: got %v want %v", statusCode, test.wantStatusCode)
+			}
+		})
+	}
+
+	t.Run("Should return 401 when the key was revoked", func(t *testing.T) {
+		httpRequest := &testutils.HTTPRequest{
+			Method:   http.MethodDelete,
+			Endpoint: fmt.Sprintf("%s/%d", keysEndpoint, created.ID),
+			Token:    token,
+		}
+		statusCode, err := httpRequest.MakeHTTPRequest(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if statusCode != http.StatusNoContent {
+			t.Errorf("unexpected status code: got %v want %v", statusCode, http.StatusNoContent)
+		}
+
+		httpRequest = &testutils.HTTPRequest{
+			Method:   http.MethodGet,
+			Endpoint: fmt.Sprintf("%s/", endpoint),
+			Headers:  map[string]string{apikeys.Header: created.Secret},
+		}
+		statusCode, err = httpRequest.MakeHTTPRequest(&[]expenses.Expense{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if statusCode != http.StatusUnauthorized {
+			t.Errorf("unexpected status code: got %v want %v", statusCode, http.StatusUnauthorized)
+		}
+	})
+}
+
+func TestITGroups(t *testing.T) {
+	endpoint, cleanup, err := setup()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+
+	groupsEndpoint := strings.TrimSuffix(endpoint, expenses.Endpoint) + "groups"
+
+	group := &groups.Group{}
+	httpRequest := &testutils.HTTPRequest{
+		Method:   http.MethodPost,
+		Endpoint: fmt.Sprintf("%s/", groupsEndpoint),
+		Body:     `{"name": "trip", "members": ["Ann", "Ben", "Cat"]}`,
+		Token:    expenses.Token,
+	}
+	statusCode, err := httpRequest.MakeHTTPRequest(group)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if statusCode != http.StatusCreated || len(group.Members) != 3 {
+		t.Fatalf("unexpected group created: got %v %v", statusCode, group)
+	}
+
+	ann, ben, cat := group.Members[0].ID, group.Members[1].ID, group.Members[2].ID
+	bodies := []string{
+		fmt.Sprintf(`{"title": "dinner", "amount": 300, "note": "team dinner", "tags": ["food"], "payer_id": %d, "split": {"method": "equal", "members": [{"member_id": %d}, {"member_id": %d}, {"member_id": %d}]}}`, ann, ann, ben, cat),
+		fmt.Sprintf(`{"title": "taxi", "amount": 60, "note": "airport", "tags": ["travel"], "payer_id": %d, "split": {"method": "exact", "members": [{"member_id": %d, "amount": 60}]}}`, ben, cat),
+	}
+	var created []groups.GroupExpense
+	for _, body := range bodies {
+		httpRequest := &testutils.HTTPRequest{
+			Method:   http.MethodPost,
+			Endpoint: fmt.Sprintf("%s/%d/expenses", groupsEndpoint, group.ID),
+			Body:     body,
+			Token:    expenses.Token,
+		}
+
+		expense := groups.GroupExpense{}
+		statusCode, err := httpRequest.MakeHTTPRequest(&expense)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if statusCode != http.StatusCreated {
+			t.Fatalf("unexpected status code: got %v want %v", statusCode, http.StatusCreated)
+		}
+		created = append(created, expense)
+	}
+
+	t.Run("Should return 409 when the amount of a split expense is changed", func(t *testing.T) {
+		httpRequest := &testutils.HTTPRequest{
+			Method:   http.MethodPut,
+			Endpoint: fmt.Sprintf("%s/%d", endpoint, created[0].ID),
+			Body:     `{"title": "dinner", "amount": 400, "note": "team dinner", "tags": ["food"]}`,
+			Token:    expenses.Token,
+		}
+
+		statusCode, err := httpRequest.MakeHTTPRequest(&expenses.Expense{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if statusCode != http.StatusConflict {
+			t.Errorf("unexpected status code: got %v want %v", statusCode, http.StatusConflict)
+		}
+	})
+
+	t.Run("Should return the balances and the transfers that settle them", func(t *testing.T) {
+		httpRequest := &testutils.HTTPRequest{
+			Method:   http.MethodGet,
+			Endpoint: fmt.Sprintf("%s/%d/balances", groupsEndpoint, group.ID),
+			Token:    expenses.Token,
+		}
+
+		balances := &groups.Balances{}
+		statusCode, err := httpRequest.MakeHTTPRequest(balances)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if statusCode != http.StatusOK {
+			t.Errorf("unexpected status code: got %v want %v", statusCode, http.StatusOK)
+		}
+
+		nets := []expenses.Money{0, 0, 0}
+		for i, balance := range balances.Balances {
+			nets[i] = balance.Net
+		}
+		if !reflect.DeepEqual(nets, []expenses.Money{20000, -4000, -16000}) || len(balances.Transfers) != 2 {
+			t.Errorf("unexpected balances: got %v", balances)
+		}
+	})
+
+	t.Run("Should return 404 when another user asks for the balances", func(t *testing.T) {
+		httpRequest := &testutils.HTTPRequest{
+			Method:   http.MethodGet,
+			Endpoint: fmt.Sprintf("%s/%d/balances", groupsEndpoint, group.ID),
+			Token:    signedToken(t, "someone-else"),
+		}
+
+		statusCode, err := httpRequest.MakeHTTPRequest(&groups.Balances{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if statusCode != http.StatusNotFound {
+			t.Errorf("unexpected status code: got %v want %v", statusCode, http.StatusNotFound)
+		}
+	})
+}
+
+func TestITBudgets(t *testing.T) {
+	endpoint, cleanup, err := setup()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+
+	budgetsEndpoint := strings.TrimSuffix(endpoint, expenses.Endpoint) + "budgets"
+	requests := []*testutils.HTTPRequest{
+		{
+			Method:   http.MethodPost,
+			Endpoint: fmt.Sprintf("%s/", budgetsEndpoint),
+			Body:     `{"name": "food", "tags": ["food", "drinks"], "period": "monthly", "limit": 8000}`,
+			Token:    expenses.Token,
+		},
+		{
+			Method:   http.MethodPost,
+			Endpoint: fmt.Sprintf("%s/", endpoint),
+			Body:     `{"title": "lunch", "amount": 120, "note": "noodles", "tags": ["food"]}`,
+			Token:    expenses.Token,
+		},
+		{
+			Method:   http.MethodPost,
+			Endpoint: fmt.Sprintf("%s/", endpoint),
+			Body:     `{"title": "coffee", "amount": 80, "note": "latte", "tags": ["drinks"]}`,
+			Token:    expenses.Token,
+		},
+		{
+			Method:   http.MethodPost,
+			Endpoint: fmt.Sprintf("%s/", endpoint),
+			Body:     `{"title": "taxi", "amount": 200, "note": "airport", "tags": ["travel"]}`,
+			Token:    expenses.Token,
+		},
+	}
+	for _, httpRequest := range requests {
+		statusCode, err := httpRequest.MakeHTTPRequest(&map[string]interface{}{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if statusCode != http.StatusCreated {
+			t.Fatalf("unexpected status code: got %v want %v", statusCode, http.StatusCreated)
+		}
+	}
+
+	t.Run("Should return 200 with what was spent on the tags this period", func(t *testing.T) {
+		httpRequest := &testutils.HTTPRequest{
+			Method:   http.MethodGet,
+			Endpoint: fmt.Sprintf("%s/status", budgetsEndpoint),
+			Token:    expenses.Token,
+		}
+
+		var statuses []budgets.Status
+		statusCode, err := httpRequest.MakeHTTPRequest(&statuses)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if statusCode != http.StatusOK {
+			t.Errorf("unexpected status code: got %v want %v", statusCode, http.StatusOK)
+		}
+
+		if len(statuses) != 1 || statuses[0].Spent != 20000 || statuses[0].Remaining != 780000 || statuses[0].PercentUsed != 2.5 {
+			t.Errorf("unexpected statuses: got %+v", statuses)
+		}
+	})
+}
+
+func TestITRecurring(t *testing.T) {
+	endpoint, cleanup, err := setup()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+
+	appConfig := config.NewAppConfig()
+	database, dbCleanup, err := db.NewConnection(appConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dbCleanup()
+
+	start := time.Now().AddDate(0, 0, -3).Add(-time.Minute).UTC()
+	httpRequest := &testutils.HTTPRequest{
+		Method:   http.MethodPost,
+		Endpoint: strings.TrimSuffix(endpoint, expenses.Endpoint) + "recurring/",
+		Body:     fmt.Sprintf(`{"title": "gym", "amount": 50, "note": "membership", "tags": ["health"], "frequency": "daily", "starts_at": %q}`, start.Format(time.RFC3339)),
+		Token:    expenses.Token,
+	}
+
+	var template recurring.Template
+	statusCode, err := httpRequest.MakeHTTPRequest(&template)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if statusCode != http.StatusCreated {
+		t.Fatalf("unexpected status code: got %v want %v", statusCode, http.StatusCreated)
+	}
+
+	t.Run("Should catch up on missed occurrences only once", func(t *testing.T) {
+		scheduler := recurring.NewScheduler(recurring.NewStore(database), appConfig)
+		for i := 0; i < 2; i++ {
+			if _, err := scheduler.Materialize(time.Now()); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		httpRequest := &testutils.HTTPRequest{
+			Method:   http.MethodGet,
+			Endpoint: fmt.Sprintf("%s/?tags=health", endpoint),
+			Token:    expenses.Token,
+		}
+
+		var list []expenses.Expense
+		statusCode, err := httpRequest.MakeHTTPRequest(&list)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if statusCode != http.StatusOK {
+			t.Errorf("unexpected status code: got %v want %v", statusCode, http.StatusOK)
+		}
+
+		if len(list) != 4 {
+			t.Errorf("unexpected expenses: got %d want %d", len(list), 4)
+		}
+	})
+}
+
+func TestITAttachments(t *testing.T) {
+	endpoint, cleanup, err := setup()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+
+	appConfig := config.NewAppConfig()
+	database, dbCleanup, err := db.NewConnection(appConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dbCleanup()
+
+	var expense expenses.Expense
+	httpRequest := &testutils.HTTPRequest{
+		Method:   http.MethodPost,
+		Endpoint: fmt.Sprintf("%s/", endpoint),
+		Body:     `{"title": "taxi", "amount": 200, "note": "airport", "tags": ["travel"]}`,
+		Token:    expenses.Token,
+	}
+	if _, err := httpRequest.MakeHTTPRequest(&expense); err != nil {
+		t.Fatal(err)
+	}
+
+	content := fmt.Sprintf("%%PDF-1.4 receipt %d", time.Now().UnixNano())
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile(attachments.FormField, "receipt.pdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write([]byte(content))
+	writer.Close()
+
+	upload := &testutils.HTTPRequest{
+		Method:   http.MethodPost,
+		Endpoint: fmt.Sprintf("%s/%d/attachments", endpoint, expense.ID),
+		Body:     body.String(),
+		Token:    expenses.Token,
+		Headers:  map[string]string{"Content-Type": writer.FormDataContentType()},
+	}
+
+	var first, second attachments.Attachment
+	t.Run("Should return the same attachment when the file is uploaded twice", func(t *testing.T) {
+		statusCode, err := upload.MakeHTTPRequest(&first)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if statusCode != http.StatusCreated {
+			t.Fatalf("unexpected status code: got %v want %v", statusCode, http.StatusCreated)
+		}
+
+		statusCode, err = upload.MakeHTTPRequest(&second)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if statusCode != http.StatusOK || second.ID != first.ID {
+			t.Errorf("unexpected second upload: got %v %+v want %v %+v", statusCode, second, http.StatusOK, first)
+		}
+	})
+
+	t.Run("Should download the file", func(t *testing.T) {
+		request, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/%d/attachments/%d", endpoint, expense.ID, first.ID), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		request.Header.Set("Authorization", expenses.Token)
+
+		resp, err := http.DefaultClient.Do(request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.StatusCode != http.StatusOK || string(data) != content || resp.Header.Get("Content-Type") != "application/pdf" {
+			t.Errorf("unexpected download: got %v %q of type %v", resp.StatusCode, data, resp.Header.Get("Content-Type"))
+		}
+	})
+
+	t.Run("Should remove the file once the expense is purged", func(t *testing.T) {
+		if err := database.Unscoped().Delete(&expenses.Expense{}, expense.ID).Error; err != nil {
+			t.Fatal(err)
+		}
+
+		storage := attachments.NewLocalStorage(appConfig.AttachmentDir)
+		collector := attachments.NewCollector(attachments.NewStore(database), storage, appConfig)
+		if _, err := collector.Collect(time.Now().Add(2 * time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := storage.Open(first.Checksum); !errors.Is(err, attachments.ErrBlobNotFound) {
+			t.Errorf("unexpected error: got %v want %v", err, attachments.ErrBlobNotFound)
+		}
+	})
 }
@@ -4,16 +4,26 @@
 package expenses_test
 
 import (
+	"encoding/csv"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/lib/pq"
+	"github.com/tirathawat/assessment/config"
+	"github.com/tirathawat/assessment/errs"
 	"github.com/tirathawat/assessment/expenses"
+	"github.com/tirathawat/assessment/rates"
 	"github.com/tirathawat/assessment/testutils"
+	"github.com/tirathawat/assessment/users"
+	"github.com/xuri/excelize/v2"
 	"gorm.io/gorm"
 )
 
@@ -22,13 +32,41 @@ const (
 	firstMethod  = "First"
 	saveMethod   = "Save"
 	findMethod   = "Find"
+	deleteMethod = "Delete"
+	updateMethod = "Update"
+	countMethod  = "Count"
+	scanMethod   = "Scan"
+	batchMethod  = "FindInBatches"
+	bulkMethod   = "CreateInBatches"
+	txMethod     = "Transaction"
 )
 
+var (
+	cfg       = &config.AppConfig{DefaultCurrency: "THB"}
+	exportCfg = &config.AppConfig{DefaultCurrency: "THB", ExportTagSeparator: ", "}
+)
+
+// testUserID is the authenticated user every handler test runs as.
+const testUserID = 7
+
+// asUser runs the handler as if users.Middleware had authenticated
+// testUserID.
+func asUser(handler gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(users.IDKey, testUserID)
+		handler(c)
+	}
+}
+
 type MockDB struct {
 	returnValue   interface{}
+	relatedValues []interface{}
+	count         int64
 	currentMethod int
 	methodsToCall map[string]bool
 	dbs           []*gorm.DB
+	conditions    []string
+	saved         []interface{}
 }
 
 func (m *MockDB) call() int {
@@ -37,38 +75,107 @@ func (m *MockDB) call() int {
 	return index
 }
 
-func (m *MockDB) Create(value interface{}) *gorm.DB {
-	if m.returnValue != nil {
-		reflect.ValueOf(value).Elem().Set(reflect.ValueOf(m.returnValue).Elem())
+// fill copies returnValue, or the related value of the same type, into dest.
+func (m *MockDB) fill(dest interface{}) {
+	for _, value := range append([]interface{}{m.returnValue}, m.relatedValues...) {
+		if value != nil && reflect.TypeOf(value) == reflect.TypeOf(dest) {
+			reflect.ValueOf(dest).Elem().Set(reflect.ValueOf(value).Elem())
+			return
+		}
 	}
+}
+
+func (m *MockDB) Create(value interface{}) *gorm.DB {
+	m.fill(value)
 	m.methodsToCall["Create"] = true
 	return m.dbs[m.call()]
 }
 
+func (m *MockDB) CreateInBatches(value interface{}, batchSize int) *gorm.DB {
+	m.fill(value)
+	m.methodsToCall["CreateInBatches"] = true
+	return m.dbs[m.call()]
+}
+
 func (m *MockDB) First(dest interface{}, conds ...interface{}) *gorm.DB {
-	if m.returnValue != nil {
-		reflect.ValueOf(dest).Elem().Set(reflect.ValueOf(m.returnValue).Elem())
-	}
+	m.fill(dest)
 	m.methodsToCall["First"] = true
 	return m.dbs[m.call()]
 }
 
 func (m *MockDB) Save(value interface{}) *gorm.DB {
-	if m.returnValue != nil {
-		reflect.ValueOf(value).Elem().Set(reflect.ValueOf(m.returnValue).Elem())
-	}
+	saved := reflect.New(reflect.TypeOf(value).Elem())
+	saved.Elem().Set(reflect.ValueOf(value).Elem())
+	m.saved = append(m.saved, saved.Interface())
+	m.fill(value)
 	m.methodsToCall["Save"] = true
 	return m.dbs[m.call()]
 }
 
 func (m *MockDB) Find(dest interface{}, conds ...interface{}) *gorm.DB {
-	if m.returnValue != nil {
-		reflect.ValueOf(dest).Elem().Set(reflect.ValueOf(m.returnValue).Elem())
-	}
+	m.fill(dest)
 	m.methodsToCall["Find"] = true
 	return m.dbs[m.call()]
 }
 
+func (m *MockDB) Delete(value interface{}, conds ...interface{}) *gorm.DB {
+	m.methodsToCall["Delete"] = true
+	return m.dbs[m.call()]
+}
+
+func (m *MockDB) Update(column string, value interface{}) *gorm.DB {
+	m.methodsToCall["Update"] = true
+	return m.dbs[m.call()]
+}
+
+func (m *MockDB) Count(count *int64) *gorm.DB {
+	*count = m.count
+	m.methodsToCall["Count"] = true
+	return m.dbs[m.call()]
+}
+
+func (m *MockDB) Scan(dest interface{}) *gorm.DB {
+	m.fill(dest)
+	m.methodsToCall["Scan"] = true
+	return m.dbs[m.call()]
+}
+
+func (m *MockDB) FindInBatches(dest interface{}, batchSize int, fc func(tx *gorm.DB, batch int) error) *gorm.DB {
+	m.fill(dest)
+	m.methodsToCall["FindInBatches"] = true
+	db := m.dbs[m.call()]
+	if db.Error == nil && reflect.ValueOf(dest).Elem().Len() > 0 {
+		db.AddError(fc(&gorm.DB{}, 1))
+	}
+	return db
+}
+
+func (m *MockDB) Model(value interface{}) expenses.DB {
+	return m
+}
+
+func (m *MockDB) Where(query interface{}, args ...interface{}) expenses.DB {
+	m.conditions = append(m.conditions, fmt.Sprint(query, args))
+	return m
+}
+
+func (m *MockDB) Select(query interface{}, args ...interface{}) expenses.DB {
+	return m
+}
+
+func (m *MockDB) Unscoped() expenses.DB {
+	return m
+}
+
+func (m *MockDB) Scopes(funcs ...func(*gorm.DB) *gorm.DB) expenses.DB {
+	return m
+}
+
+func (m *MockDB) Transaction(fc func(tx expenses.DB) error) error {
+	m.methodsToCall["Transaction"] = true
+	return fc(m)
+}
+
 func (m *MockDB) Verify(t *testing.T) {
 	for methodName, called := range m.methodsToCall {
 		if !called {
@@ -90,7 +197,7 @@ func TestCreate(t *testing.T) {
 			want: &expenses.Expense{
 				ID:     1,
 				Title:  "test expense",
-				Amount: 100,
+				Amount: 10000,
 				Note:   "test note",
 				Tags:   pq.StringArray([]string{"tag1", "tag2"}),
 			},
@@ -98,11 +205,11 @@ func TestCreate(t *testing.T) {
 				returnValue: &expenses.Expense{
 					ID:     1,
 					Title:  "test expense",
-					Amount: 100,
+					Amount: 10000,
 					Note:   "test note",
 					Tags:   pq.StringArray([]string{"tag1", "tag2"}),
 				},
-				dbs: []*gorm.DB{{}},
+				dbs: []*gorm.DB{{}, {}},
 				methodsToCall: map[string]bool{
 					createMethod: false,
 				},
@@ -138,6 +245,43 @@ func TestCreate(t *testing.T) {
 			},
 			wantStatusCode: http.StatusBadRequest,
 		},
+		{
+			name: "Should return 400 when spent_at is in the future",
+			mockDB: &MockDB{
+				dbs: []*gorm.DB{{}},
+			},
+			httpRequest: &testutils.HTTPRequest{
+				Method:   http.MethodPost,
+				Endpoint: fmt.Sprintf("%s/", expenses.Endpoint),
+				Body:     expenses.FutureCreateBody,
+			},
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name: "Should return 400 when amount is too precise for currency",
+			mockDB: &MockDB{
+				dbs: []*gorm.DB{{}},
+			},
+			httpRequest: &testutils.HTTPRequest{
+				Method:   http.MethodPost,
+				Endpoint: fmt.Sprintf("%s/", expenses.Endpoint),
+				Body:     expenses.FractionalYenCreateBody,
+			},
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name: "Should return 400 when time zone is invalid",
+			mockDB: &MockDB{
+				dbs: []*gorm.DB{{}},
+			},
+			httpRequest: &testutils.HTTPRequest{
+				Method:   http.MethodPost,
+				Endpoint: fmt.Sprintf("%s/", expenses.Endpoint),
+				Body:     expenses.CreateBody,
+				Headers:  map[string]string{expenses.TimeZoneHeader: "Mars/Olympus"},
+			},
+			wantStatusCode: http.StatusBadRequest,
+		},
 		{
 			name: "Should return 500 when database error",
 			mockDB: &MockDB{
@@ -158,7 +302,7 @@ func TestCreate(t *testing.T) {
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
 			createdExpense := &expenses.Expense{}
-			statusCode, err := test.httpRequest.MakeTestHTTPRequest(expenses.NewHandler(test.mockDB).Create, createdExpense)
+			statusCode, err := test.httpRequest.MakeTestHTTPRequest(asUser(expenses.NewHandler(test.mockDB, cfg).Create), createdExpense)
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -184,20 +328,21 @@ func TestGet(t *testing.T) {
 		mockDB         *MockDB
 		httpRequest    *testutils.HTTPRequest
 		wantStatusCode int
+		wantCode       string
 	}{
 		{
 			name: "Should return 200 when get expense successfully",
 			id:   "1",
 			want: &expenses.Expense{
 				Title:  "test expense",
-				Amount: 100,
+				Amount: 10000,
 				Note:   "test note",
 				Tags:   pq.StringArray([]string{"tag1", "tag2"}),
 			},
 			mockDB: &MockDB{
 				returnValue: &expenses.Expense{
 					Title:  "test expense",
-					Amount: 100,
+					Amount: 10000,
 					Note:   "test note",
 					Tags:   pq.StringArray([]string{"tag1", "tag2"}),
 				},
@@ -228,6 +373,7 @@ func TestGet(t *testing.T) {
 				Endpoint: fmt.Sprintf("%s/", expenses.Endpoint),
 			},
 			wantStatusCode: http.StatusNotFound,
+			wantCode:       "EXPENSE_NOT_FOUND",
 		},
 		{
 			name: "Should return 500 when database error",
@@ -245,6 +391,7 @@ func TestGet(t *testing.T) {
 				Endpoint: fmt.Sprintf("%s/", expenses.Endpoint),
 			},
 			wantStatusCode: http.StatusInternalServerError,
+			wantCode:       "EXPENSE_GET_FAILED",
 		},
 		{
 			name: "Should return 400 when id is not a number",
@@ -259,23 +406,39 @@ func TestGet(t *testing.T) {
 				Endpoint: fmt.Sprintf("%s/", expenses.Endpoint),
 			},
 			wantStatusCode: http.StatusBadRequest,
+			wantCode:       "INVALID_ID",
 		},
 	}
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			expense := &expenses.Expense{}
-			statusCode, err := test.httpRequest.MakeTestHTTPRequest(expenses.NewHandler(test.mockDB).Get, expense, gin.Param{Key: "id", Value: test.id})
+			resp, err := test.httpRequest.MakeTestHTTPResponse(asUser(expenses.NewHandler(test.mockDB, cfg).Get), gin.Param{Key: "id", Value: test.id})
 			if err != nil {
 				t.Fatal(err)
 			}
 
-			if statusCode != test.wantStatusCode {
-				t.Errorf("unexpected status code: got %v want %v", statusCode, test.wantStatusCode)
+			if resp.Code != test.wantStatusCode {
+				t.Errorf("unexpected status code: got %v want %v", resp.Code, test.wantStatusCode)
 			}
 
-			if statusCode == http.StatusOK && !reflect.DeepEqual(expense, test.want) {
-				t.Errorf("unexpected expense created: got %v want %v", expense, test.want)
+			if resp.Code != http.StatusOK {
+				var problem errs.Problem
+				if err := json.Unmarshal(resp.Body.Bytes(), &problem); err != nil {
+					t.Fatal(err)
+				}
+
+				if problem.Code != test.wantCode || resp.Header().Get("Content-Type") != errs.ContentType {
+					t.Errorf("unexpected problem: got %+v of type %v want code %v", problem, resp.Header().Get("Content-Type"), test.wantCode)
+				}
+			} else {
+				expense := &expenses.Expense{}
+				if err := json.Unmarshal(resp.Body.Bytes(), expense); err != nil {
+					t.Fatal(err)
+				}
+
+				if !reflect.DeepEqual(expense, test.want) {
+					t.Errorf("unexpected expense created: got %v want %v", expense, test.want)
+				}
 			}
 
 			test.mockDB.Verify(t)
@@ -283,6 +446,80 @@ func TestGet(t *testing.T) {
 	}
 }
 
+func TestGetTimeZone(t *testing.T) {
+	spentAt := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		cfg      *config.AppConfig
+		timeZone string
+		want     string
+	}{
+		{
+			name: "Should render timestamps in UTC when time zone is not configured",
+			cfg:  &config.AppConfig{},
+			want: "2023-01-01T00:00:00Z",
+		},
+		{
+			name: "Should render timestamps in configured time zone",
+			cfg:  &config.AppConfig{TimeZone: config.Location{Location: mustLoadLocation(t, "Asia/Bangkok")}},
+			want: "2023-01-01T07:00:00+07:00",
+		},
+		{
+			name:     "Should render timestamps in requested time zone",
+			cfg:      &config.AppConfig{TimeZone: config.Location{Location: mustLoadLocation(t, "Asia/Bangkok")}},
+			timeZone: "Asia/Tokyo",
+			want:     "2023-01-01T09:00:00+09:00",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mockDB := &MockDB{
+				returnValue: &expenses.Expense{ID: 1, SpentAt: spentAt},
+				dbs:         []*gorm.DB{{}},
+				methodsToCall: map[string]bool{
+					firstMethod: false,
+				},
+			}
+
+			httpRequest := &testutils.HTTPRequest{
+				Method:   http.MethodGet,
+				Endpoint: fmt.Sprintf("%s/", expenses.Endpoint),
+			}
+
+			if test.timeZone != "" {
+				httpRequest.Headers = map[string]string{expenses.TimeZoneHeader: test.timeZone}
+			}
+
+			expense := &expenses.Expense{}
+			statusCode, err := httpRequest.MakeTestHTTPRequest(asUser(expenses.NewHandler(mockDB, test.cfg).Get), expense, gin.Param{Key: "id", Value: "1"})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if statusCode != http.StatusOK {
+				t.Errorf("unexpected status code: got %v want %v", statusCode, http.StatusOK)
+			}
+
+			if got := expense.SpentAt.Format(time.RFC3339); got != test.want {
+				t.Errorf("unexpected spent_at: got %v want %v", got, test.want)
+			}
+
+			mockDB.Verify(t)
+		})
+	}
+}
+
+func mustLoadLocation(t *testing.T, name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return loc
+}
+
 func TestUpdate(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -298,7 +535,7 @@ func TestUpdate(t *testing.T) {
 			want: &expenses.Expense{
 				ID:     1,
 				Title:  "test expense",
-				Amount: 100,
+				Amount: 10000,
 				Note:   "test note",
 				Tags:   pq.StringArray([]string{"tag1", "tag2"}),
 			},
@@ -306,13 +543,14 @@ func TestUpdate(t *testing.T) {
 				returnValue: &expenses.Expense{
 					ID:     1,
 					Title:  "test expense",
-					Amount: 100,
+					Amount: 10000,
 					Note:   "test note",
 					Tags:   pq.StringArray([]string{"tag1", "tag2"}),
 				},
-				dbs: []*gorm.DB{{}, {}},
+				dbs: []*gorm.DB{{}, {}, {RowsAffected: 1}, {}},
 				methodsToCall: map[string]bool{
 					firstMethod: false,
+					countMethod: false,
 					saveMethod:  false,
 				},
 			},
@@ -389,6 +627,44 @@ func TestUpdate(t *testing.T) {
 			},
 			wantStatusCode: http.StatusInternalServerError,
 		},
+		{
+			name: "Should return 409 when changing the amount of an expense split in a group",
+			id:   "1",
+			want: &expenses.Expense{},
+			mockDB: &MockDB{
+				returnValue: &expenses.Expense{ID: 1, Amount: 10000},
+				count:       1,
+				dbs:         []*gorm.DB{{}, {}},
+				methodsToCall: map[string]bool{
+					firstMethod: false,
+					countMethod: false,
+				},
+			},
+			httpRequest: &testutils.HTTPRequest{
+				Method:   http.MethodPut,
+				Endpoint: fmt.Sprintf("%s/", expenses.Endpoint),
+				Body:     expenses.UpdateBody,
+			},
+			wantStatusCode: http.StatusConflict,
+		},
+		{
+			name: "Should return 400 when spent_at is in the future",
+			id:   "1",
+			want: &expenses.Expense{},
+			mockDB: &MockDB{
+				returnValue: &expenses.Expense{ID: 1},
+				dbs:         []*gorm.DB{{}},
+				methodsToCall: map[string]bool{
+					firstMethod: false,
+				},
+			},
+			httpRequest: &testutils.HTTPRequest{
+				Method:   http.MethodPut,
+				Endpoint: fmt.Sprintf("%s/", expenses.Endpoint),
+				Body:     fmt.Sprintf(`{"id":1,"title":"test expense update","amount":200,"note":"test note update","tags":["tag1"],"spent_at":%q}`, time.Now().Add(time.Hour).Format(time.RFC3339)),
+			},
+			wantStatusCode: http.StatusBadRequest,
+		},
 		{
 			name: "Should return 500 when save database error",
 			id:   "1",
@@ -412,7 +688,7 @@ func TestUpdate(t *testing.T) {
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
 			expense := &expenses.Expense{}
-			statusCode, err := test.httpRequest.MakeTestHTTPRequest(expenses.NewHandler(test.mockDB).Update, expense, gin.Param{Key: "id", Value: test.id})
+			statusCode, err := test.httpRequest.MakeTestHTTPRequest(asUser(expenses.NewHandler(test.mockDB, cfg).Update), expense, gin.Param{Key: "id", Value: test.id})
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -444,7 +720,7 @@ func TestList(t *testing.T) {
 			want: []expenses.Expense{{
 				ID:     1,
 				Title:  "test expense",
-				Amount: 100,
+				Amount: 10000,
 				Note:   "test note",
 				Tags:   pq.StringArray([]string{"tag1", "tag2"}),
 			}},
@@ -452,13 +728,15 @@ func TestList(t *testing.T) {
 				returnValue: &[]expenses.Expense{{
 					ID:     1,
 					Title:  "test expense",
-					Amount: 100,
+					Amount: 10000,
 					Note:   "test note",
 					Tags:   pq.StringArray([]string{"tag1", "tag2"}),
 				}},
-				dbs: []*gorm.DB{{}},
+				count: 1,
+				dbs:   []*gorm.DB{{}, {}},
 				methodsToCall: map[string]bool{
-					findMethod: false,
+					countMethod: false,
+					findMethod:  false,
 				},
 			},
 			httpRequest: &testutils.HTTPRequest{
@@ -468,13 +746,145 @@ func TestList(t *testing.T) {
 			wantStatusCode: http.StatusOK,
 		},
 		{
-			name: "Should return 500 when database error",
+			name: "Should return converted amounts when currency is requested",
+			want: []expenses.Expense{{
+				ID:        1,
+				Amount:    10000,
+				Currency:  "USD",
+				SpentAt:   time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+				Converted: &expenses.Conversion{Amount: 350000, Currency: "THB"},
+			}},
+			mockDB: &MockDB{
+				returnValue: &[]expenses.Expense{{
+					ID:       1,
+					Amount:   10000,
+					Currency: "USD",
+					SpentAt:  time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+				}},
+				relatedValues: []interface{}{&[]rates.Rate{
+					{Currency: "USD", EffectiveOn: rates.Date{Time: time.Date(2022, 12, 1, 0, 0, 0, 0, time.UTC)}, Rate: 35},
+				}},
+				count: 1,
+				dbs:   []*gorm.DB{{}, {}, {}},
+				methodsToCall: map[string]bool{
+					countMethod: false,
+					findMethod:  false,
+				},
+			},
+			httpRequest: &testutils.HTTPRequest{
+				Method:   http.MethodGet,
+				Endpoint: fmt.Sprintf("%s?currency=THB", expenses.Endpoint),
+			},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name: "Should return 422 when exchange rate is missing",
+			mockDB: &MockDB{
+				returnValue: &[]expenses.Expense{{ID: 1, Amount: 10000, Currency: "USD"}},
+				count:       1,
+				dbs:         []*gorm.DB{{}, {}, {}},
+				methodsToCall: map[string]bool{
+					countMethod: false,
+					findMethod:  false,
+				},
+			},
+			httpRequest: &testutils.HTTPRequest{
+				Method:   http.MethodGet,
+				Endpoint: fmt.Sprintf("%s?currency=THB", expenses.Endpoint),
+			},
+			wantStatusCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name: "Should return 400 when currency is unknown",
+			mockDB: &MockDB{
+				dbs: []*gorm.DB{{}},
+			},
+			httpRequest: &testutils.HTTPRequest{
+				Method:   http.MethodGet,
+				Endpoint: fmt.Sprintf("%s?currency=XYZ", expenses.Endpoint),
+			},
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name: "Should return only limit expenses when more rows exist",
+			want: []expenses.Expense{{ID: 2}, {ID: 1}},
+			mockDB: &MockDB{
+				returnValue: &[]expenses.Expense{{ID: 2}, {ID: 1}, {ID: 0}},
+				count:       3,
+				dbs:         []*gorm.DB{{}, {}},
+				methodsToCall: map[string]bool{
+					countMethod: false,
+					findMethod:  false,
+				},
+			},
+			httpRequest: &testutils.HTTPRequest{
+				Method:   http.MethodGet,
+				Endpoint: fmt.Sprintf("%s?limit=2&sort=-id", expenses.Endpoint),
+			},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name: "Should return 400 when limit is out of range",
+			mockDB: &MockDB{
+				dbs: []*gorm.DB{{}},
+			},
+			httpRequest: &testutils.HTTPRequest{
+				Method:   http.MethodGet,
+				Endpoint: fmt.Sprintf("%s?limit=1000", expenses.Endpoint),
+			},
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name: "Should return 400 when sort column is unknown",
+			mockDB: &MockDB{
+				dbs: []*gorm.DB{{}},
+			},
+			httpRequest: &testutils.HTTPRequest{
+				Method:   http.MethodGet,
+				Endpoint: fmt.Sprintf("%s?sort=note", expenses.Endpoint),
+			},
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name: "Should return 400 when min_amount is greater than max_amount",
+			mockDB: &MockDB{
+				dbs: []*gorm.DB{{}},
+			},
+			httpRequest: &testutils.HTTPRequest{
+				Method:   http.MethodGet,
+				Endpoint: fmt.Sprintf("%s?min_amount=200&max_amount=100", expenses.Endpoint),
+			},
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name: "Should return 400 when tags_match is unknown",
+			mockDB: &MockDB{
+				dbs: []*gorm.DB{{}},
+			},
+			httpRequest: &testutils.HTTPRequest{
+				Method:   http.MethodGet,
+				Endpoint: fmt.Sprintf("%s?tags=food&tags_match=some", expenses.Endpoint),
+			},
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name: "Should return 400 when cursor is invalid",
+			mockDB: &MockDB{
+				dbs: []*gorm.DB{{}},
+			},
+			httpRequest: &testutils.HTTPRequest{
+				Method:   http.MethodGet,
+				Endpoint: fmt.Sprintf("%s?cursor=invalid", expenses.Endpoint),
+			},
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name: "Should return 500 when count database error",
 			want: []expenses.Expense{},
 			mockDB: &MockDB{
-				returnValue: &[]expenses.Expense{},
-				dbs:         []*gorm.DB{{Error: errors.New("error")}},
+				dbs: []*gorm.DB{{Error: errors.New("error")}},
 				methodsToCall: map[string]bool{
-					findMethod: false,
+					countMethod: false,
 				},
 			},
 			httpRequest: &testutils.HTTPRequest{
@@ -483,14 +893,193 @@ func TestList(t *testing.T) {
 			},
 			wantStatusCode: http.StatusInternalServerError,
 		},
-	}
-
-	for _, test := range tests {
-		t.Run(test.name, func(t *testing.T) {
-			list := []expenses.Expense{}
-			statusCode, err := test.httpRequest.MakeTestHTTPRequest(expenses.NewHandler(test.mockDB).List, &list)
-			if err != nil {
-				t.Fatal(err)
+		{
+			name: "Should return 500 when database error",
+			want: []expenses.Expense{},
+			mockDB: &MockDB{
+				returnValue: &[]expenses.Expense{},
+				dbs:         []*gorm.DB{{}, {Error: errors.New("error")}},
+				methodsToCall: map[string]bool{
+					countMethod: false,
+					findMethod:  false,
+				},
+			},
+			httpRequest: &testutils.HTTPRequest{
+				Method:   http.MethodGet,
+				Endpoint: expenses.Endpoint,
+			},
+			wantStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			list := []expenses.Expense{}
+			statusCode, err := test.httpRequest.MakeTestHTTPRequest(asUser(expenses.NewHandler(test.mockDB, cfg).List), &list)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if statusCode != test.wantStatusCode {
+				t.Errorf("unexpected status code: got %v want %v", statusCode, test.wantStatusCode)
+			}
+
+			if statusCode == http.StatusOK && !reflect.DeepEqual(list, test.want) {
+				t.Errorf("unexpected expenses list: got %v want %v", list, test.want)
+			}
+
+			test.mockDB.Verify(t)
+		})
+	}
+}
+
+func TestDelete(t *testing.T) {
+	tests := []struct {
+		name           string
+		id             string
+		mockDB         *MockDB
+		wantStatusCode int
+	}{
+		{
+			name: "Should return 204 when delete expense successfully",
+			id:   "1",
+			mockDB: &MockDB{
+				dbs: []*gorm.DB{{}, {RowsAffected: 1}, {}},
+				methodsToCall: map[string]bool{
+					deleteMethod: false,
+				},
+			},
+			wantStatusCode: http.StatusNoContent,
+		},
+		{
+			name: "Should return 400 when id is not a number",
+			id:   "invalid",
+			mockDB: &MockDB{
+				dbs: []*gorm.DB{{}},
+			},
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name: "Should return 404 when expense not found",
+			id:   "1",
+			mockDB: &MockDB{
+				dbs: []*gorm.DB{{Error: gorm.ErrRecordNotFound}},
+				methodsToCall: map[string]bool{
+					firstMethod: false,
+				},
+			},
+			wantStatusCode: http.StatusNotFound,
+		},
+		{
+			name: "Should return 404 when expense was deleted by another request",
+			id:   "1",
+			mockDB: &MockDB{
+				dbs: []*gorm.DB{{}, {RowsAffected: 0}},
+				methodsToCall: map[string]bool{
+					deleteMethod: false,
+				},
+			},
+			wantStatusCode: http.StatusNotFound,
+		},
+		{
+			name: "Should return 500 when database error",
+			id:   "1",
+			mockDB: &MockDB{
+				dbs: []*gorm.DB{{}, {Error: errors.New("error")}},
+				methodsToCall: map[string]bool{
+					deleteMethod: false,
+				},
+			},
+			wantStatusCode: http.StatusInternalServerError,
+		},
+		{
+			name: "Should return 500 when history cannot be recorded",
+			id:   "1",
+			mockDB: &MockDB{
+				dbs: []*gorm.DB{{}, {RowsAffected: 1}, {Error: errors.New("error")}},
+				methodsToCall: map[string]bool{
+					createMethod: false,
+				},
+			},
+			wantStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			httpRequest := &testutils.HTTPRequest{
+				Method:   http.MethodDelete,
+				Endpoint: fmt.Sprintf("%s/", expenses.Endpoint),
+			}
+
+			statusCode, err := httpRequest.MakeTestHTTPRequest(asUser(expenses.NewHandler(test.mockDB, cfg).Delete), nil, gin.Param{Key: "id", Value: test.id})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if statusCode != test.wantStatusCode {
+				t.Errorf("unexpected status code: got %v want %v", statusCode, test.wantStatusCode)
+			}
+
+			test.mockDB.Verify(t)
+		})
+	}
+}
+
+func TestTrash(t *testing.T) {
+	tests := []struct {
+		name           string
+		want           []expenses.Expense
+		mockDB         *MockDB
+		wantStatusCode int
+	}{
+		{
+			name: "Should return 200 when list deleted expenses successfully",
+			want: []expenses.Expense{{
+				ID:     1,
+				Title:  "test expense",
+				Amount: 10000,
+				Note:   "test note",
+				Tags:   pq.StringArray([]string{"tag1", "tag2"}),
+			}},
+			mockDB: &MockDB{
+				returnValue: &[]expenses.Expense{{
+					ID:     1,
+					Title:  "test expense",
+					Amount: 10000,
+					Note:   "test note",
+					Tags:   pq.StringArray([]string{"tag1", "tag2"}),
+				}},
+				dbs: []*gorm.DB{{}},
+				methodsToCall: map[string]bool{
+					findMethod: false,
+				},
+			},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name: "Should return 500 when database error",
+			mockDB: &MockDB{
+				dbs: []*gorm.DB{{Error: errors.New("error")}},
+				methodsToCall: map[string]bool{
+					findMethod: false,
+				},
+			},
+			wantStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			httpRequest := &testutils.HTTPRequest{
+				Method:   http.MethodGet,
+				Endpoint: fmt.Sprintf("%s/trash", expenses.Endpoint),
+			}
+
+			list := []expenses.Expense{}
+			statusCode, err := httpRequest.MakeTestHTTPRequest(asUser(expenses.NewHandler(test.mockDB, cfg).Trash), &list)
+			if err != nil {
+				t.Fatal(err)
 			}
 
 			if statusCode != test.wantStatusCode {
@@ -505,3 +1094,1268 @@ func TestList(t *testing.T) {
 		})
 	}
 }
+
+func TestRestore(t *testing.T) {
+	tests := []struct {
+		name           string
+		id             string
+		want           *expenses.Expense
+		mockDB         *MockDB
+		wantStatusCode int
+	}{
+		{
+			name: "Should return 200 when restore expense successfully",
+			id:   "1",
+			want: &expenses.Expense{
+				ID:     1,
+				Title:  "test expense",
+				Amount: 10000,
+				Note:   "test note",
+				Tags:   pq.StringArray([]string{"tag1", "tag2"}),
+			},
+			mockDB: &MockDB{
+				returnValue: &expenses.Expense{
+					ID:     1,
+					Title:  "test expense",
+					Amount: 10000,
+					Note:   "test note",
+					Tags:   pq.StringArray([]string{"tag1", "tag2"}),
+				},
+				dbs: []*gorm.DB{{RowsAffected: 1}, {}, {}},
+				methodsToCall: map[string]bool{
+					updateMethod: false,
+					firstMethod:  false,
+				},
+			},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name: "Should return 400 when id is not a number",
+			id:   "invalid",
+			mockDB: &MockDB{
+				dbs: []*gorm.DB{{}},
+			},
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name: "Should return 404 when expense is not in trash",
+			id:   "1",
+			mockDB: &MockDB{
+				dbs: []*gorm.DB{{RowsAffected: 0}},
+				methodsToCall: map[string]bool{
+					updateMethod: false,
+				},
+			},
+			wantStatusCode: http.StatusNotFound,
+		},
+		{
+			name: "Should return 500 when database error",
+			id:   "1",
+			mockDB: &MockDB{
+				dbs: []*gorm.DB{{Error: errors.New("error")}},
+				methodsToCall: map[string]bool{
+					updateMethod: false,
+				},
+			},
+			wantStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			httpRequest := &testutils.HTTPRequest{
+				Method:   http.MethodPost,
+				Endpoint: fmt.Sprintf("%s/", expenses.Endpoint),
+			}
+
+			expense := &expenses.Expense{}
+			statusCode, err := httpRequest.MakeTestHTTPRequest(asUser(expenses.NewHandler(test.mockDB, cfg).Restore), expense, gin.Param{Key: "id", Value: test.id})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if statusCode != test.wantStatusCode {
+				t.Errorf("unexpected status code: got %v want %v", statusCode, test.wantStatusCode)
+			}
+
+			if statusCode == http.StatusOK && !reflect.DeepEqual(expense, test.want) {
+				t.Errorf("unexpected expense restored: got %v want %v", expense, test.want)
+			}
+
+			test.mockDB.Verify(t)
+		})
+	}
+}
+
+func TestSummary(t *testing.T) {
+	tests := []struct {
+		name           string
+		endpoint       string
+		want           expenses.Summary
+		mockDB         *MockDB
+		wantStatusCode int
+	}{
+		{
+			name:     "Should return 200 with groups in the default currency",
+			endpoint: fmt.Sprintf("%s/summary?group_by=tag", expenses.Endpoint),
+			want: expenses.Summary{
+				GroupBy:  "tag",
+				Currency: "THB",
+				Groups: []expenses.SummaryGroup{
+					{Key: "food", Count: 2, Total: 30000, Average: 15000, Min: 10000, Max: 20000},
+				},
+			},
+			mockDB: &MockDB{
+				returnValue: &[]expenses.SummaryGroup{
+					{Key: "food", Count: 2, Total: 30000, Average: 15000, Min: 10000, Max: 20000},
+				},
+				dbs: []*gorm.DB{{}},
+				methodsToCall: map[string]bool{
+					scanMethod: false,
+				},
+			},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name:     "Should return requested currency",
+			endpoint: fmt.Sprintf("%s/summary?group_by=month&currency=USD", expenses.Endpoint),
+			want: expenses.Summary{
+				GroupBy:  "month",
+				Currency: "USD",
+				Groups:   []expenses.SummaryGroup{},
+			},
+			mockDB: &MockDB{
+				dbs: []*gorm.DB{{}},
+				methodsToCall: map[string]bool{
+					scanMethod: false,
+				},
+			},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name:     "Should return 400 when group_by is missing",
+			endpoint: fmt.Sprintf("%s/summary", expenses.Endpoint),
+			mockDB: &MockDB{
+				methodsToCall: map[string]bool{},
+			},
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:     "Should return 400 when group_by is unknown",
+			endpoint: fmt.Sprintf("%s/summary?group_by=year", expenses.Endpoint),
+			mockDB: &MockDB{
+				methodsToCall: map[string]bool{},
+			},
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:     "Should return 400 when date range is invalid",
+			endpoint: fmt.Sprintf("%s/summary?group_by=week&from=2023-02-01&to=2023-01-01", expenses.Endpoint),
+			mockDB: &MockDB{
+				methodsToCall: map[string]bool{},
+			},
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:     "Should return 422 when exchange rate is missing",
+			endpoint: fmt.Sprintf("%s/summary?group_by=tag&currency=USD", expenses.Endpoint),
+			mockDB: &MockDB{
+				returnValue: &[]expenses.SummaryGroup{
+					{Key: "food", Count: 2, Total: 300, Unconverted: 1},
+				},
+				dbs: []*gorm.DB{{}},
+				methodsToCall: map[string]bool{
+					scanMethod: false,
+				},
+			},
+			wantStatusCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:     "Should return 500 when database error",
+			endpoint: fmt.Sprintf("%s/summary?group_by=tag", expenses.Endpoint),
+			mockDB: &MockDB{
+				dbs: []*gorm.DB{{Error: errors.New("error")}},
+				methodsToCall: map[string]bool{
+					scanMethod: false,
+				},
+			},
+			wantStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			httpRequest := &testutils.HTTPRequest{
+				Method:   http.MethodGet,
+				Endpoint: test.endpoint,
+			}
+
+			var summary expenses.Summary
+			statusCode, err := httpRequest.MakeTestHTTPRequest(asUser(expenses.NewHandler(test.mockDB, cfg).Summary), &summary)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if statusCode != test.wantStatusCode {
+				t.Errorf("unexpected status code: got %v want %v", statusCode, test.wantStatusCode)
+			}
+
+			if statusCode == http.StatusOK && !reflect.DeepEqual(summary, test.want) {
+				t.Errorf("unexpected summary: got %v want %v", summary, test.want)
+			}
+
+			test.mockDB.Verify(t)
+		})
+	}
+}
+
+func TestExport(t *testing.T) {
+	expense := expenses.Expense{
+		ID:        1,
+		Title:     "test expense",
+		Amount:    10050,
+		Currency:  "THB",
+		Note:      "test note",
+		Tags:      pq.StringArray([]string{"tag1", "tag2"}),
+		SpentAt:   time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+		CreatedAt: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+		UpdatedAt: time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC),
+	}
+	formula := expense
+	formula.Title = `=HYPERLINK("http://example.com","click")`
+	formula.Note = "+1"
+	formula.Tags = pq.StringArray([]string{"-tag1", "@tag2"})
+	header := []string{"id", "title", "amount", "currency", "note", "tags", "spent_at", "created_at", "updated_at"}
+
+	tests := []struct {
+		name            string
+		endpoint        string
+		mockDB          *MockDB
+		wantStatusCode  int
+		wantContentType string
+		want            [][]string
+	}{
+		{
+			name:     "Should return csv by default",
+			endpoint: fmt.Sprintf("%s/export", expenses.Endpoint),
+			mockDB: &MockDB{
+				returnValue: &[]expenses.Expense{expense},
+				dbs:         []*gorm.DB{{}},
+				methodsToCall: map[string]bool{
+					batchMethod: false,
+				},
+			},
+			wantStatusCode:  http.StatusOK,
+			wantContentType: "text/csv; charset=utf-8",
+			want: [][]string{
+				header,
+				{"1", "test expense", "100.50", "THB", "test note", "tag1, tag2", "2023-01-01T00:00:00Z", "2023-01-01T00:00:00Z", "2023-01-02T00:00:00Z"},
+			},
+		},
+		{
+			name:     "Should join tags with the requested separator",
+			endpoint: fmt.Sprintf("%s/export?format=csv&tag_separator=%%7C", expenses.Endpoint),
+			mockDB: &MockDB{
+				returnValue: &[]expenses.Expense{expense},
+				dbs:         []*gorm.DB{{}},
+				methodsToCall: map[string]bool{
+					batchMethod: false,
+				},
+			},
+			wantStatusCode:  http.StatusOK,
+			wantContentType: "text/csv; charset=utf-8",
+			want: [][]string{
+				header,
+				{"1", "test expense", "100.50", "THB", "test note", "tag1|tag2", "2023-01-01T00:00:00Z", "2023-01-01T00:00:00Z", "2023-01-02T00:00:00Z"},
+			},
+		},
+		{
+			name:     "Should return xlsx when requested",
+			endpoint: fmt.Sprintf("%s/export?format=xlsx", expenses.Endpoint),
+			mockDB: &MockDB{
+				returnValue: &[]expenses.Expense{expense},
+				dbs:         []*gorm.DB{{}},
+				methodsToCall: map[string]bool{
+					batchMethod: false,
+				},
+			},
+			wantStatusCode:  http.StatusOK,
+			wantContentType: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+			want: [][]string{
+				header,
+				{"1", "test expense", "100.5", "THB", "test note", "tag1, tag2", "2023-01-01T00:00:00Z", "2023-01-01T00:00:00Z", "2023-01-02T00:00:00Z"},
+			},
+		},
+		{
+			name:     "Should escape text that would run as a formula",
+			endpoint: fmt.Sprintf("%s/export", expenses.Endpoint),
+			mockDB: &MockDB{
+				returnValue: &[]expenses.Expense{formula},
+				dbs:         []*gorm.DB{{}},
+				methodsToCall: map[string]bool{
+					batchMethod: false,
+				},
+			},
+			wantStatusCode:  http.StatusOK,
+			wantContentType: "text/csv; charset=utf-8",
+			want: [][]string{
+				header,
+				{"1", `'=HYPERLINK("http://example.com","click")`, "100.50", "THB", "'+1", "'-tag1, @tag2", "2023-01-01T00:00:00Z", "2023-01-01T00:00:00Z", "2023-01-02T00:00:00Z"},
+			},
+		},
+		{
+			name:     "Should escape formulas in xlsx",
+			endpoint: fmt.Sprintf("%s/export?format=xlsx", expenses.Endpoint),
+			mockDB: &MockDB{
+				returnValue: &[]expenses.Expense{formula},
+				dbs:         []*gorm.DB{{}},
+				methodsToCall: map[string]bool{
+					batchMethod: false,
+				},
+			},
+			wantStatusCode:  http.StatusOK,
+			wantContentType: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+			want: [][]string{
+				header,
+				{"1", `'=HYPERLINK("http://example.com","click")`, "100.5", "THB", "'+1", "'-tag1, @tag2", "2023-01-01T00:00:00Z", "2023-01-01T00:00:00Z", "2023-01-02T00:00:00Z"},
+			},
+		},
+		{
+			name:     "Should return only the header when nothing matches",
+			endpoint: fmt.Sprintf("%s/export", expenses.Endpoint),
+			mockDB: &MockDB{
+				dbs: []*gorm.DB{{}},
+				methodsToCall: map[string]bool{
+					batchMethod: false,
+				},
+			},
+			wantStatusCode:  http.StatusOK,
+			wantContentType: "text/csv; charset=utf-8",
+			want:            [][]string{header},
+		},
+		{
+			name:     "Should return 400 when format is unknown",
+			endpoint: fmt.Sprintf("%s/export?format=pdf", expenses.Endpoint),
+			mockDB: &MockDB{
+				methodsToCall: map[string]bool{},
+			},
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:     "Should return 500 when database error",
+			endpoint: fmt.Sprintf("%s/export", expenses.Endpoint),
+			mockDB: &MockDB{
+				dbs: []*gorm.DB{{Error: errors.New("error")}},
+				methodsToCall: map[string]bool{
+					batchMethod: false,
+				},
+			},
+			wantStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			httpRequest := &testutils.HTTPRequest{
+				Method:   http.MethodGet,
+				Endpoint: test.endpoint,
+				Headers:  map[string]string{expenses.TimeZoneHeader: "UTC"},
+			}
+
+			resp, err := httpRequest.MakeTestHTTPResponse(asUser(expenses.NewHandler(test.mockDB, exportCfg).Export))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if resp.Code != test.wantStatusCode {
+				t.Errorf("unexpected status code: got %v want %v", resp.Code, test.wantStatusCode)
+			}
+
+			test.mockDB.Verify(t)
+			if resp.Code != http.StatusOK {
+				return
+			}
+
+			if got := resp.Header().Get("Content-Type"); got != test.wantContentType {
+				t.Errorf("unexpected content type: got %v want %v", got, test.wantContentType)
+			}
+
+			if got := resp.Header().Get("Content-Disposition"); !strings.HasPrefix(got, "attachment; filename=expenses-") {
+				t.Errorf("unexpected content disposition: got %v", got)
+			}
+
+			var rows [][]string
+			if strings.HasPrefix(test.wantContentType, "text/csv") {
+				rows, err = csv.NewReader(resp.Body).ReadAll()
+			} else {
+				rows, err = readXLSX(resp.Body)
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(rows, test.want) {
+				t.Errorf("unexpected rows: got %v want %v", rows, test.want)
+			}
+		})
+	}
+}
+
+func readXLSX(r io.Reader) ([][]string, error) {
+	file, err := excelize.OpenReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return file.GetRows(file.GetSheetName(0))
+}
+
+func TestImport(t *testing.T) {
+	csvBody := "id,title,amount,currency,note,tags,spent_at\n" +
+		"1,coffee,80,,morning,\"food, beverage\",2023-01-01\n" +
+		"2,sushi,1200,JPY,dinner,food,2023-01-02T19:00:00Z\n" +
+		"3,bad amount,abc,,note,food,\n" +
+		"4,,100,,no title,food,\n"
+	jsonlBody := `{"title":"coffee","amount":80,"note":"morning","tags":["food"]}` + "\n" +
+		"\n" +
+		`{"title":"yen","amount":100.5,"currency":"JPY","note":"fractional","tags":["food"]}` + "\n" +
+		`{"title":` + "\n"
+
+	tests := []struct {
+		name           string
+		endpoint       string
+		contentType    string
+		body           string
+		cfg            *config.AppConfig
+		mockDB         *MockDB
+		wantStatusCode int
+		want           expenses.ImportReport
+	}{
+		{
+			name:        "Should import accepted csv rows and report rejected ones",
+			endpoint:    fmt.Sprintf("%s/import", expenses.Endpoint),
+			contentType: "text/csv",
+			body:        csvBody,
+			mockDB: &MockDB{
+				returnValue: &[]expenses.Expense{{ID: 10}, {ID: 11}},
+				dbs:         []*gorm.DB{{}, {}},
+				methodsToCall: map[string]bool{
+					txMethod:   false,
+					bulkMethod: false,
+				},
+			},
+			wantStatusCode: http.StatusCreated,
+			want: expenses.ImportReport{
+				Accepted: 2,
+				Rejected: 2,
+				Rows: []expenses.ImportRow{
+					{Line: 2, Status: "accepted", ID: 10},
+					{Line: 3, Status: "accepted", ID: 11},
+					{Line: 4, Status: "rejected"},
+					{Line: 5, Status: "rejected"},
+				},
+			},
+		},
+		{
+			name:        "Should only validate json lines on dry run",
+			endpoint:    fmt.Sprintf("%s/import?dry_run=true", expenses.Endpoint),
+			contentType: "application/x-ndjson",
+			body:        jsonlBody,
+			mockDB: &MockDB{
+				methodsToCall: map[string]bool{},
+			},
+			wantStatusCode: http.StatusOK,
+			want: expenses.ImportReport{
+				DryRun:   true,
+				Accepted: 1,
+				Rejected: 2,
+				Rows: []expenses.ImportRow{
+					{Line: 1, Status: "accepted"},
+					{Line: 3, Status: "rejected"},
+					{Line: 4, Status: "rejected"},
+				},
+			},
+		},
+		{
+			name:        "Should use format from query over content type",
+			endpoint:    fmt.Sprintf("%s/import?format=jsonl&dry_run=true", expenses.Endpoint),
+			contentType: "text/plain",
+			body:        `{"title":"coffee","amount":80,"note":"morning","tags":["food"]}`,
+			mockDB: &MockDB{
+				methodsToCall: map[string]bool{},
+			},
+			wantStatusCode: http.StatusOK,
+			want: expenses.ImportReport{
+				DryRun:   true,
+				Accepted: 1,
+				Rows:     []expenses.ImportRow{{Line: 1, Status: "accepted"}},
+			},
+		},
+		{
+			name:        "Should return 415 when content type is unsupported",
+			endpoint:    fmt.Sprintf("%s/import", expenses.Endpoint),
+			contentType: "application/xml",
+			body:        "<expenses/>",
+			mockDB: &MockDB{
+				methodsToCall: map[string]bool{},
+			},
+			wantStatusCode: http.StatusUnsupportedMediaType,
+		},
+		{
+			name:        "Should return 400 when csv header is missing columns",
+			endpoint:    fmt.Sprintf("%s/import", expenses.Endpoint),
+			contentType: "text/csv",
+			body:        "title,amount\ncoffee,80\n",
+			mockDB: &MockDB{
+				methodsToCall: map[string]bool{},
+			},
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:        "Should return 400 when file is empty",
+			endpoint:    fmt.Sprintf("%s/import", expenses.Endpoint),
+			contentType: "application/x-ndjson",
+			mockDB: &MockDB{
+				methodsToCall: map[string]bool{},
+			},
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:        "Should return 413 when there are too many rows",
+			endpoint:    fmt.Sprintf("%s/import", expenses.Endpoint),
+			contentType: "text/csv",
+			body:        csvBody,
+			cfg:         &config.AppConfig{DefaultCurrency: "THB", ImportMaxRows: 1},
+			mockDB: &MockDB{
+				methodsToCall: map[string]bool{},
+			},
+			wantStatusCode: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:        "Should return 500 when database error",
+			endpoint:    fmt.Sprintf("%s/import", expenses.Endpoint),
+			contentType: "text/csv",
+			body:        csvBody,
+			mockDB: &MockDB{
+				dbs: []*gorm.DB{{Error: errors.New("error")}},
+				methodsToCall: map[string]bool{
+					txMethod:   false,
+					bulkMethod: false,
+				},
+			},
+			wantStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			httpRequest := &testutils.HTTPRequest{
+				Method:   http.MethodPost,
+				Endpoint: test.endpoint,
+				Body:     test.body,
+				Headers: map[string]string{
+					"Content-Type":          test.contentType,
+					expenses.TimeZoneHeader: "UTC",
+				},
+			}
+
+			handlerCfg := cfg
+			if test.cfg != nil {
+				handlerCfg = test.cfg
+			}
+
+			var report expenses.ImportReport
+			statusCode, err := httpRequest.MakeTestHTTPRequest(asUser(expenses.NewHandler(test.mockDB, handlerCfg).Import), &report)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if statusCode != test.wantStatusCode {
+				t.Errorf("unexpected status code: got %v want %v", statusCode, test.wantStatusCode)
+			}
+
+			test.mockDB.Verify(t)
+			if statusCode != http.StatusOK && statusCode != http.StatusCreated {
+				return
+			}
+
+			for i, row := range report.Rows {
+				if (row.Status == "rejected") != (row.Errors != nil) {
+					t.Errorf("unexpected errors for line %d: %v", row.Line, row.Errors)
+				}
+				report.Rows[i].Errors = nil
+			}
+
+			if !reflect.DeepEqual(report, test.want) {
+				t.Errorf("unexpected report: got %+v want %+v", report, test.want)
+			}
+		})
+	}
+}
+
+func TestPatch(t *testing.T) {
+	expense := expenses.Expense{
+		ID:       1,
+		Title:    "test expense",
+		Amount:   10000,
+		Currency: "THB",
+		Note:     "test note",
+		Tags:     pq.StringArray([]string{"tag1", "tag2"}),
+		SpentAt:  time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	tests := []struct {
+		name           string
+		id             string
+		contentType    string
+		body           string
+		mockDB         *MockDB
+		wantStatusCode int
+	}{
+		{
+			name:        "Should return 200 when merge patch is applied",
+			id:          "1",
+			contentType: expenses.MergePatchContentType,
+			body:        `{"title":"new title"}`,
+			mockDB: &MockDB{
+				returnValue: &expense,
+				dbs:         []*gorm.DB{{}, {RowsAffected: 1}, {}},
+				methodsToCall: map[string]bool{
+					firstMethod: false,
+					saveMethod:  false,
+				},
+			},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name:        "Should return 200 when json patch is applied",
+			id:          "1",
+			contentType: expenses.JSONPatchContentType,
+			body:        `[{"op":"add","path":"/tags/-","value":"food"}]`,
+			mockDB: &MockDB{
+				returnValue: &expense,
+				dbs:         []*gorm.DB{{}, {RowsAffected: 1}, {}},
+				methodsToCall: map[string]bool{
+					firstMethod: false,
+					saveMethod:  false,
+				},
+			},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name:        "Should return 409 when changing the amount of an expense split in a group",
+			id:          "1",
+			contentType: expenses.MergePatchContentType,
+			body:        `{"amount":150}`,
+			mockDB: &MockDB{
+				returnValue: &expense,
+				count:       1,
+				dbs:         []*gorm.DB{{}, {}},
+				methodsToCall: map[string]bool{
+					firstMethod: false,
+					countMethod: false,
+				},
+			},
+			wantStatusCode: http.StatusConflict,
+		},
+		{
+			name:        "Should return 409 when changing the currency of an expense split in a group",
+			id:          "1",
+			contentType: expenses.MergePatchContentType,
+			body:        `{"currency":"USD"}`,
+			mockDB: &MockDB{
+				returnValue: &expense,
+				count:       1,
+				dbs:         []*gorm.DB{{}, {}},
+				methodsToCall: map[string]bool{
+					firstMethod: false,
+					countMethod: false,
+				},
+			},
+			wantStatusCode: http.StatusConflict,
+		},
+		{
+			name:        "Should return 400 when id is not a number",
+			id:          "invalid",
+			contentType: expenses.MergePatchContentType,
+			body:        `{"title":"new title"}`,
+			mockDB: &MockDB{
+				methodsToCall: map[string]bool{},
+			},
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:        "Should return 404 when expense not found",
+			id:          "1",
+			contentType: expenses.MergePatchContentType,
+			body:        `{"title":"new title"}`,
+			mockDB: &MockDB{
+				dbs: []*gorm.DB{{Error: gorm.ErrRecordNotFound}},
+				methodsToCall: map[string]bool{
+					firstMethod: false,
+				},
+			},
+			wantStatusCode: http.StatusNotFound,
+		},
+		{
+			name:        "Should return 415 when content type is unsupported",
+			id:          "1",
+			contentType: "text/plain",
+			body:        `{"title":"new title"}`,
+			mockDB: &MockDB{
+				returnValue: &expense,
+				dbs:         []*gorm.DB{{}},
+				methodsToCall: map[string]bool{
+					firstMethod: false,
+				},
+			},
+			wantStatusCode: http.StatusUnsupportedMediaType,
+		},
+		{
+			name:        "Should return 422 when json patch cannot be applied",
+			id:          "1",
+			contentType: expenses.JSONPatchContentType,
+			body:        `[{"op":"remove","path":"/tags/5"}]`,
+			mockDB: &MockDB{
+				returnValue: &expense,
+				dbs:         []*gorm.DB{{}},
+				methodsToCall: map[string]bool{
+					firstMethod: false,
+				},
+			},
+			wantStatusCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:        "Should return 400 when patched expense is invalid",
+			id:          "1",
+			contentType: expenses.MergePatchContentType,
+			body:        `{"title":null}`,
+			mockDB: &MockDB{
+				returnValue: &expense,
+				dbs:         []*gorm.DB{{}},
+				methodsToCall: map[string]bool{
+					firstMethod: false,
+				},
+			},
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:        "Should return 400 when amount does not fit the patched currency",
+			id:          "1",
+			contentType: expenses.MergePatchContentType,
+			body:        `{"amount":100.5,"currency":"JPY"}`,
+			mockDB: &MockDB{
+				returnValue: &expense,
+				dbs:         []*gorm.DB{{}},
+				methodsToCall: map[string]bool{
+					firstMethod: false,
+				},
+			},
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:        "Should return 500 when database error",
+			id:          "1",
+			contentType: expenses.MergePatchContentType,
+			body:        `{"title":"new title"}`,
+			mockDB: &MockDB{
+				returnValue: &expense,
+				dbs:         []*gorm.DB{{}, {Error: errors.New("error")}},
+				methodsToCall: map[string]bool{
+					firstMethod: false,
+					saveMethod:  false,
+				},
+			},
+			wantStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			httpRequest := &testutils.HTTPRequest{
+				Method:   http.MethodPatch,
+				Endpoint: fmt.Sprintf("%s/%s", expenses.Endpoint, test.id),
+				Body:     test.body,
+				Headers:  map[string]string{"Content-Type": test.contentType},
+			}
+
+			statusCode, err := httpRequest.MakeTestHTTPRequest(asUser(expenses.NewHandler(test.mockDB, cfg).Patch), &expenses.Expense{}, gin.Param{Key: "id", Value: test.id})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if statusCode != test.wantStatusCode {
+				t.Errorf("unexpected status code: got %v want %v", statusCode, test.wantStatusCode)
+			}
+
+			test.mockDB.Verify(t)
+		})
+	}
+}
+
+func TestPatchNullCurrency(t *testing.T) {
+	expense := expenses.Expense{
+		ID:       1,
+		Title:    "test expense",
+		Amount:   10000,
+		Currency: "USD",
+		Note:     "test note",
+		Tags:     pq.StringArray([]string{"tag1"}),
+		SpentAt:  time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	mockDB := &MockDB{
+		returnValue: &expense,
+		dbs:         []*gorm.DB{{}, {}, {RowsAffected: 1}, {}},
+		methodsToCall: map[string]bool{
+			firstMethod: false,
+			countMethod: false,
+			saveMethod:  false,
+		},
+	}
+
+	httpRequest := &testutils.HTTPRequest{
+		Method:   http.MethodPatch,
+		Endpoint: fmt.Sprintf("%s/1", expenses.Endpoint),
+		Body:     `{"currency":null}`,
+		Headers:  map[string]string{"Content-Type": expenses.MergePatchContentType},
+	}
+
+	statusCode, err := httpRequest.MakeTestHTTPRequest(asUser(expenses.NewHandler(mockDB, cfg).Patch), &expenses.Expense{}, gin.Param{Key: "id", Value: "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if statusCode != http.StatusOK {
+		t.Fatalf("unexpected status code: got %v want %v", statusCode, http.StatusOK)
+	}
+
+	mockDB.Verify(t)
+	if len(mockDB.saved) != 1 {
+		t.Fatalf("unexpected saves: got %v want 1", len(mockDB.saved))
+	}
+	if got := mockDB.saved[0].(*expenses.Expense).Currency; got != cfg.DefaultCurrency {
+		t.Errorf("unexpected currency: got %q want %q", got, cfg.DefaultCurrency)
+	}
+}
+
+func TestConditionalRequests(t *testing.T) {
+	stored := func() *expenses.Expense {
+		return &expenses.Expense{
+			ID:       1,
+			Title:    "test expense",
+			Amount:   10000,
+			Currency: "THB",
+			Note:     "test note",
+			Tags:     pq.StringArray([]string{"tag1", "tag2"}),
+			SpentAt:  time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+			Version:  2,
+		}
+	}
+
+	tests := []struct {
+		name           string
+		handler        func(expenses.Handler) gin.HandlerFunc
+		method         string
+		body           string
+		headers        map[string]string
+		mockDB         *MockDB
+		wantStatusCode int
+		wantETag       string
+	}{
+		{
+			name:           "Should return ETag when get expense",
+			handler:        func(h expenses.Handler) gin.HandlerFunc { return h.Get },
+			method:         http.MethodGet,
+			mockDB:         &MockDB{returnValue: stored(), dbs: []*gorm.DB{{}}, methodsToCall: map[string]bool{firstMethod: false}},
+			wantStatusCode: http.StatusOK,
+			wantETag:       `"2"`,
+		},
+		{
+			name:           "Should return 304 when If-None-Match matches",
+			handler:        func(h expenses.Handler) gin.HandlerFunc { return h.Get },
+			method:         http.MethodGet,
+			headers:        map[string]string{expenses.IfNoneMatchHeader: `"1", W/"2"`},
+			mockDB:         &MockDB{returnValue: stored(), dbs: []*gorm.DB{{}}, methodsToCall: map[string]bool{firstMethod: false}},
+			wantStatusCode: http.StatusNotModified,
+			wantETag:       `"2"`,
+		},
+		{
+			name:           "Should return 200 when If-None-Match is stale",
+			handler:        func(h expenses.Handler) gin.HandlerFunc { return h.Get },
+			method:         http.MethodGet,
+			headers:        map[string]string{expenses.IfNoneMatchHeader: `"1"`},
+			mockDB:         &MockDB{returnValue: stored(), dbs: []*gorm.DB{{}}, methodsToCall: map[string]bool{firstMethod: false}},
+			wantStatusCode: http.StatusOK,
+			wantETag:       `"2"`,
+		},
+		{
+			name:           "Should return ETag when create expense",
+			handler:        func(h expenses.Handler) gin.HandlerFunc { return h.Create },
+			method:         http.MethodPost,
+			body:           expenses.CreateBody,
+			mockDB:         &MockDB{dbs: []*gorm.DB{{}, {}}, methodsToCall: map[string]bool{createMethod: false}},
+			wantStatusCode: http.StatusCreated,
+			wantETag:       `"1"`,
+		},
+		{
+			name:           "Should return ETag when If-Match matches on update",
+			handler:        func(h expenses.Handler) gin.HandlerFunc { return h.Update },
+			method:         http.MethodPut,
+			body:           expenses.UpdateBody,
+			headers:        map[string]string{expenses.IfMatchHeader: `"2"`},
+			mockDB:         &MockDB{returnValue: stored(), dbs: []*gorm.DB{{}, {}, {RowsAffected: 1}, {}}, methodsToCall: map[string]bool{firstMethod: false, saveMethod: false}},
+			wantStatusCode: http.StatusOK,
+			wantETag:       `"2"`,
+		},
+		{
+			name:           "Should return 412 when If-Match is stale on update",
+			handler:        func(h expenses.Handler) gin.HandlerFunc { return h.Update },
+			method:         http.MethodPut,
+			body:           expenses.UpdateBody,
+			headers:        map[string]string{expenses.IfMatchHeader: `"1"`},
+			mockDB:         &MockDB{returnValue: stored(), dbs: []*gorm.DB{{}}, methodsToCall: map[string]bool{firstMethod: false}},
+			wantStatusCode: http.StatusPreconditionFailed,
+		},
+		{
+			name:           "Should return 412 when If-Match is weak on update",
+			handler:        func(h expenses.Handler) gin.HandlerFunc { return h.Update },
+			method:         http.MethodPut,
+			body:           expenses.UpdateBody,
+			headers:        map[string]string{expenses.IfMatchHeader: `W/"2"`},
+			mockDB:         &MockDB{returnValue: stored(), dbs: []*gorm.DB{{}}, methodsToCall: map[string]bool{firstMethod: false}},
+			wantStatusCode: http.StatusPreconditionFailed,
+		},
+		{
+			name:           "Should return 412 when If-Match is stale on patch",
+			handler:        func(h expenses.Handler) gin.HandlerFunc { return h.Patch },
+			method:         http.MethodPatch,
+			body:           expenses.MergePatchBody,
+			headers:        map[string]string{expenses.IfMatchHeader: `"1"`, "Content-Type": expenses.MergePatchContentType},
+			mockDB:         &MockDB{returnValue: stored(), dbs: []*gorm.DB{{}}, methodsToCall: map[string]bool{firstMethod: false}},
+			wantStatusCode: http.StatusPreconditionFailed,
+		},
+		{
+			name:           "Should return 412 when expense changes between read and write",
+			handler:        func(h expenses.Handler) gin.HandlerFunc { return h.Update },
+			method:         http.MethodPut,
+			body:           expenses.UpdateBody,
+			headers:        map[string]string{expenses.IfMatchHeader: `"2"`},
+			mockDB:         &MockDB{returnValue: stored(), dbs: []*gorm.DB{{}, {}, {}}, methodsToCall: map[string]bool{firstMethod: false, saveMethod: false}},
+			wantStatusCode: http.StatusPreconditionFailed,
+		},
+		{
+			name:           "Should return 409 when expense changes between read and write without If-Match",
+			handler:        func(h expenses.Handler) gin.HandlerFunc { return h.Update },
+			method:         http.MethodPut,
+			body:           expenses.UpdateBody,
+			mockDB:         &MockDB{returnValue: stored(), dbs: []*gorm.DB{{}, {}, {}}, methodsToCall: map[string]bool{firstMethod: false, saveMethod: false}},
+			wantStatusCode: http.StatusConflict,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			httpRequest := &testutils.HTTPRequest{
+				Method:   test.method,
+				Endpoint: fmt.Sprintf("%s/1", expenses.Endpoint),
+				Body:     test.body,
+				Headers:  test.headers,
+			}
+
+			h := expenses.NewHandler(test.mockDB, cfg)
+			resp, err := httpRequest.MakeTestHTTPResponse(asUser(test.handler(h)), gin.Param{Key: "id", Value: "1"})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if resp.Code != test.wantStatusCode {
+				t.Errorf("unexpected status code: got %v want %v", resp.Code, test.wantStatusCode)
+			}
+
+			if got := resp.Header().Get(expenses.ETagHeader); got != test.wantETag {
+				t.Errorf("unexpected etag: got %v want %v", got, test.wantETag)
+			}
+
+			if resp.Code == http.StatusNotModified && resp.Body.Len() != 0 {
+				t.Errorf("unexpected body: %s", resp.Body.String())
+			}
+
+			test.mockDB.Verify(t)
+		})
+	}
+}
+
+func TestOwnerScope(t *testing.T) {
+	owner := fmt.Sprint("owner_id = ?", []interface{}{testUserID})
+	tests := []struct {
+		name           string
+		handler        func(h expenses.Handler) gin.HandlerFunc
+		method         string
+		authenticated  bool
+		mockDB         *MockDB
+		wantStatusCode int
+	}{
+		{
+			name:           "Should scope get to the authenticated user",
+			handler:        func(h expenses.Handler) gin.HandlerFunc { return h.Get },
+			method:         http.MethodGet,
+			authenticated:  true,
+			mockDB:         &MockDB{returnValue: &expenses.Expense{}, dbs: []*gorm.DB{{Error: gorm.ErrRecordNotFound}}, methodsToCall: map[string]bool{firstMethod: false}},
+			wantStatusCode: http.StatusNotFound,
+		},
+		{
+			name:           "Should scope list to the authenticated user",
+			handler:        func(h expenses.Handler) gin.HandlerFunc { return h.List },
+			method:         http.MethodGet,
+			authenticated:  true,
+			mockDB:         &MockDB{returnValue: &[]expenses.Expense{}, dbs: []*gorm.DB{{}, {}}, methodsToCall: map[string]bool{countMethod: false, findMethod: false}},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name:           "Should scope delete to the authenticated user",
+			handler:        func(h expenses.Handler) gin.HandlerFunc { return h.Delete },
+			method:         http.MethodDelete,
+			authenticated:  true,
+			mockDB:         &MockDB{dbs: []*gorm.DB{{Error: gorm.ErrRecordNotFound}}, methodsToCall: map[string]bool{firstMethod: false}},
+			wantStatusCode: http.StatusNotFound,
+		},
+		{
+			name:           "Should return 401 when no user is authenticated",
+			handler:        func(h expenses.Handler) gin.HandlerFunc { return h.Get },
+			method:         http.MethodGet,
+			mockDB:         &MockDB{},
+			wantStatusCode: http.StatusUnauthorized,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			httpRequest := &testutils.HTTPRequest{
+				Method:   test.method,
+				Endpoint: fmt.Sprintf("%s/1", expenses.Endpoint),
+			}
+
+			handler := test.handler(expenses.NewHandler(test.mockDB, cfg))
+			if test.authenticated {
+				handler = asUser(handler)
+			}
+
+			resp, err := httpRequest.MakeTestHTTPResponse(handler, gin.Param{Key: "id", Value: "1"})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if resp.Code != test.wantStatusCode {
+				t.Errorf("unexpected status code: got %v want %v", resp.Code, test.wantStatusCode)
+			}
+
+			scoped := false
+			for _, condition := range test.mockDB.conditions {
+				scoped = scoped || condition == owner
+			}
+			if test.authenticated && !scoped {
+				t.Errorf("unexpected conditions: got %v want %v", test.mockDB.conditions, owner)
+			}
+
+			test.mockDB.Verify(t)
+		})
+	}
+}
+
+func TestHistory(t *testing.T) {
+	spentAt := time.Date(2023, time.January, 15, 0, 0, 0, 0, time.UTC)
+	created := &expenses.Snapshot{Title: "lunch", Amount: 10000, Currency: "THB", Note: "noodles", Tags: []string{"food"}, SpentAt: spentAt}
+	updated := &expenses.Snapshot{Title: "lunch", Amount: 12000, Currency: "THB", Note: "noodles", Tags: []string{"food"}, SpentAt: spentAt}
+
+	tests := []struct {
+		name           string
+		id             string
+		mockDB         *MockDB
+		wantStatusCode int
+		wantChanges    []int
+	}{
+		{
+			name: "Should return 200 with the changes of every entry",
+			id:   "1",
+			mockDB: &MockDB{
+				returnValue: &[]expenses.History{
+					{ID: 1, ExpenseID: 1, Version: 1, Action: expenses.ActionCreate, After: created},
+					{ID: 2, ExpenseID: 1, Version: 2, Action: expenses.ActionUpdate, Before: created, After: updated},
+					{ID: 3, ExpenseID: 1, Version: 2, Action: expenses.ActionDelete, Before: updated},
+				},
+				dbs:           []*gorm.DB{{}},
+				methodsToCall: map[string]bool{findMethod: false},
+			},
+			wantStatusCode: http.StatusOK,
+			wantChanges:    []int{6, 1, 6},
+		},
+		{
+			name: "Should return 200 with no entries when expense predates history",
+			id:   "1",
+			mockDB: &MockDB{
+				returnValue:   &[]expenses.History{},
+				dbs:           []*gorm.DB{{}, {}},
+				methodsToCall: map[string]bool{findMethod: false, firstMethod: false},
+			},
+			wantStatusCode: http.StatusOK,
+			wantChanges:    []int{},
+		},
+		{
+			name: "Should return 404 when expense not found",
+			id:   "1",
+			mockDB: &MockDB{
+				returnValue:   &[]expenses.History{},
+				dbs:           []*gorm.DB{{}, {Error: gorm.ErrRecordNotFound}},
+				methodsToCall: map[string]bool{findMethod: false, firstMethod: false},
+			},
+			wantStatusCode: http.StatusNotFound,
+		},
+		{
+			name:           "Should return 400 when id is not a number",
+			id:             "invalid",
+			mockDB:         &MockDB{},
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name: "Should return 500 when database error",
+			id:   "1",
+			mockDB: &MockDB{
+				dbs:           []*gorm.DB{{Error: errors.New("error")}},
+				methodsToCall: map[string]bool{findMethod: false},
+			},
+			wantStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			httpRequest := &testutils.HTTPRequest{
+				Method:   http.MethodGet,
+				Endpoint: fmt.Sprintf("%s/1/history", expenses.Endpoint),
+			}
+
+			var entries []expenses.History
+			statusCode, err := httpRequest.MakeTestHTTPRequest(asUser(expenses.NewHandler(test.mockDB, cfg).History), &entries, gin.Param{Key: "id", Value: test.id})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if statusCode != test.wantStatusCode {
+				t.Errorf("unexpected status code: got %v want %v", statusCode, test.wantStatusCode)
+			}
+
+			if statusCode == http.StatusOK {
+				if len(entries) != len(test.wantChanges) {
+					t.Fatalf("unexpected entries: got %+v", entries)
+				}
+
+				for i, entry := range entries {
+					if len(entry.Changes) != test.wantChanges[i] {
+						t.Errorf("unexpected changes of entry %d: got %v want %d fields", entry.ID, entry.Changes, test.wantChanges[i])
+					}
+				}
+			}
+
+			test.mockDB.Verify(t)
+		})
+	}
+}
+
+func TestRevert(t *testing.T) {
+	stored := func() *expenses.Expense {
+		return &expenses.Expense{ID: 1, Title: "lunch", Amount: 12000, Currency: "THB", Note: "noodles", Tags: pq.StringArray{"food"}, Version: 2}
+	}
+	version := func() *expenses.History {
+		return &expenses.History{ExpenseID: 1, Version: 1, After: &expenses.Snapshot{Title: "lunch", Amount: 10000, Currency: "THB", Note: "noodles", Tags: []string{"food"}}}
+	}
+
+	tests := []struct {
+		name           string
+		body           string
+		headers        map[string]string
+		mockDB         *MockDB
+		wantStatusCode int
+	}{
+		{
+			name: "Should return 200 when revert expense successfully",
+			body: `{"version": 1}`,
+			mockDB: &MockDB{
+				returnValue:   stored(),
+				relatedValues: []interface{}{version()},
+				dbs:           []*gorm.DB{{}, {}, {}, {RowsAffected: 1}, {}},
+				methodsToCall: map[string]bool{firstMethod: false, countMethod: false, saveMethod: false, createMethod: false},
+			},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name: "Should return 404 when version is not in the history",
+			body: `{"version": 9}`,
+			mockDB: &MockDB{
+				returnValue:   stored(),
+				dbs:           []*gorm.DB{{}, {Error: gorm.ErrRecordNotFound}},
+				methodsToCall: map[string]bool{firstMethod: false},
+			},
+			wantStatusCode: http.StatusNotFound,
+		},
+		{
+			name: "Should return 404 when expense not found",
+			body: `{"version": 1}`,
+			mockDB: &MockDB{
+				dbs:           []*gorm.DB{{Error: gorm.ErrRecordNotFound}},
+				methodsToCall: map[string]bool{firstMethod: false},
+			},
+			wantStatusCode: http.StatusNotFound,
+		},
+		{
+			name:           "Should return 400 when version is missing",
+			body:           `{}`,
+			mockDB:         &MockDB{},
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:    "Should return 412 when If-Match is stale",
+			body:    `{"version": 1}`,
+			headers: map[string]string{expenses.IfMatchHeader: `"1"`},
+			mockDB: &MockDB{
+				returnValue:   stored(),
+				dbs:           []*gorm.DB{{}},
+				methodsToCall: map[string]bool{firstMethod: false},
+			},
+			wantStatusCode: http.StatusPreconditionFailed,
+		},
+		{
+			name: "Should return 409 when expense was modified by another request",
+			body: `{"version": 1}`,
+			mockDB: &MockDB{
+				returnValue:   stored(),
+				relatedValues: []interface{}{version()},
+				dbs:           []*gorm.DB{{}, {}, {}, {RowsAffected: 0}},
+				methodsToCall: map[string]bool{saveMethod: false},
+			},
+			wantStatusCode: http.StatusConflict,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			httpRequest := &testutils.HTTPRequest{
+				Method:   http.MethodPost,
+				Endpoint: fmt.Sprintf("%s/1/revert", expenses.Endpoint),
+				Body:     test.body,
+				Headers:  test.headers,
+			}
+
+			resp, err := httpRequest.MakeTestHTTPResponse(asUser(expenses.NewHandler(test.mockDB, cfg).Revert), gin.Param{Key: "id", Value: "1"})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if resp.Code != test.wantStatusCode {
+				t.Errorf("unexpected status code: got %v want %v", resp.Code, test.wantStatusCode)
+			}
+
+			test.mockDB.Verify(t)
+		})
+	}
+}
@@ -0,0 +1,183 @@
+package expenses
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/tirathawat/assessment/errs"
+)
+
+const (
+	ActionCreate  = "create"
+	ActionUpdate  = "update"
+	ActionDelete  = "delete"
+	ActionRestore = "restore"
+	ActionRevert  = "revert"
+)
+
+var (
+	ErrHistoryFailed   = errs.New(http.StatusInternalServerError, "EXPENSE_HISTORY_FAILED", "failed to get expense history")
+	ErrRevertFailed    = errs.New(http.StatusInternalServerError, "EXPENSE_REVERT_FAILED", "failed to revert expense")
+	ErrVersionNotFound = errs.New(http.StatusNotFound, "EXPENSE_VERSION_NOT_FOUND", "version not found in expense history")
+)
+
+// Snapshot is the content of an expense that users can change.
+type Snapshot struct {
+	Title    string    `json:"title"`
+	Amount   Money     `json:"amount"`
+	Currency string    `json:"currency"`
+	Note     string    `json:"note"`
+	Tags     []string  `json:"tags"`
+	SpentAt  time.Time `json:"spent_at"`
+}
+
+func (s Snapshot) Value() (driver.Value, error) {
+	return json.Marshal(s)
+}
+
+func (s *Snapshot) Scan(value interface{}) error {
+	switch value := value.(type) {
+	case []byte:
+		return json.Unmarshal(value, s)
+	case string:
+		return json.Unmarshal([]byte(value), s)
+	default:
+		return fmt.Errorf("cannot scan %T into Snapshot", value)
+	}
+}
+
+// apply copies the snapshot onto the expense.
+func (s Snapshot) apply(expense *Expense) {
+	expense.Title = s.Title
+	expense.Amount = s.Amount
+	expense.Currency = s.Currency
+	expense.Note = s.Note
+	expense.Tags = pq.StringArray(s.Tags)
+	expense.SpentAt = s.SpentAt
+}
+
+// fields lists the snapshot by JSON name in a form that compares equal
+// when the values are the same.
+func (s *Snapshot) fields() map[string]interface{} {
+	if s == nil {
+		return nil
+	}
+
+	tags := s.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+
+	return map[string]interface{}{
+		"title":    s.Title,
+		"amount":   s.Amount,
+		"currency": s.Currency,
+		"note":     s.Note,
+		"tags":     tags,
+		"spent_at": s.SpentAt.Format(time.RFC3339Nano),
+	}
+}
+
+// Snapshot returns the content of the expense.
+func (e Expense) Snapshot() *Snapshot {
+	return &Snapshot{
+		Title:    e.Title,
+		Amount:   e.Amount,
+		Currency: e.Currency,
+		Note:     e.Note,
+		Tags:     []string(e.Tags),
+		SpentAt:  e.SpentAt,
+	}
+}
+
+// Change is the value of a field before and after a change. Before is null
+// for a created expense and After for a deleted one.
+type Change struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// History is an entry of the append-only audit trail of an expense. It
+// records who changed the expense, and its content before and after, in
+// the same transaction as the change. Entries are kept after the expense
+// is purged from the trash.
+type History struct {
+	ID        int       `gorm:"primary_key" json:"id"`
+	ExpenseID int       `gorm:"not null;index" json:"expense_id"`
+	OwnerID   int       `gorm:"not null;index" json:"-"`
+	Version   int       `gorm:"not null" json:"version"`
+	Action    string    `gorm:"type:text;not null" json:"action"`
+	Actor     string    `gorm:"type:text;not null" json:"actor"`
+	Before    *Snapshot `gorm:"type:jsonb" json:"before"`
+	After     *Snapshot `gorm:"type:jsonb" json:"after"`
+	CreatedAt time.Time `gorm:"not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+
+	Changes map[string]Change `gorm:"-" json:"changes"`
+}
+
+func (History) TableName() string {
+	return "expense_history"
+}
+
+// NewHistory records an action on an expense. before is nil for a created
+// expense and after is nil for a deleted one.
+func NewHistory(action, actor string, before, after *Expense) History {
+	history := History{Action: action, Actor: actor}
+	current := after
+	if current == nil {
+		current = before
+	}
+
+	history.ExpenseID = current.ID
+	history.OwnerID = current.OwnerID
+	history.Version = current.Version
+	if before != nil {
+		history.Before = before.Snapshot()
+	}
+	if after != nil {
+		history.After = after.Snapshot()
+	}
+
+	return history
+}
+
+// diff fills Changes with the fields that differ between Before and After.
+func (h *History) diff() {
+	before, after := h.Before.fields(), h.After.fields()
+	h.Changes = map[string]Change{}
+	for _, name := range []string{"title", "amount", "currency", "note", "tags", "spent_at"} {
+		b, hasBefore := before[name]
+		a, hasAfter := after[name]
+		if hasBefore && hasAfter && reflect.DeepEqual(a, b) {
+			continue
+		}
+
+		h.Changes[name] = Change{Before: b, After: a}
+	}
+}
+
+// In returns a copy of the entry with its timestamps expressed in loc.
+func (h History) In(loc *time.Location) History {
+	h.CreatedAt = h.CreatedAt.In(loc)
+	if h.Before != nil {
+		before := *h.Before
+		before.SpentAt = before.SpentAt.In(loc)
+		h.Before = &before
+	}
+	if h.After != nil {
+		after := *h.After
+		after.SpentAt = after.SpentAt.In(loc)
+		h.After = &after
+	}
+
+	return h
+}
+
+type RevertRequestBody struct {
+	Version int `json:"version" binding:"required,min=1"`
+}
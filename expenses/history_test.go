@@ -0,0 +1,95 @@
+//go:build unit
+// +build unit
+
+package expenses
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+func TestNewHistory(t *testing.T) {
+	spentAt := time.Date(2023, time.January, 15, 0, 0, 0, 0, time.UTC)
+	before := Expense{ID: 1, OwnerID: 7, Title: "lunch", Amount: 10000, Currency: "THB", Note: "noodles", Tags: pq.StringArray{"food"}, SpentAt: spentAt, Version: 1}
+	after := before
+	after.Amount = 12000
+	after.Tags = pq.StringArray{"food", "work"}
+	after.Version = 2
+
+	tests := []struct {
+		name        string
+		history     History
+		wantVersion int
+		wantChanges map[string]Change
+	}{
+		{
+			name:        "Should record every field of a created expense",
+			history:     NewHistory(ActionCreate, "alice", nil, &before),
+			wantVersion: 1,
+			wantChanges: map[string]Change{
+				"title":    {After: "lunch"},
+				"amount":   {After: Money(10000)},
+				"currency": {After: "THB"},
+				"note":     {After: "noodles"},
+				"tags":     {After: []string{"food"}},
+				"spent_at": {After: "2023-01-15T00:00:00Z"},
+			},
+		},
+		{
+			name:        "Should record only the fields that changed",
+			history:     NewHistory(ActionUpdate, "alice", &before, &after),
+			wantVersion: 2,
+			wantChanges: map[string]Change{
+				"amount": {Before: Money(10000), After: Money(12000)},
+				"tags":   {Before: []string{"food"}, After: []string{"food", "work"}},
+			},
+		},
+		{
+			name:        "Should record every field of a deleted expense",
+			history:     NewHistory(ActionDelete, "alice", &after, nil),
+			wantVersion: 2,
+			wantChanges: map[string]Change{
+				"title":    {Before: "lunch"},
+				"amount":   {Before: Money(12000)},
+				"currency": {Before: "THB"},
+				"note":     {Before: "noodles"},
+				"tags":     {Before: []string{"food", "work"}},
+				"spent_at": {Before: "2023-01-15T00:00:00Z"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			history := test.history
+			if history.ExpenseID != 1 || history.OwnerID != 7 || history.Version != test.wantVersion || history.Actor != "alice" {
+				t.Errorf("unexpected history: got %+v", history)
+			}
+
+			history.diff()
+			if !reflect.DeepEqual(history.Changes, test.wantChanges) {
+				t.Errorf("unexpected changes: got %v want %v", history.Changes, test.wantChanges)
+			}
+		})
+	}
+}
+
+func TestSnapshotScan(t *testing.T) {
+	want := Snapshot{Title: "lunch", Amount: 10050, Currency: "THB", Tags: []string{"food"}, SpentAt: time.Date(2023, time.January, 15, 0, 0, 0, 0, time.UTC)}
+	value, err := want.Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got Snapshot
+	if err := got.Scan(value); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(got.fields(), want.fields()) {
+		t.Errorf("unexpected snapshot: got %+v want %+v", got, want)
+	}
+}
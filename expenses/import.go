@@ -0,0 +1,257 @@
+package expenses
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/tirathawat/assessment/errs"
+)
+
+const (
+	importFormatCSV   = "csv"
+	importFormatJSONL = "jsonl"
+
+	importBatchSize = 500
+
+	// maxImportLine bounds a single JSON Lines record.
+	maxImportLine = 1 << 20
+)
+
+var (
+	ErrImportFormat    = errs.New(http.StatusUnsupportedMediaType, "UNSUPPORTED_IMPORT_FORMAT", "unsupported import format, use csv or jsonl")
+	ErrImportHeader    = errs.New(http.StatusBadRequest, "INVALID_IMPORT_HEADER", "csv header must include title, amount, note and tags")
+	ErrImportTooLarge  = errs.New(http.StatusRequestEntityTooLarge, "IMPORT_TOO_LARGE", "too many rows to import")
+	ErrImportEmptyFile = errs.New(http.StatusBadRequest, "IMPORT_EMPTY", "nothing to import")
+)
+
+type ImportQuery struct {
+	Format       string  `form:"format" binding:"omitempty,oneof=csv jsonl"`
+	DryRun       bool    `form:"dry_run"`
+	TagSeparator *string `form:"tag_separator" binding:"omitempty,max=10"`
+}
+
+const (
+	importAccepted = "accepted"
+	importRejected = "rejected"
+)
+
+type ImportRow struct {
+	Line   int                    `json:"line"`
+	Status string                 `json:"status"`
+	ID     int                    `json:"id,omitempty"`
+	Errors map[string]interface{} `json:"errors,omitempty"`
+}
+
+type ImportReport struct {
+	DryRun   bool        `json:"dry_run"`
+	Accepted int         `json:"accepted"`
+	Rejected int         `json:"rejected"`
+	Rows     []ImportRow `json:"rows"`
+}
+
+// importRecord is a parsed line waiting to be validated. err is set when the
+// line itself could not be read.
+type importRecord struct {
+	line int
+	body CreateRequestBody
+	err  error
+}
+
+// importFormat picks the format from the request content type when it is
+// not given explicitly.
+func importFormat(format, contentType string) (string, error) {
+	if format != "" {
+		return format, nil
+	}
+
+	switch contentType {
+	case "text/csv":
+		return importFormatCSV, nil
+	case "application/x-ndjson", "application/jsonl", "application/x-jsonlines":
+		return importFormatJSONL, nil
+	}
+
+	return "", ErrImportFormat
+}
+
+func readImport(format string, r io.Reader, loc *time.Location, tagSeparator string, maxRows int) ([]importRecord, error) {
+	var records []importRecord
+	var err error
+	if format == importFormatCSV {
+		records, err = readCSV(r, loc, tagSeparator, maxRows)
+	} else {
+		records, err = readJSONLines(r, maxRows)
+	}
+
+	if err == nil && len(records) == 0 {
+		err = ErrImportEmptyFile
+	}
+
+	return records, err
+}
+
+// readCSV reads files with a header row naming the columns, so an export
+// can be imported back. Columns that are not part of CreateRequestBody,
+// such as id, are ignored.
+func readCSV(r io.Reader, loc *time.Location, tagSeparator string, maxRows int) ([]importRecord, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	for _, name := range []string{"title", "amount", "note", "tags"} {
+		if _, ok := columns[name]; !ok {
+			return nil, ErrImportHeader
+		}
+	}
+
+	var records []importRecord
+	for {
+		values, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return records, nil
+		}
+
+		if maxRows > 0 && len(records) == maxRows {
+			return nil, ErrImportTooLarge
+		}
+
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			records = append(records, importRecord{line: parseErr.StartLine, err: parseErr.Err})
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		line, _ := reader.FieldPos(0)
+		body, err := csvBody(values, columns, loc, tagSeparator)
+		records = append(records, importRecord{line: line, body: body, err: err})
+	}
+}
+
+func csvBody(values []string, columns map[string]int, loc *time.Location, tagSeparator string) (CreateRequestBody, error) {
+	value := func(name string) string {
+		if i, ok := columns[name]; ok && i < len(values) {
+			return strings.TrimSpace(values[i])
+		}
+		return ""
+	}
+
+	body := CreateRequestBody{
+		Title:    unescapeCell(value("title")),
+		Currency: strings.ToUpper(value("currency")),
+		Note:     unescapeCell(value("note")),
+		Tags:     splitTags(unescapeCell(value("tags")), tagSeparator),
+	}
+
+	amount, err := ParseMoney(value("amount"))
+	if err != nil {
+		return body, fmt.Errorf("amount: %w", err)
+	}
+	body.Amount = amount
+
+	if spentAt := value("spent_at"); spentAt != "" {
+		t, err := parseDate(spentAt, loc, false)
+		if err != nil {
+			return body, fmt.Errorf("spent_at: %w", err)
+		}
+		body.SpentAt = &t
+	}
+
+	return body, nil
+}
+
+// splitTags reverses the join done by export. Surrounding spaces are
+// ignored so "food, beverage" and "food,beverage" give the same tags.
+func splitTags(value, separator string) []string {
+	tags := []string{}
+	if separator = strings.TrimSpace(separator); separator == "" {
+		separator = ","
+	}
+
+	for _, tag := range strings.Split(value, separator) {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+
+	return tags
+}
+
+func readJSONLines(r io.Reader, maxRows int) ([]importRecord, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxImportLine)
+
+	var records []importRecord
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		if maxRows > 0 && len(records) == maxRows {
+			return nil, ErrImportTooLarge
+		}
+
+		var body CreateRequestBody
+		err := json.Unmarshal([]byte(text), &body)
+		records = append(records, importRecord{line: line, body: body, err: err})
+	}
+
+	return records, scanner.Err()
+}
+
+// check validates every record with the same rules as Create and returns
+// the report, with row errors in lang, along with the expenses that were
+// accepted.
+func (h *handler) check(records []importRecord, owner int, now time.Time, dryRun bool, lang string) (ImportReport, []Expense) {
+	report := ImportReport{DryRun: dryRun, Rows: make([]ImportRow, 0, len(records))}
+	var expenses []Expense
+	for _, record := range records {
+		err := record.err
+		if err == nil {
+			if record.body.Currency == "" {
+				record.body.Currency = h.currency
+			}
+			err = binding.Validator.ValidateStruct(&record.body)
+		}
+		if err == nil {
+			err = record.body.Validate(now)
+		}
+
+		if err != nil {
+			report.Rejected++
+			report.Rows = append(report.Rows, ImportRow{Line: record.line, Status: importRejected, Errors: errs.ErrorIn(lang, err)})
+			continue
+		}
+
+		report.Accepted++
+		report.Rows = append(report.Rows, ImportRow{Line: record.line, Status: importAccepted})
+		expense := record.body.Expense(now)
+		expense.OwnerID = owner
+		expenses = append(expenses, expense)
+	}
+
+	return report, expenses
+}
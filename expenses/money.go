@@ -0,0 +1,135 @@
+package expenses
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/tirathawat/assessment/errs"
+)
+
+const (
+	// MoneyScale is the number of fractional digits kept for an amount.
+	MoneyScale = 2
+
+	moneyUnit = 100
+)
+
+var (
+	ErrInvalidAmount      = errs.New(http.StatusBadRequest, "INVALID_AMOUNT", "invalid amount")
+	ErrTooManyFractionals = errs.New(http.StatusBadRequest, "AMOUNT_TOO_MANY_DECIMALS", fmt.Sprintf("amount cannot have more than %d decimal places", MoneyScale))
+)
+
+// Money is an exact amount counted in hundredths of the currency unit, so
+// 79.50 THB is Money(7950). It is stored as numeric and rendered in JSON as
+// a decimal number, which keeps sums free of floating point drift.
+type Money int64
+
+func ParseMoney(s string) (Money, error) {
+	s = strings.TrimSpace(s)
+
+	negative := strings.HasPrefix(s, "-")
+	if negative {
+		s = s[1:]
+	}
+
+	whole, fraction, hasPoint := strings.Cut(s, ".")
+	if whole == "" || (hasPoint && fraction == "") || !isDigits(whole) || !isDigits(fraction) {
+		return 0, ErrInvalidAmount
+	}
+
+	if len(fraction) > MoneyScale {
+		return 0, ErrTooManyFractionals
+	}
+
+	fraction += strings.Repeat("0", MoneyScale-len(fraction))
+	minor, err := strconv.ParseInt(whole+fraction, 10, 64)
+	if err != nil {
+		return 0, ErrInvalidAmount
+	}
+
+	if negative {
+		minor = -minor
+	}
+
+	return Money(minor), nil
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// String formats the amount with exactly MoneyScale decimal places.
+func (m Money) String() string {
+	sign := ""
+	minor := int64(m)
+	if minor < 0 {
+		sign = "-"
+		minor = -minor
+	}
+
+	return fmt.Sprintf("%s%d.%0*d", sign, minor/moneyUnit, MoneyScale, minor%moneyUnit)
+}
+
+// MarshalJSON drops insignificant zeros, so 79.00 is rendered as 79.
+func (m Money) MarshalJSON() ([]byte, error) {
+	s := strings.TrimRight(m.String(), "0")
+	return []byte(strings.TrimSuffix(s, ".")), nil
+}
+
+// UnmarshalJSON accepts a JSON number or a numeric string. The decimal text
+// is parsed directly so no precision is lost through float64.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		return nil
+	}
+
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		s = unquoted
+	}
+
+	money, err := ParseMoney(s)
+	if err != nil {
+		return err
+	}
+
+	*m = money
+	return nil
+}
+
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
+
+func (m *Money) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case int64:
+		*m = Money(v * moneyUnit)
+		return nil
+	case []byte:
+		return m.scanString(string(v))
+	case string:
+		return m.scanString(v)
+	}
+
+	return fmt.Errorf("cannot scan %T into Money", src)
+}
+
+func (m *Money) scanString(s string) error {
+	money, err := ParseMoney(s)
+	if err != nil {
+		return err
+	}
+
+	*m = money
+	return nil
+}
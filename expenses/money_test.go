@@ -0,0 +1,111 @@
+//go:build unit
+// +build unit
+
+package expenses_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/tirathawat/assessment/expenses"
+)
+
+func TestMoneyUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		json    string
+		want    expenses.Money
+		wantErr error
+	}{
+		{name: "Should parse integer", json: `79`, want: 7900},
+		{name: "Should parse decimal", json: `0.3`, want: 30},
+		{name: "Should parse two decimal places", json: `66900.05`, want: 6690005},
+		{name: "Should parse numeric string", json: `"12.34"`, want: 1234},
+		{name: "Should parse negative amount", json: `-1.5`, want: -150},
+		{name: "Should reject too many decimal places", json: `1.005`, wantErr: expenses.ErrTooManyFractionals},
+		{name: "Should reject exponent", json: `1e2`, wantErr: expenses.ErrInvalidAmount},
+		{name: "Should reject trailing point", json: `"1."`, wantErr: expenses.ErrInvalidAmount},
+		{name: "Should reject text", json: `"abc"`, wantErr: expenses.ErrInvalidAmount},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var got expenses.Money
+			err := json.Unmarshal([]byte(test.json), &got)
+			if err != test.wantErr {
+				t.Fatalf("unexpected error: got %v want %v", err, test.wantErr)
+			}
+
+			if got != test.want {
+				t.Errorf("unexpected money: got %d want %d", got, test.want)
+			}
+		})
+	}
+}
+
+func TestMoneyMarshalJSON(t *testing.T) {
+	tests := []struct {
+		money expenses.Money
+		want  string
+	}{
+		{money: 7900, want: `79`},
+		{money: 7950, want: `79.5`},
+		{money: 7905, want: `79.05`},
+		{money: 5, want: `0.05`},
+		{money: -150, want: `-1.5`},
+		{money: 0, want: `0`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.want, func(t *testing.T) {
+			got, err := json.Marshal(test.money)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(got) != test.want {
+				t.Errorf("unexpected json: got %s want %s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestMoneySum(t *testing.T) {
+	var a, b expenses.Money
+	if err := json.Unmarshal([]byte(`0.1`), &a); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := json.Unmarshal([]byte(`0.2`), &b); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := (a + b).String(); got != "0.30" {
+		t.Errorf("unexpected sum: got %s want 0.30", got)
+	}
+}
+
+func TestMoneyScan(t *testing.T) {
+	tests := []struct {
+		name string
+		src  interface{}
+		want expenses.Money
+	}{
+		{name: "Should scan numeric text", src: []byte("79.50"), want: 7950},
+		{name: "Should scan string", src: "0.05", want: 5},
+		{name: "Should scan integer", src: int64(12), want: 1200},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var got expenses.Money
+			if err := got.Scan(test.src); err != nil {
+				t.Fatal(err)
+			}
+
+			if got != test.want {
+				t.Errorf("unexpected money: got %d want %d", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,18 @@
+package expenses
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/tirathawat/assessment/middleware"
+	"github.com/tirathawat/assessment/users"
+)
+
+// owner returns the ID of the authenticated user. Every query is scoped to
+// it so an expense of another user looks like it does not exist.
+func (h *handler) owner(c *gin.Context) (int, error) {
+	id, ok := users.ID(c)
+	if !ok {
+		return 0, middleware.ErrUnauthorized
+	}
+
+	return id, nil
+}
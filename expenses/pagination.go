@@ -0,0 +1,276 @@
+package expenses
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	defaultLimit = 20
+	defaultSort  = "id"
+
+	directionNext = "next"
+	directionPrev = "prev"
+)
+
+type ListQuery struct {
+	Filter
+
+	Limit  int    `form:"limit" binding:"omitempty,min=1,max=100"`
+	Offset int    `form:"offset" binding:"omitempty,min=0"`
+	Cursor string `form:"cursor"`
+	Sort   string `form:"sort"`
+
+	// Currency adds the amount converted into this currency to every expense.
+	Currency string `form:"currency" binding:"omitempty,iso4217"`
+}
+
+type sortColumn struct {
+	value func(e Expense) interface{}
+	scan  func() interface{}
+}
+
+// sortColumns is the whitelist of columns that can be used in the sort
+// query parameter. Column names are interpolated into SQL, so anything not
+// listed here must be rejected.
+var sortColumns = map[string]sortColumn{
+	"id": {
+		value: func(e Expense) interface{} { return e.ID },
+		scan:  func() interface{} { return new(int) },
+	},
+	"title": {
+		value: func(e Expense) interface{} { return e.Title },
+		scan:  func() interface{} { return new(string) },
+	},
+	"amount": {
+		value: func(e Expense) interface{} { return e.Amount },
+		scan:  func() interface{} { return new(Money) },
+	},
+	"spent_at": {
+		value: func(e Expense) interface{} { return e.SpentAt },
+		scan:  func() interface{} { return new(time.Time) },
+	},
+	"created_at": {
+		value: func(e Expense) interface{} { return e.CreatedAt },
+		scan:  func() interface{} { return new(time.Time) },
+	},
+}
+
+type sortField struct {
+	column string
+	desc   bool
+}
+
+type cursor struct {
+	Sort      string            `json:"s"`
+	Direction string            `json:"d"`
+	Values    []json.RawMessage `json:"v"`
+}
+
+type page struct {
+	limit     int
+	offset    int
+	sort      string
+	fields    []sortField
+	direction string
+	after     []interface{}
+}
+
+func parseSort(sort string) ([]sortField, error) {
+	if sort == "" {
+		sort = defaultSort
+	}
+
+	var fields []sortField
+	seen := map[string]bool{}
+	for _, part := range strings.Split(sort, ",") {
+		field := sortField{column: strings.TrimSpace(part)}
+		if strings.HasPrefix(field.column, "-") {
+			field.column = field.column[1:]
+			field.desc = true
+		}
+
+		if _, ok := sortColumns[field.column]; !ok || seen[field.column] {
+			return nil, ErrInvalidSort
+		}
+
+		seen[field.column] = true
+		fields = append(fields, field)
+	}
+
+	if !seen["id"] {
+		fields = append(fields, sortField{column: "id"})
+	}
+
+	return fields, nil
+}
+
+func newPage(q ListQuery) (*page, error) {
+	fields, err := parseSort(q.Sort)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &page{
+		limit:     q.Limit,
+		offset:    q.Offset,
+		sort:      q.Sort,
+		fields:    fields,
+		direction: directionNext,
+	}
+
+	if p.limit == 0 {
+		p.limit = defaultLimit
+	}
+
+	if q.Cursor == "" {
+		return p, nil
+	}
+
+	if q.Offset != 0 {
+		return nil, ErrCursorWithOffset
+	}
+
+	if err := p.decodeCursor(q.Cursor); err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	return p, nil
+}
+
+func (p *page) decodeCursor(encoded string) error {
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return err
+	}
+
+	var cur cursor
+	if err := json.Unmarshal(raw, &cur); err != nil {
+		return err
+	}
+
+	if cur.Sort != p.sort || len(cur.Values) != len(p.fields) {
+		return fmt.Errorf("cursor does not match sort %q", p.sort)
+	}
+
+	if cur.Direction != directionNext && cur.Direction != directionPrev {
+		return fmt.Errorf("unknown cursor direction %q", cur.Direction)
+	}
+
+	p.direction = cur.Direction
+	p.after = make([]interface{}, len(p.fields))
+	for i, field := range p.fields {
+		dest := sortColumns[field.column].scan()
+		if err := json.Unmarshal(cur.Values[i], dest); err != nil {
+			return err
+		}
+		p.after[i] = reflect.ValueOf(dest).Elem().Interface()
+	}
+
+	return nil
+}
+
+func (p *page) encodeCursor(e Expense, direction string) string {
+	cur := cursor{Sort: p.sort, Direction: direction}
+	for _, field := range p.fields {
+		value, _ := json.Marshal(sortColumns[field.column].value(e))
+		cur.Values = append(cur.Values, value)
+	}
+
+	raw, _ := json.Marshal(cur)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// scope orders, bounds and positions the query. One extra row is fetched so
+// the caller can tell whether another page exists. Previous pages are read
+// in reverse order and flipped back by results.
+func (p *page) scope(db *gorm.DB) *gorm.DB {
+	reverse := p.direction == directionPrev
+
+	if p.after != nil {
+		var (
+			clauses []string
+			args    []interface{}
+		)
+
+		for i, field := range p.fields {
+			var conds []string
+			for j := 0; j < i; j++ {
+				conds = append(conds, p.fields[j].column+" = ?")
+				args = append(args, p.after[j])
+			}
+
+			op := ">"
+			if field.desc != reverse {
+				op = "<"
+			}
+
+			conds = append(conds, field.column+" "+op+" ?")
+			args = append(args, p.after[i])
+			clauses = append(clauses, "("+strings.Join(conds, " AND ")+")")
+		}
+
+		db = db.Where(strings.Join(clauses, " OR "), args...)
+	}
+
+	for _, field := range p.fields {
+		order := field.column
+		if field.desc != reverse {
+			order += " DESC"
+		}
+		db = db.Order(order)
+	}
+
+	return db.Offset(p.offset).Limit(p.limit + 1)
+}
+
+// results trims the look-ahead row, restores the requested order and
+// reports whether pages exist on either side.
+func (p *page) results(expenses []Expense) (list []Expense, hasPrev, hasNext bool) {
+	more := len(expenses) > p.limit
+	if more {
+		expenses = expenses[:p.limit]
+	}
+
+	if p.direction == directionPrev {
+		for i, j := 0, len(expenses)-1; i < j; i, j = i+1, j-1 {
+			expenses[i], expenses[j] = expenses[j], expenses[i]
+		}
+		return expenses, more, true
+	}
+
+	return expenses, p.after != nil || p.offset > 0, more
+}
+
+func (p *page) links(u *url.URL, expenses []Expense, hasPrev, hasNext bool) []string {
+	if len(expenses) == 0 {
+		return nil
+	}
+
+	link := func(e Expense, direction, rel string) string {
+		query := u.Query()
+		query.Del("offset")
+		query.Set("limit", strconv.Itoa(p.limit))
+		query.Set("cursor", p.encodeCursor(e, direction))
+		return fmt.Sprintf(`<%s?%s>; rel="%s"`, u.Path, query.Encode(), rel)
+	}
+
+	var links []string
+	if hasPrev {
+		links = append(links, link(expenses[0], directionPrev, "prev"))
+	}
+
+	if hasNext {
+		links = append(links, link(expenses[len(expenses)-1], directionNext, "next"))
+	}
+
+	return links
+}
@@ -0,0 +1,155 @@
+//go:build unit
+// +build unit
+
+package expenses
+
+import (
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func dryRunDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{
+		DryRun:               true,
+		DisableAutomaticPing: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return db
+}
+
+func TestParseSort(t *testing.T) {
+	tests := []struct {
+		name    string
+		sort    string
+		want    []sortField
+		wantErr error
+	}{
+		{
+			name: "Should sort by id when sort is empty",
+			sort: "",
+			want: []sortField{{column: "id"}},
+		},
+		{
+			name: "Should append id as tie breaker",
+			sort: "amount,-title",
+			want: []sortField{{column: "amount"}, {column: "title", desc: true}, {column: "id"}},
+		},
+		{
+			name: "Should keep id position when given",
+			sort: "-id,amount",
+			want: []sortField{{column: "id", desc: true}, {column: "amount"}},
+		},
+		{
+			name:    "Should return error when column is unknown",
+			sort:    "note",
+			wantErr: ErrInvalidSort,
+		},
+		{
+			name:    "Should return error when column is repeated",
+			sort:    "amount,-amount",
+			wantErr: ErrInvalidSort,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := parseSort(test.sort)
+			if err != test.wantErr {
+				t.Fatalf("unexpected error: got %v want %v", err, test.wantErr)
+			}
+
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("unexpected sort fields: got %v want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestPageCursor(t *testing.T) {
+	first, err := newPage(ListQuery{Limit: 2, Sort: "-amount"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	u, _ := url.Parse("/expenses?limit=2&sort=-amount")
+	links := first.links(u, []Expense{{ID: 3, Amount: 30000}, {ID: 2, Amount: 20000}}, false, true)
+	if len(links) != 1 || !strings.HasSuffix(links[0], `rel="next"`) {
+		t.Fatalf("unexpected links: %v", links)
+	}
+
+	next, _ := url.Parse(strings.TrimSuffix(strings.TrimPrefix(links[0], "<"), `>; rel="next"`))
+	second, err := newPage(ListQuery{Limit: 2, Sort: "-amount", Cursor: next.Query().Get("cursor")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(second.after, []interface{}{Money(20000), 2}) {
+		t.Errorf("unexpected cursor values: got %v", second.after)
+	}
+
+	sql := dryRunDB(t).ToSQL(func(tx *gorm.DB) *gorm.DB {
+		return tx.Scopes(second.scope).Find(&[]Expense{})
+	})
+	want := `WHERE ((amount < '200.00') OR (amount = '200.00' AND id > 2)) AND "expenses"."deleted_at" IS NULL ORDER BY amount DESC,id LIMIT 3`
+	if !strings.Contains(sql, want) {
+		t.Errorf("unexpected sql: got %s want %s", sql, want)
+	}
+
+	if _, err := newPage(ListQuery{Sort: "amount", Cursor: next.Query().Get("cursor")}); err != ErrInvalidCursor {
+		t.Errorf("expected cursor to be rejected for another sort, got %v", err)
+	}
+}
+
+func TestPageResults(t *testing.T) {
+	tests := []struct {
+		name        string
+		page        *page
+		expenses    []Expense
+		want        []Expense
+		wantHasPrev bool
+		wantHasNext bool
+	}{
+		{
+			name:        "Should report next page when look-ahead row exists",
+			page:        &page{limit: 2, direction: directionNext},
+			expenses:    []Expense{{ID: 1}, {ID: 2}, {ID: 3}},
+			want:        []Expense{{ID: 1}, {ID: 2}},
+			wantHasNext: true,
+		},
+		{
+			name:        "Should report previous page when offset is set",
+			page:        &page{limit: 2, offset: 2, direction: directionNext},
+			expenses:    []Expense{{ID: 3}},
+			want:        []Expense{{ID: 3}},
+			wantHasPrev: true,
+		},
+		{
+			name:        "Should reverse rows when reading previous page",
+			page:        &page{limit: 2, direction: directionPrev, after: []interface{}{3}},
+			expenses:    []Expense{{ID: 2}, {ID: 1}},
+			want:        []Expense{{ID: 1}, {ID: 2}},
+			wantHasNext: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, hasPrev, hasNext := test.page.results(test.expenses)
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("unexpected expenses: got %v want %v", got, test.want)
+			}
+
+			if hasPrev != test.wantHasPrev || hasNext != test.wantHasNext {
+				t.Errorf("unexpected pages: got prev %v next %v want prev %v next %v", hasPrev, hasNext, test.wantHasPrev, test.wantHasNext)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package expenses
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+	"github.com/tirathawat/assessment/errs"
+)
+
+const (
+	MergePatchContentType = "application/merge-patch+json"
+	JSONPatchContentType  = "application/json-patch+json"
+)
+
+var (
+	ErrUnsupportedPatch = errs.New(http.StatusUnsupportedMediaType, "UNSUPPORTED_PATCH", "unsupported patch content type, use application/merge-patch+json or application/json-patch+json")
+	ErrInvalidPatch     = errs.New(http.StatusBadRequest, "INVALID_PATCH", "invalid patch document")
+	ErrPatchConflict    = errs.New(http.StatusUnprocessableEntity, "PATCH_CONFLICT", "patch cannot be applied to expense")
+	ErrSpentAtRequired  = errs.New(http.StatusBadRequest, "SPENT_AT_REQUIRED", "spent_at cannot be removed")
+)
+
+// patchDocument is the part of an expense a patch may change. Its shape
+// matches CreateRequestBody so both are validated the same way.
+func patchDocument(e Expense) ([]byte, error) {
+	tags := []string(e.Tags)
+	if tags == nil {
+		tags = []string{}
+	}
+
+	return json.Marshal(CreateRequestBody{
+		Title:    e.Title,
+		Amount:   e.Amount,
+		Currency: e.Currency,
+		Note:     e.Note,
+		Tags:     tags,
+		SpentAt:  &e.SpentAt,
+	})
+}
+
+// applyPatch applies an RFC 7396 merge patch or an RFC 6902 JSON patch,
+// depending on the content type, and decodes the result.
+func applyPatch(contentType string, e Expense, patch []byte) (CreateRequestBody, error) {
+	var body CreateRequestBody
+	doc, err := patchDocument(e)
+	if err != nil {
+		return body, err
+	}
+
+	switch contentType {
+	case MergePatchContentType, "application/json":
+		if !json.Valid(patch) || !bytes.HasPrefix(bytes.TrimSpace(patch), []byte("{")) {
+			return body, ErrInvalidPatch
+		}
+
+		if doc, err = jsonpatch.MergePatch(doc, patch); err != nil {
+			return body, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+		}
+	case JSONPatchContentType:
+		operations, err := jsonpatch.DecodePatch(patch)
+		if err != nil {
+			return body, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+		}
+
+		if doc, err = operations.Apply(doc); err != nil {
+			return body, fmt.Errorf("%w: %v", ErrPatchConflict, err)
+		}
+	default:
+		return body, ErrUnsupportedPatch
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(doc))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&body); err != nil {
+		return body, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+
+	if body.SpentAt == nil {
+		return body, ErrSpentAtRequired
+	}
+
+	return body, nil
+}
+
+func patchStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrUnsupportedPatch):
+		return http.StatusUnsupportedMediaType
+	case errors.Is(err, ErrPatchConflict):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, ErrInvalidPatch), errors.Is(err, ErrSpentAtRequired):
+		return http.StatusBadRequest
+	}
+
+	return http.StatusInternalServerError
+}
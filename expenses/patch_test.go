@@ -0,0 +1,117 @@
+//go:build unit
+// +build unit
+
+package expenses
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+func TestApplyPatch(t *testing.T) {
+	spentAt := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	expense := Expense{
+		ID:       1,
+		Title:    "test expense",
+		Amount:   10000,
+		Currency: "THB",
+		Note:     "test note",
+		Tags:     pq.StringArray([]string{"tag1", "tag2"}),
+		SpentAt:  spentAt,
+	}
+
+	tests := []struct {
+		name        string
+		contentType string
+		patch       string
+		want        CreateRequestBody
+		wantErr     error
+	}{
+		{
+			name:        "Should only change supplied fields with merge patch",
+			contentType: MergePatchContentType,
+			patch:       `{"title":"new title","amount":"12.5"}`,
+			want:        CreateRequestBody{Title: "new title", Amount: 1250, Currency: "THB", Note: "test note", Tags: []string{"tag1", "tag2"}, SpentAt: &spentAt},
+		},
+		{
+			name:        "Should replace tags with merge patch",
+			contentType: "application/json",
+			patch:       `{"tags":["food"]}`,
+			want:        CreateRequestBody{Title: "test expense", Amount: 10000, Currency: "THB", Note: "test note", Tags: []string{"food"}, SpentAt: &spentAt},
+		},
+		{
+			name:        "Should clear a field set to null with merge patch",
+			contentType: MergePatchContentType,
+			patch:       `{"note":null}`,
+			want:        CreateRequestBody{Title: "test expense", Amount: 10000, Currency: "THB", Tags: []string{"tag1", "tag2"}, SpentAt: &spentAt},
+		},
+		{
+			name:        "Should add and remove tags with json patch",
+			contentType: JSONPatchContentType,
+			patch:       `[{"op":"remove","path":"/tags/0"},{"op":"add","path":"/tags/-","value":"food"}]`,
+			want:        CreateRequestBody{Title: "test expense", Amount: 10000, Currency: "THB", Note: "test note", Tags: []string{"tag2", "food"}, SpentAt: &spentAt},
+		},
+		{
+			name:        "Should return conflict when json patch test fails",
+			contentType: JSONPatchContentType,
+			patch:       `[{"op":"test","path":"/title","value":"other"},{"op":"replace","path":"/title","value":"new"}]`,
+			wantErr:     ErrPatchConflict,
+		},
+		{
+			name:        "Should reject fields that cannot be patched",
+			contentType: MergePatchContentType,
+			patch:       `{"id":2}`,
+			wantErr:     ErrInvalidPatch,
+		},
+		{
+			name:        "Should reject a merge patch that is not an object",
+			contentType: MergePatchContentType,
+			patch:       `["title"]`,
+			wantErr:     ErrInvalidPatch,
+		},
+		{
+			name:        "Should reject a malformed json patch",
+			contentType: JSONPatchContentType,
+			patch:       `{"op":"add"}`,
+			wantErr:     ErrInvalidPatch,
+		},
+		{
+			name:        "Should reject removing spent_at",
+			contentType: JSONPatchContentType,
+			patch:       `[{"op":"remove","path":"/spent_at"}]`,
+			wantErr:     ErrSpentAtRequired,
+		},
+		{
+			name:        "Should reject unsupported content type",
+			contentType: "text/plain",
+			patch:       `{}`,
+			wantErr:     ErrUnsupportedPatch,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := applyPatch(test.contentType, expense, []byte(test.patch))
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("unexpected error: got %v want %v", err, test.wantErr)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if !got.SpentAt.Equal(*test.want.SpentAt) {
+				t.Errorf("unexpected spent_at: got %v want %v", got.SpentAt, test.want.SpentAt)
+			}
+			got.SpentAt, test.want.SpentAt = nil, nil
+
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("unexpected body: got %+v want %+v", got, test.want)
+			}
+		})
+	}
+}
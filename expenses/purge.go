@@ -0,0 +1,76 @@
+package expenses
+
+import (
+	"time"
+
+	"github.com/tirathawat/assessment/config"
+	"github.com/tirathawat/assessment/logs"
+)
+
+// Purger permanently removes soft-deleted expenses once they have been in
+// the trash for longer than the configured retention.
+type Purger struct {
+	db        DB
+	retention time.Duration
+	interval  time.Duration
+	stop      chan struct{}
+	done      chan struct{}
+}
+
+func NewPurger(db DB, cfg *config.AppConfig) *Purger {
+	return &Purger{
+		db:        db,
+		retention: cfg.TrashRetention,
+		interval:  cfg.PurgeInterval,
+	}
+}
+
+func (p *Purger) Start() {
+	p.stop = make(chan struct{})
+	p.done = make(chan struct{})
+
+	go func() {
+		defer close(p.done)
+
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+
+		for {
+			p.run()
+
+			select {
+			case <-ticker.C:
+			case <-p.stop:
+				return
+			}
+		}
+	}()
+}
+
+func (p *Purger) Stop() {
+	if p.stop == nil {
+		return
+	}
+
+	close(p.stop)
+	<-p.done
+}
+
+// Purge deletes every expense that was moved to the trash before
+// now minus the retention and returns how many rows were removed.
+func (p *Purger) Purge(now time.Time) (int64, error) {
+	result := p.db.Unscoped().Delete(&Expense{}, "deleted_at < ?", now.Add(-p.retention))
+	return result.RowsAffected, result.Error
+}
+
+func (p *Purger) run() {
+	purged, err := p.Purge(time.Now())
+	if err != nil {
+		logs.Error().Err(err).Msg("failed to purge expenses")
+		return
+	}
+
+	if purged > 0 {
+		logs.Info().Msgf("purged %d expenses from trash", purged)
+	}
+}
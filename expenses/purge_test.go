@@ -0,0 +1,60 @@
+//go:build unit
+// +build unit
+
+package expenses_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/tirathawat/assessment/config"
+	"github.com/tirathawat/assessment/expenses"
+	"gorm.io/gorm"
+)
+
+func TestPurge(t *testing.T) {
+	tests := []struct {
+		name       string
+		mockDB     *MockDB
+		wantPurged int64
+		wantErr    bool
+	}{
+		{
+			name: "Should return purged rows when purge successfully",
+			mockDB: &MockDB{
+				dbs: []*gorm.DB{{RowsAffected: 3}},
+				methodsToCall: map[string]bool{
+					deleteMethod: false,
+				},
+			},
+			wantPurged: 3,
+		},
+		{
+			name: "Should return error when database error",
+			mockDB: &MockDB{
+				dbs: []*gorm.DB{{Error: errors.New("error")}},
+				methodsToCall: map[string]bool{
+					deleteMethod: false,
+				},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			purger := expenses.NewPurger(test.mockDB, &config.AppConfig{TrashRetention: time.Hour})
+			purged, err := purger.Purge(time.Now())
+			if (err != nil) != test.wantErr {
+				t.Errorf("unexpected error: got %v want error %v", err, test.wantErr)
+			}
+
+			if purged != test.wantPurged {
+				t.Errorf("unexpected purged rows: got %v want %v", purged, test.wantPurged)
+			}
+
+			test.mockDB.Verify(t)
+		})
+	}
+}
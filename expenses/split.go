@@ -0,0 +1,35 @@
+package expenses
+
+import (
+	"net/http"
+
+	"github.com/tirathawat/assessment/errs"
+	"gorm.io/gorm"
+)
+
+// splitsTable is where groups records how an expense is shared among the
+// members of a group. The expense amount is what the members owe, in the
+// currency of the group.
+const splitsTable = "expense_splits"
+
+var ErrSplitLocked = errs.New(http.StatusConflict, "EXPENSE_SPLIT_LOCKED", "the amount and currency of an expense shared in a group cannot be changed")
+
+// checkSplit refuses to change the amount or currency of an expense that is
+// shared in a group, as that would change what the members owe.
+func checkSplit(tx DB, before, after Expense) error {
+	if before.Amount == after.Amount && before.Currency == after.Currency {
+		return nil
+	}
+
+	var count int64
+	table := func(db *gorm.DB) *gorm.DB { return db.Table(splitsTable) }
+	if err := tx.Scopes(table).Where("expense_id = ?", after.ID).Count(&count).Error; err != nil {
+		return err
+	}
+
+	if count > 0 {
+		return ErrSplitLocked
+	}
+
+	return nil
+}
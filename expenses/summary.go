@@ -0,0 +1,100 @@
+package expenses
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	groupByTag   = "tag"
+	groupByMonth = "month"
+	groupByWeek  = "week"
+)
+
+type SummaryQuery struct {
+	Filter
+
+	GroupBy  string `form:"group_by" binding:"required,oneof=tag month week"`
+	Currency string `form:"currency" binding:"omitempty,iso4217"`
+}
+
+type SummaryGroup struct {
+	Key     string `json:"key"`
+	Count   int64  `json:"count"`
+	Total   Money  `json:"total"`
+	Average Money  `json:"average"`
+	Min     Money  `json:"min"`
+	Max     Money  `json:"max"`
+
+	// Unconverted counts expenses in the group without an exchange rate.
+	Unconverted int64 `json:"-"`
+}
+
+type Summary struct {
+	GroupBy  string         `json:"group_by"`
+	Currency string         `json:"currency"`
+	Groups   []SummaryGroup `json:"groups"`
+}
+
+// summary aggregates expenses in SQL after converting each amount into
+// currency with the rate effective on the day it was spent.
+type summary struct {
+	query    SummaryQuery
+	owner    int
+	base     string
+	currency string
+	loc      *time.Location
+}
+
+// rateSQL selects the rate of a currency in the base currency effective on
+// the day an expense was spent. Its arguments are the currency, the base
+// currency, the currency again and the time zone.
+const rateSQL = `CASE WHEN ? = ? THEN 1 ELSE (
+	SELECT r.rate FROM exchange_rates r
+	WHERE r.currency = ? AND r.effective_on <= (expenses.spent_at AT TIME ZONE ?)::date
+	ORDER BY r.effective_on DESC LIMIT 1
+) END`
+
+func (s *summary) scope(db *gorm.DB) *gorm.DB {
+	tz := s.loc.String()
+	scale := MoneyScale
+	if CurrencyUnit(s.currency) != 1 {
+		scale = 0
+	}
+
+	from := gorm.Expr(rateSQL, gorm.Expr("expenses.currency"), s.base, gorm.Expr("expenses.currency"), tz)
+	to := gorm.Expr(rateSQL, s.currency, s.base, s.currency, tz)
+	converted := db.Session(&gorm.Session{NewDB: true}).
+		Model(&Expense{}).
+		Where("expenses.owner_id = ?", s.owner).
+		Scopes(s.query.Filter.scope).
+		Select(fmt.Sprintf("expenses.tags, expenses.spent_at, ROUND(expenses.amount * (?) / (?), %d) AS amount", scale), from, to)
+
+	db = db.Table("(?) AS e", converted)
+
+	var key string
+	var args []interface{}
+	switch s.query.GroupBy {
+	case groupByTag:
+		db = db.Joins("CROSS JOIN LATERAL unnest(e.tags) AS tag")
+		key = "tag"
+	case groupByMonth:
+		key = `to_char(e.spent_at AT TIME ZONE ?, 'YYYY-MM')`
+		args = append(args, tz)
+	case groupByWeek:
+		key = `to_char(e.spent_at AT TIME ZONE ?, 'IYYY-"W"IW')`
+		args = append(args, tz)
+	}
+
+	return db.Select(fmt.Sprintf(`%s AS key,
+		COUNT(*) AS count,
+		COALESCE(SUM(e.amount), 0) AS total,
+		COALESCE(ROUND(AVG(e.amount), %d), 0) AS average,
+		COALESCE(MIN(e.amount), 0) AS min,
+		COALESCE(MAX(e.amount), 0) AS max,
+		COUNT(*) FILTER (WHERE e.amount IS NULL) AS unconverted`, key, scale), args...).
+		Group("key").
+		Order("key")
+}
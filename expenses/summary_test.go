@@ -0,0 +1,81 @@
+//go:build unit
+// +build unit
+
+package expenses
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func TestSummaryScope(t *testing.T) {
+	tests := []struct {
+		name    string
+		summary summary
+		want    []string
+	}{
+		{
+			name:    "Should unnest tags when grouping by tag",
+			summary: summary{query: SummaryQuery{GroupBy: groupByTag}, base: "THB", currency: "THB"},
+			want: []string{
+				`FROM (SELECT expenses.tags, expenses.spent_at, ROUND(expenses.amount * (CASE WHEN expenses.currency = 'THB'`,
+				`CROSS JOIN LATERAL unnest(e.tags) AS tag`,
+				`SELECT tag AS key`,
+				`GROUP BY "key" ORDER BY key`,
+			},
+		},
+		{
+			name:    "Should truncate spent_at to month in the time zone",
+			summary: summary{query: SummaryQuery{GroupBy: groupByMonth}, base: "THB", currency: "USD"},
+			want: []string{
+				`to_char(e.spent_at AT TIME ZONE 'Asia/Bangkok', 'YYYY-MM') AS key`,
+				`WHERE r.currency = 'USD' AND`,
+			},
+		},
+		{
+			name:    "Should use ISO weeks when grouping by week",
+			summary: summary{query: SummaryQuery{GroupBy: groupByWeek}, base: "THB", currency: "JPY"},
+			want: []string{
+				`to_char(e.spent_at AT TIME ZONE 'Asia/Bangkok', 'IYYY-"W"IW') AS key`,
+				`ROUND(AVG(e.amount), 0)`,
+			},
+		},
+		{
+			name: "Should apply the owner and list filter before grouping",
+			summary: summary{
+				query: SummaryQuery{Filter: Filter{From: "2023-01-01", To: "2023-01-31"}, GroupBy: groupByTag},
+				owner: 7, base: "THB", currency: "THB",
+			},
+			want: []string{
+				`FROM "expenses" WHERE expenses.owner_id = 7 AND spent_at >= '2023-01-01 00:00:00' AND spent_at < '2023-02-01 00:00:00' AND "expenses"."deleted_at" IS NULL) AS e`,
+			},
+		},
+	}
+
+	loc, err := time.LoadLocation("Asia/Bangkok")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.summary.loc = loc
+			if err := test.summary.query.Filter.prepare(loc); err != nil {
+				t.Fatal(err)
+			}
+
+			sql := dryRunDB(t).ToSQL(func(tx *gorm.DB) *gorm.DB {
+				return tx.Scopes(test.summary.scope).Scan(&[]SummaryGroup{})
+			})
+
+			for _, want := range test.want {
+				if !strings.Contains(sql, want) {
+					t.Errorf("unexpected sql: got %s want %s", sql, want)
+				}
+			}
+		})
+	}
+}
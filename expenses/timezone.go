@@ -0,0 +1,40 @@
+package expenses
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TimeZoneHeader lets a client ask for timestamps rendered in its own
+// time zone, e.g. "Time-Zone: Asia/Tokyo".
+const TimeZoneHeader = "Time-Zone"
+
+func (h *handler) location(c *gin.Context) (*time.Location, error) {
+	name := c.GetHeader(TimeZoneHeader)
+	if name == "" {
+		return h.timeZone, nil
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, ErrInvalidTimeZone
+	}
+
+	return loc, nil
+}
+
+func localize(expenses []Expense, loc *time.Location) []Expense {
+	localized := make([]Expense, len(expenses))
+	for i, expense := range expenses {
+		localized[i] = expense.In(loc)
+	}
+
+	return localized
+}
+
+// Now is truncated to microseconds, the precision Postgres keeps, so the
+// value returned on create matches what is read back later.
+func Now() time.Time {
+	return time.Now().Truncate(time.Microsecond)
+}
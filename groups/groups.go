@@ -0,0 +1,108 @@
+package groups
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/tirathawat/assessment/errs"
+	"github.com/tirathawat/assessment/expenses"
+)
+
+var (
+	ErrNotFound         = errs.New(http.StatusNotFound, "GROUP_NOT_FOUND", "group not found")
+	ErrInvalidID        = errs.New(http.StatusBadRequest, "INVALID_ID", "invalid id")
+	ErrCreateFailed     = errs.New(http.StatusInternalServerError, "GROUP_CREATE_FAILED", "failed to create group")
+	ErrListFailed       = errs.New(http.StatusInternalServerError, "GROUP_LIST_FAILED", "failed to list groups")
+	ErrGetFailed        = errs.New(http.StatusInternalServerError, "GROUP_GET_FAILED", "failed to get group")
+	ErrAddMemberFailed  = errs.New(http.StatusInternalServerError, "MEMBER_ADD_FAILED", "failed to add member")
+	ErrAddExpenseFailed = errs.New(http.StatusInternalServerError, "GROUP_EXPENSE_ADD_FAILED", "failed to add expense")
+	ErrBalancesFailed   = errs.New(http.StatusInternalServerError, "GROUP_BALANCES_FAILED", "failed to compute balances")
+	ErrDuplicateMember  = errs.New(http.StatusBadRequest, "DUPLICATE_MEMBER", "member names must be unique within a group")
+	ErrCurrencyMismatch = errs.New(http.StatusBadRequest, "CURRENCY_MISMATCH", "expense currency must match the group currency")
+	ErrUnknownMember    = errs.New(http.StatusBadRequest, "UNKNOWN_MEMBER", "payer and split members must belong to the group")
+	ErrMemberExists     = errs.New(http.StatusConflict, "MEMBER_EXISTS", "a member with this name is already in the group")
+)
+
+// Group is a set of people sharing expenses, such as a trip or a team
+// dinner. Members do not need accounts; they are known by name.
+type Group struct {
+	ID        int       `gorm:"primary_key" json:"id"`
+	OwnerID   int       `gorm:"not null;index" json:"-"`
+	Name      string    `gorm:"type:text;not null" json:"name"`
+	Currency  string    `gorm:"type:char(3);not null" json:"currency"`
+	CreatedAt time.Time `gorm:"not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+
+	Members []Member `gorm:"foreignKey:GroupID;constraint:OnDelete:CASCADE" json:"members"`
+}
+
+type Member struct {
+	ID      int    `gorm:"primary_key" json:"id"`
+	GroupID int    `gorm:"not null;uniqueIndex:idx_group_members_name" json:"-"`
+	Name    string `gorm:"type:text;not null;uniqueIndex:idx_group_members_name" json:"name"`
+}
+
+func (Member) TableName() string {
+	return "group_members"
+}
+
+// Split records who paid for a group expense and how it is shared. Shares
+// keep weights rather than amounts, which are worked out from the expense
+// amount when they are read. The amount and currency of a split expense
+// cannot be changed, see expenses.ErrSplitLocked.
+type Split struct {
+	ExpenseID int    `gorm:"primaryKey;autoIncrement:false" json:"expense_id"`
+	GroupID   int    `gorm:"not null;index" json:"group_id"`
+	PayerID   int    `gorm:"not null" json:"payer_id"`
+	Method    string `gorm:"type:text;not null" json:"method"`
+
+	Shares  []Share          `gorm:"foreignKey:ExpenseID;constraint:OnDelete:CASCADE" json:"shares"`
+	Expense expenses.Expense `gorm:"foreignKey:ExpenseID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+func (Split) TableName() string {
+	return "expense_splits"
+}
+
+type Share struct {
+	ExpenseID int            `gorm:"primaryKey;autoIncrement:false" json:"-"`
+	MemberID  int            `gorm:"primaryKey;autoIncrement:false" json:"member_id"`
+	Weight    int64          `gorm:"not null" json:"-"`
+	Amount    expenses.Money `gorm:"-" json:"amount"`
+}
+
+func (Share) TableName() string {
+	return "expense_shares"
+}
+
+type CreateRequestBody struct {
+	Name     string   `json:"name" binding:"required,max=100"`
+	Currency string   `json:"currency" binding:"omitempty,iso4217"`
+	Members  []string `json:"members" binding:"required,min=1,dive,required,max=100"`
+}
+
+type MemberRequestBody struct {
+	Name string `json:"name" binding:"required,max=100"`
+}
+
+type ExpenseRequestBody struct {
+	expenses.CreateRequestBody
+	PayerID int          `json:"payer_id" binding:"required"`
+	Split   SplitRequest `json:"split"`
+}
+
+// GroupExpense is an expense along with how it is split in the group.
+type GroupExpense struct {
+	expenses.Expense
+	Split Split `json:"split"`
+}
+
+// has reports whether the member belongs to the group.
+func (g *Group) has(memberID int) bool {
+	for _, member := range g.Members {
+		if member.ID == memberID {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,249 @@
+package groups
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tirathawat/assessment/config"
+	"github.com/tirathawat/assessment/errs"
+	"github.com/tirathawat/assessment/expenses"
+	"github.com/tirathawat/assessment/logs"
+	"github.com/tirathawat/assessment/middleware"
+	"github.com/tirathawat/assessment/users"
+	"gorm.io/gorm"
+)
+
+type Handler interface {
+	Create(c *gin.Context)
+	List(c *gin.Context)
+	Get(c *gin.Context)
+	AddMember(c *gin.Context)
+	AddExpense(c *gin.Context)
+	Balances(c *gin.Context)
+}
+
+type handler struct {
+	store    Store
+	currency string
+	timeZone *time.Location
+}
+
+func NewHandler(store Store, cfg *config.AppConfig) Handler {
+	return &handler{store: store, currency: cfg.DefaultCurrency, timeZone: cfg.TimeZone.Get()}
+}
+
+func (h *handler) Create(c *gin.Context) {
+	owner, ok := users.ID(c)
+	if !ok {
+		logs.Error().Context(c.Request.Context()).Msg("no authenticated user")
+		errs.JSON(c, http.StatusUnauthorized, middleware.ErrUnauthorized)
+		return
+	}
+
+	var body CreateRequestBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msg("failed to bind request body")
+		errs.JSON(c, http.StatusBadRequest, err)
+		return
+	}
+
+	group := Group{OwnerID: owner, Name: body.Name, Currency: body.Currency}
+	if group.Currency == "" {
+		group.Currency = h.currency
+	}
+
+	seen := make(map[string]bool, len(body.Members))
+	for _, name := range body.Members {
+		if seen[name] {
+			logs.Error().Context(c.Request.Context()).Msgf("duplicate member: %s", name)
+			errs.JSON(c, http.StatusBadRequest, ErrDuplicateMember)
+			return
+		}
+		seen[name] = true
+		group.Members = append(group.Members, Member{Name: name})
+	}
+
+	if err := h.store.Create(&group); err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("failed to create group: %s", body.Name)
+		errs.JSON(c, http.StatusInternalServerError, ErrCreateFailed)
+		return
+	}
+
+	c.JSON(http.StatusCreated, group)
+}
+
+func (h *handler) List(c *gin.Context) {
+	owner, ok := users.ID(c)
+	if !ok {
+		logs.Error().Context(c.Request.Context()).Msg("no authenticated user")
+		errs.JSON(c, http.StatusUnauthorized, middleware.ErrUnauthorized)
+		return
+	}
+
+	groups, err := h.store.List(owner)
+	if err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msg("failed to list groups")
+		errs.JSON(c, http.StatusInternalServerError, ErrListFailed)
+		return
+	}
+
+	c.JSON(http.StatusOK, groups)
+}
+
+func (h *handler) Get(c *gin.Context) {
+	group, ok := h.group(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, group)
+}
+
+func (h *handler) AddMember(c *gin.Context) {
+	group, ok := h.group(c)
+	if !ok {
+		return
+	}
+
+	var body MemberRequestBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msg("failed to bind request body")
+		errs.JSON(c, http.StatusBadRequest, err)
+		return
+	}
+
+	for _, member := range group.Members {
+		if member.Name == body.Name {
+			logs.Error().Context(c.Request.Context()).Msgf("duplicate member: %s", body.Name)
+			errs.JSON(c, http.StatusConflict, ErrMemberExists)
+			return
+		}
+	}
+
+	member := Member{GroupID: group.ID, Name: body.Name}
+	if err := h.store.AddMember(&member); err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("failed to add member: %s", body.Name)
+		errs.JSON(c, http.StatusInternalServerError, ErrAddMemberFailed)
+		return
+	}
+
+	c.JSON(http.StatusCreated, member)
+}
+
+func (h *handler) AddExpense(c *gin.Context) {
+	group, ok := h.group(c)
+	if !ok {
+		return
+	}
+
+	var body ExpenseRequestBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msg("failed to bind request body")
+		errs.JSON(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if body.Currency == "" {
+		body.Currency = group.Currency
+	}
+
+	now := expenses.Now()
+	if err := body.Validate(now); err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("invalid request body: %v", body)
+		errs.JSON(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if body.Currency != group.Currency {
+		logs.Error().Context(c.Request.Context()).Msgf("expense currency %s does not match group %d", body.Currency, group.ID)
+		errs.JSON(c, http.StatusBadRequest, ErrCurrencyMismatch)
+		return
+	}
+
+	shares, err := body.Split.shares(body.Amount, expenses.CurrencyUnit(group.Currency))
+	if err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("invalid split: %v", body.Split)
+		errs.JSON(c, http.StatusBadRequest, err)
+		return
+	}
+
+	for _, memberID := range append([]int{body.PayerID}, memberIDs(shares)...) {
+		if !group.has(memberID) {
+			logs.Error().Context(c.Request.Context()).Msgf("member %d is not in group %d", memberID, group.ID)
+			errs.JSON(c, http.StatusBadRequest, ErrUnknownMember)
+			return
+		}
+	}
+
+	expense := body.Expense(now.In(h.timeZone))
+	expense.OwnerID = group.OwnerID
+	split := Split{GroupID: group.ID, PayerID: body.PayerID, Method: body.Split.Method, Shares: shares}
+	if err := h.store.AddExpense(&expense, &split, middleware.Actor(c)); err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("failed to add expense to group: %d", group.ID)
+		errs.JSON(c, http.StatusInternalServerError, ErrAddExpenseFailed)
+		return
+	}
+
+	allocate(expense.Amount, expenses.CurrencyUnit(group.Currency), split.Shares)
+	c.JSON(http.StatusCreated, GroupExpense{Expense: expense, Split: split})
+}
+
+func (h *handler) Balances(c *gin.Context) {
+	group, ok := h.group(c)
+	if !ok {
+		return
+	}
+
+	splits, err := h.store.Splits(group.ID)
+	if err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("failed to load splits of group: %d", group.ID)
+		errs.JSON(c, http.StatusInternalServerError, ErrBalancesFailed)
+		return
+	}
+
+	c.JSON(http.StatusOK, balances(group, splits))
+}
+
+// group loads the authenticated user's group named by the id parameter,
+// responding with an error when it cannot.
+func (h *handler) group(c *gin.Context) (Group, bool) {
+	owner, ok := users.ID(c)
+	if !ok {
+		logs.Error().Context(c.Request.Context()).Msg("no authenticated user")
+		errs.JSON(c, http.StatusUnauthorized, middleware.ErrUnauthorized)
+		return Group{}, false
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("invalid id: %s", c.Param("id"))
+		errs.JSON(c, http.StatusBadRequest, ErrInvalidID)
+		return Group{}, false
+	}
+
+	group, err := h.store.Get(owner, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("group not found: %d", id)
+		errs.JSON(c, http.StatusNotFound, ErrNotFound)
+		return Group{}, false
+	}
+	if err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("failed to get group: %d", id)
+		errs.JSON(c, http.StatusInternalServerError, ErrGetFailed)
+		return Group{}, false
+	}
+
+	return group, true
+}
+
+func memberIDs(shares []Share) []int {
+	ids := make([]int, len(shares))
+	for i, share := range shares {
+		ids[i] = share.MemberID
+	}
+
+	return ids
+}
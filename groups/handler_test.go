@@ -0,0 +1,286 @@
+//go:build unit
+// +build unit
+
+package groups_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tirathawat/assessment/config"
+	"github.com/tirathawat/assessment/expenses"
+	"github.com/tirathawat/assessment/groups"
+	"github.com/tirathawat/assessment/testutils"
+	"github.com/tirathawat/assessment/users"
+	"gorm.io/gorm"
+)
+
+const ownerID = 7
+
+var cfg = &config.AppConfig{DefaultCurrency: "THB"}
+
+type MockStore struct {
+	group  *groups.Group
+	splits []groups.Split
+	err    error
+	added  *groups.Split
+}
+
+func (m *MockStore) Create(group *groups.Group) error {
+	return m.err
+}
+
+func (m *MockStore) List(owner int) ([]groups.Group, error) {
+	return []groups.Group{}, m.err
+}
+
+func (m *MockStore) Get(owner, id int) (groups.Group, error) {
+	if m.group == nil || m.group.OwnerID != owner || m.group.ID != id {
+		return groups.Group{}, gorm.ErrRecordNotFound
+	}
+
+	return *m.group, nil
+}
+
+func (m *MockStore) AddMember(member *groups.Member) error {
+	return m.err
+}
+
+func (m *MockStore) AddExpense(expense *expenses.Expense, split *groups.Split, actor string) error {
+	m.added = split
+	return m.err
+}
+
+func (m *MockStore) Splits(groupID int) ([]groups.Split, error) {
+	return m.splits, m.err
+}
+
+func asUser(handler gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(users.IDKey, ownerID)
+		handler(c)
+	}
+}
+
+func trip() *groups.Group {
+	return &groups.Group{
+		ID:       1,
+		OwnerID:  ownerID,
+		Name:     "trip",
+		Currency: "THB",
+		Members:  []groups.Member{{ID: 1, Name: "Ann"}, {ID: 2, Name: "Ben"}},
+	}
+}
+
+func TestCreate(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		store          *MockStore
+		wantStatusCode int
+	}{
+		{
+			name:           "Should return 201 when create group successfully",
+			body:           `{"name": "trip", "members": ["Ann", "Ben"]}`,
+			store:          &MockStore{},
+			wantStatusCode: http.StatusCreated,
+		},
+		{
+			name:           "Should return 400 when member names repeat",
+			body:           `{"name": "trip", "members": ["Ann", "Ann"]}`,
+			store:          &MockStore{},
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:           "Should return 400 when there are no members",
+			body:           `{"name": "trip", "members": []}`,
+			store:          &MockStore{},
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:           "Should return 500 when store error",
+			body:           `{"name": "trip", "members": ["Ann"]}`,
+			store:          &MockStore{err: errors.New("error")},
+			wantStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			httpRequest := &testutils.HTTPRequest{Method: http.MethodPost, Endpoint: "groups", Body: test.body}
+
+			group := &groups.Group{}
+			statusCode, err := httpRequest.MakeTestHTTPRequest(asUser(groups.NewHandler(test.store, cfg).Create), group)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if statusCode != test.wantStatusCode {
+				t.Errorf("unexpected status code: got %v want %v", statusCode, test.wantStatusCode)
+			}
+
+			if statusCode == http.StatusCreated && (group.Currency != "THB" || len(group.Members) != 2) {
+				t.Errorf("unexpected group: got %+v", group)
+			}
+		})
+	}
+}
+
+func TestAddExpense(t *testing.T) {
+	tests := []struct {
+		name           string
+		id             string
+		body           string
+		store          *MockStore
+		wantStatusCode int
+		wantShares     []expenses.Money
+	}{
+		{
+			name:           "Should return 201 with the shares when add expense successfully",
+			id:             "1",
+			body:           `{"title": "dinner", "amount": 100.01, "note": "team dinner", "tags": ["food"], "payer_id": 1, "split": {"method": "equal", "members": [{"member_id": 1}, {"member_id": 2}]}}`,
+			store:          &MockStore{group: trip()},
+			wantStatusCode: http.StatusCreated,
+			wantShares:     []expenses.Money{5001, 5000},
+		},
+		{
+			name:           "Should return 400 when the payer is not a member",
+			id:             "1",
+			body:           `{"title": "dinner", "amount": 100, "note": "team dinner", "tags": ["food"], "payer_id": 3, "split": {"method": "equal", "members": [{"member_id": 1}]}}`,
+			store:          &MockStore{group: trip()},
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:           "Should return 400 when a split member is not in the group",
+			id:             "1",
+			body:           `{"title": "dinner", "amount": 100, "note": "team dinner", "tags": ["food"], "payer_id": 1, "split": {"method": "equal", "members": [{"member_id": 9}]}}`,
+			store:          &MockStore{group: trip()},
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:           "Should return 400 when the method is unknown",
+			id:             "1",
+			body:           `{"title": "dinner", "amount": 100, "note": "team dinner", "tags": ["food"], "payer_id": 1, "split": {"method": "random", "members": [{"member_id": 1}]}}`,
+			store:          &MockStore{group: trip()},
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:           "Should return 400 when the currency differs from the group",
+			id:             "1",
+			body:           `{"title": "dinner", "amount": 100, "currency": "USD", "note": "team dinner", "tags": ["food"], "payer_id": 1, "split": {"method": "equal", "members": [{"member_id": 1}]}}`,
+			store:          &MockStore{group: trip()},
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:           "Should return 404 when the group belongs to someone else",
+			id:             "2",
+			body:           `{"title": "dinner", "amount": 100, "note": "team dinner", "tags": ["food"], "payer_id": 1, "split": {"method": "equal", "members": [{"member_id": 1}]}}`,
+			store:          &MockStore{group: trip()},
+			wantStatusCode: http.StatusNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			httpRequest := &testutils.HTTPRequest{Method: http.MethodPost, Endpoint: "groups", Body: test.body}
+
+			created := &groups.GroupExpense{}
+			statusCode, err := httpRequest.MakeTestHTTPRequest(asUser(groups.NewHandler(test.store, cfg).AddExpense), created, gin.Param{Key: "id", Value: test.id})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if statusCode != test.wantStatusCode {
+				t.Errorf("unexpected status code: got %v want %v", statusCode, test.wantStatusCode)
+			}
+
+			for i, want := range test.wantShares {
+				if i >= len(created.Split.Shares) || created.Split.Shares[i].Amount != want {
+					t.Errorf("unexpected shares: got %v want %v", created.Split.Shares, test.wantShares)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestAddExpenseSpentAt(t *testing.T) {
+	bangkok, err := time.LoadLocation("Asia/Bangkok")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.AppConfig{DefaultCurrency: "THB", TimeZone: config.Location{Location: bangkok}}
+
+	httpRequest := &testutils.HTTPRequest{
+		Method:   http.MethodPost,
+		Endpoint: "groups",
+		Body:     `{"title": "dinner", "amount": 100, "note": "team dinner", "tags": ["food"], "payer_id": 1, "split": {"method": "equal", "members": [{"member_id": 1}]}}`,
+	}
+
+	created := &groups.GroupExpense{}
+	statusCode, err := httpRequest.MakeTestHTTPRequest(asUser(groups.NewHandler(&MockStore{group: trip()}, cfg).AddExpense), created, gin.Param{Key: "id", Value: "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if statusCode != http.StatusCreated {
+		t.Fatalf("unexpected status code: got %v want %v", statusCode, http.StatusCreated)
+	}
+
+	if _, offset := created.SpentAt.Zone(); offset != 7*60*60 {
+		t.Errorf("unexpected spent_at offset: got %v want %v", offset, 7*60*60)
+	}
+
+	if created.SpentAt.Nanosecond()%int(time.Microsecond) != 0 {
+		t.Errorf("unexpected spent_at precision: got %v want microseconds", created.SpentAt)
+	}
+}
+
+func TestBalances(t *testing.T) {
+	tests := []struct {
+		name           string
+		store          *MockStore
+		wantStatusCode int
+		wantTransfers  []groups.Transfer
+	}{
+		{
+			name: "Should return 200 with the transfers that settle the group",
+			store: &MockStore{group: trip(), splits: []groups.Split{{
+				PayerID: 1,
+				Method:  groups.SplitEqual,
+				Expense: expenses.Expense{Amount: 10000},
+				Shares:  []groups.Share{{MemberID: 1, Weight: 1}, {MemberID: 2, Weight: 1}},
+			}}},
+			wantStatusCode: http.StatusOK,
+			wantTransfers:  []groups.Transfer{{From: 2, To: 1, Amount: 5000}},
+		},
+		{
+			name:           "Should return 500 when store error",
+			store:          &MockStore{group: trip(), err: errors.New("error")},
+			wantStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			httpRequest := &testutils.HTTPRequest{Method: http.MethodGet, Endpoint: "groups"}
+
+			got := &groups.Balances{}
+			statusCode, err := httpRequest.MakeTestHTTPRequest(asUser(groups.NewHandler(test.store, cfg).Balances), got, gin.Param{Key: "id", Value: "1"})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if statusCode != test.wantStatusCode {
+				t.Errorf("unexpected status code: got %v want %v", statusCode, test.wantStatusCode)
+			}
+
+			if statusCode == http.StatusOK && (len(got.Transfers) != 1 || got.Transfers[0] != test.wantTransfers[0]) {
+				t.Errorf("unexpected transfers: got %v want %v", got.Transfers, test.wantTransfers)
+			}
+		})
+	}
+}
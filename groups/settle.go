@@ -0,0 +1,189 @@
+package groups
+
+import (
+	"sort"
+
+	"github.com/tirathawat/assessment/expenses"
+)
+
+// maxExactSettle bounds the members settled with the exact search, which
+// grows as 2^n. Larger groups are settled greedily, which needs at most one
+// transfer fewer than the members owing or owed.
+const maxExactSettle = 16
+
+type Balance struct {
+	MemberID int            `json:"member_id"`
+	Name     string         `json:"name"`
+	Paid     expenses.Money `json:"paid"`
+	Owed     expenses.Money `json:"owed"`
+	Net      expenses.Money `json:"net"`
+}
+
+// Transfer settles part of a debt: From pays Amount to To.
+type Transfer struct {
+	From   int            `json:"from"`
+	To     int            `json:"to"`
+	Amount expenses.Money `json:"amount"`
+}
+
+type Balances struct {
+	GroupID   int        `json:"group_id"`
+	Currency  string     `json:"currency"`
+	Balances  []Balance  `json:"balances"`
+	Transfers []Transfer `json:"transfers"`
+}
+
+// balances adds up what every member paid and owes across the splits and
+// works out the transfers that settle the group.
+func balances(group Group, splits []Split) Balances {
+	index := make(map[int]int, len(group.Members))
+	result := Balances{GroupID: group.ID, Currency: group.Currency, Balances: make([]Balance, len(group.Members))}
+	for i, member := range group.Members {
+		index[member.ID] = i
+		result.Balances[i] = Balance{MemberID: member.ID, Name: member.Name}
+	}
+
+	unit := expenses.CurrencyUnit(group.Currency)
+	for _, split := range splits {
+		if i, ok := index[split.PayerID]; ok {
+			result.Balances[i].Paid += split.Expense.Amount
+		}
+
+		allocate(split.Expense.Amount, unit, split.Shares)
+		for _, share := range split.Shares {
+			if i, ok := index[share.MemberID]; ok {
+				result.Balances[i].Owed += share.Amount
+			}
+		}
+	}
+
+	for i := range result.Balances {
+		result.Balances[i].Net = result.Balances[i].Paid - result.Balances[i].Owed
+	}
+
+	result.Transfers = settle(result.Balances)
+	return result
+}
+
+// settle returns the fewest transfers that bring every net balance to zero.
+// Members whose balances add up to zero among themselves can settle apart
+// from everyone else, so the fewest transfers come from splitting the
+// members into as many zero-sum sets as possible: a set of k members needs
+// k-1 transfers.
+func settle(balances []Balance) []Transfer {
+	var open []Balance
+	for _, balance := range balances {
+		if balance.Net != 0 {
+			open = append(open, balance)
+		}
+	}
+
+	transfers := []Transfer{}
+	if len(open) > maxExactSettle {
+		return append(transfers, settleGreedy(open)...)
+	}
+
+	for _, set := range zeroSumSets(open) {
+		transfers = append(transfers, settleGreedy(set)...)
+	}
+
+	return transfers
+}
+
+// zeroSumSets partitions balances that add up to zero into the largest
+// number of sets that each add up to zero.
+func zeroSumSets(balances []Balance) [][]Balance {
+	n := len(balances)
+	full := 1<<n - 1
+	sums := make([]expenses.Money, full+1)
+	count := make([]int, full+1)
+	for mask := 1; mask <= full; mask++ {
+		for i := 0; i < n; i++ {
+			if mask&(1<<i) != 0 {
+				sums[mask] = sums[mask&^(1<<i)] + balances[i].Net
+				break
+			}
+		}
+
+		for i := 0; i < n; i++ {
+			if mask&(1<<i) != 0 && count[mask&^(1<<i)] > count[mask] {
+				count[mask] = count[mask&^(1<<i)]
+			}
+		}
+		if sums[mask] == 0 {
+			count[mask]++
+		}
+	}
+
+	// Walk back from the full set removing one member at a time along the
+	// best choices. Each time the remaining members add up to zero, the
+	// members removed since the last such point form a set of their own.
+	var sets [][]Balance
+	var set []Balance
+	for mask := full; mask != 0; {
+		best := -1
+		for i := 0; i < n; i++ {
+			if mask&(1<<i) != 0 && (best < 0 || count[mask&^(1<<i)] > count[mask&^(1<<best)]) {
+				best = i
+			}
+		}
+
+		set = append(set, balances[best])
+		mask &^= 1 << best
+		if sums[mask] == 0 {
+			sets = append(sets, set)
+			set = nil
+		}
+	}
+
+	return sets
+}
+
+// settleGreedy repeatedly lets the largest debtor pay the largest creditor.
+// Every transfer clears at least one of them.
+func settleGreedy(balances []Balance) []Transfer {
+	var debtors, creditors []Balance
+	for _, balance := range balances {
+		if balance.Net < 0 {
+			debtors = append(debtors, balance)
+		} else if balance.Net > 0 {
+			creditors = append(creditors, balance)
+		}
+	}
+
+	byAmount := func(list []Balance) {
+		sort.SliceStable(list, func(a, b int) bool {
+			return abs(list[a].Net) > abs(list[b].Net)
+		})
+	}
+	byAmount(debtors)
+	byAmount(creditors)
+
+	var transfers []Transfer
+	for d, c := 0, 0; d < len(debtors) && c < len(creditors); {
+		amount := -debtors[d].Net
+		if creditors[c].Net < amount {
+			amount = creditors[c].Net
+		}
+
+		transfers = append(transfers, Transfer{From: debtors[d].MemberID, To: creditors[c].MemberID, Amount: amount})
+		debtors[d].Net += amount
+		creditors[c].Net -= amount
+		if debtors[d].Net == 0 {
+			d++
+		}
+		if creditors[c].Net == 0 {
+			c++
+		}
+	}
+
+	return transfers
+}
+
+func abs(m expenses.Money) expenses.Money {
+	if m < 0 {
+		return -m
+	}
+
+	return m
+}
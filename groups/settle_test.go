@@ -0,0 +1,96 @@
+//go:build unit
+// +build unit
+
+package groups
+
+import (
+	"testing"
+
+	"github.com/tirathawat/assessment/expenses"
+)
+
+func TestSettle(t *testing.T) {
+	tests := []struct {
+		name          string
+		nets          []expenses.Money
+		wantTransfers int
+	}{
+		{
+			name:          "Should not transfer when everyone is even",
+			nets:          []expenses.Money{0, 0, 0},
+			wantTransfers: 0,
+		},
+		{
+			name:          "Should pay the creditor directly",
+			nets:          []expenses.Money{-5000, 5000},
+			wantTransfers: 1,
+		},
+		{
+			name:          "Should need one transfer less than the members with a balance",
+			nets:          []expenses.Money{-3000, -2000, 5000},
+			wantTransfers: 2,
+		},
+		{
+			name:          "Should settle pairs that cancel out on their own",
+			nets:          []expenses.Money{-700, -300, 400, 600, -600, 600},
+			wantTransfers: 4,
+		},
+		{
+			name:          "Should find zero-sum sets the greedy order misses",
+			nets:          []expenses.Money{-600, -400, 300, 300, 400},
+			wantTransfers: 3,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			balances := make([]Balance, len(test.nets))
+			for i, net := range test.nets {
+				balances[i] = Balance{MemberID: i + 1, Net: net}
+			}
+
+			transfers := settle(balances)
+			if len(transfers) != test.wantTransfers {
+				t.Errorf("unexpected transfers: got %v want %d", transfers, test.wantTransfers)
+			}
+
+			nets := make(map[int]expenses.Money, len(balances))
+			for _, balance := range balances {
+				nets[balance.MemberID] = balance.Net
+			}
+			for _, transfer := range transfers {
+				if transfer.Amount <= 0 {
+					t.Errorf("unexpected transfer amount: %v", transfer)
+				}
+				nets[transfer.From] += transfer.Amount
+				nets[transfer.To] -= transfer.Amount
+			}
+			for member, net := range nets {
+				if net != 0 {
+					t.Errorf("member %d is not settled: %v", member, net)
+				}
+			}
+		})
+	}
+}
+
+func TestBalances(t *testing.T) {
+	group := Group{ID: 1, Currency: "THB", Members: []Member{{ID: 1, Name: "Ann"}, {ID: 2, Name: "Ben"}, {ID: 3, Name: "Cat"}}}
+	splits := []Split{
+		{PayerID: 1, Method: SplitEqual, Expense: expenses.Expense{Amount: 30000}, Shares: []Share{{MemberID: 1, Weight: 1}, {MemberID: 2, Weight: 1}, {MemberID: 3, Weight: 1}}},
+		{PayerID: 2, Method: SplitExact, Expense: expenses.Expense{Amount: 6000}, Shares: []Share{{MemberID: 3, Weight: 6000}}},
+	}
+
+	got := balances(group, splits)
+
+	want := []expenses.Money{20000, -4000, -16000}
+	for i, balance := range got.Balances {
+		if balance.Net != want[i] {
+			t.Errorf("unexpected net for %s: got %v want %v", balance.Name, balance.Net, want[i])
+		}
+	}
+
+	if len(got.Transfers) != 2 {
+		t.Errorf("unexpected transfers: got %v want 2", got.Transfers)
+	}
+}
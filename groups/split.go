@@ -0,0 +1,137 @@
+package groups
+
+import (
+	"math"
+	"math/big"
+	"net/http"
+	"sort"
+
+	"github.com/tirathawat/assessment/errs"
+	"github.com/tirathawat/assessment/expenses"
+)
+
+const (
+	SplitEqual   = "equal"
+	SplitExact   = "exact"
+	SplitPercent = "percent"
+	SplitShares  = "shares"
+
+	// fullPercent is 100% in hundredths of a percent, the precision kept
+	// for percentages.
+	fullPercent = 100 * 100
+)
+
+var (
+	ErrSplitAmount    = errs.New(http.StatusBadRequest, "SPLIT_AMOUNT_NOT_POSITIVE", "group expenses must have a positive amount")
+	ErrSplitDuplicate = errs.New(http.StatusBadRequest, "SPLIT_DUPLICATE_MEMBER", "a member can appear only once in a split")
+	ErrSplitNegative  = errs.New(http.StatusBadRequest, "SPLIT_NEGATIVE", "split values cannot be negative")
+	ErrSplitEmpty     = errs.New(http.StatusBadRequest, "SPLIT_EMPTY", "split must give a share to at least one member")
+	ErrSplitTotal     = errs.New(http.StatusBadRequest, "SPLIT_TOTAL_MISMATCH", "split amounts must add up to the expense amount")
+	ErrSplitPercent   = errs.New(http.StatusBadRequest, "SPLIT_PERCENT_TOTAL", "split percentages must add up to 100")
+)
+
+type SplitRequest struct {
+	Method  string        `json:"method" binding:"required,oneof=equal exact percent shares"`
+	Members []SplitMember `json:"members" binding:"required,min=1,dive"`
+}
+
+// SplitMember is one member's part of a split. Only the value that
+// matches the method is read: none for equal, Amount for exact, Percent
+// for percent and Shares for shares.
+type SplitMember struct {
+	MemberID int            `json:"member_id" binding:"required"`
+	Amount   expenses.Money `json:"amount"`
+	Percent  float64        `json:"percent"`
+	Shares   int64          `json:"shares" binding:"max=1000000"`
+}
+
+// shares turns the request into weighted shares of amount. Exact amounts
+// must be whole multiples of unit, like the expense amount itself.
+func (r *SplitRequest) shares(amount, unit expenses.Money) ([]Share, error) {
+	if amount <= 0 {
+		return nil, ErrSplitAmount
+	}
+
+	seen := make(map[int]bool, len(r.Members))
+	shares := make([]Share, 0, len(r.Members))
+	var total int64
+	for _, member := range r.Members {
+		if seen[member.MemberID] {
+			return nil, ErrSplitDuplicate
+		}
+		seen[member.MemberID] = true
+
+		var weight int64
+		switch r.Method {
+		case SplitEqual:
+			weight = 1
+		case SplitExact:
+			if member.Amount%unit != 0 {
+				return nil, expenses.ErrAmountPrecision
+			}
+			weight = int64(member.Amount)
+		case SplitPercent:
+			weight = int64(math.Round(member.Percent * 100))
+		case SplitShares:
+			weight = member.Shares
+		}
+
+		if weight < 0 {
+			return nil, ErrSplitNegative
+		}
+
+		total += weight
+		shares = append(shares, Share{MemberID: member.MemberID, Weight: weight})
+	}
+
+	switch {
+	case r.Method == SplitExact && total != int64(amount):
+		return nil, ErrSplitTotal
+	case r.Method == SplitPercent && total != fullPercent:
+		return nil, ErrSplitPercent
+	case total == 0:
+		return nil, ErrSplitEmpty
+	}
+
+	return shares, nil
+}
+
+// allocate fills in the amount of every share in proportion to its weight.
+// Amounts are whole multiples of unit and always add up to amount: what is
+// left after rounding down goes to the shares with the largest remainders.
+func allocate(amount, unit expenses.Money, shares []Share) {
+	var total int64
+	for _, share := range shares {
+		total += share.Weight
+	}
+	if total == 0 {
+		return
+	}
+
+	units := big.NewInt(int64(amount / unit))
+	divisor := big.NewInt(total)
+	remainders := make([]*big.Int, len(shares))
+	left := int64(amount / unit)
+	for i := range shares {
+		q, r := new(big.Int).DivMod(new(big.Int).Mul(units, big.NewInt(shares[i].Weight)), divisor, new(big.Int))
+		shares[i].Amount = expenses.Money(q.Int64())
+		remainders[i] = r
+		left -= q.Int64()
+	}
+
+	order := make([]int, len(shares))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return remainders[order[a]].Cmp(remainders[order[b]]) > 0
+	})
+
+	for i := int64(0); i < left; i++ {
+		shares[order[i]].Amount++
+	}
+
+	for i := range shares {
+		shares[i].Amount *= unit
+	}
+}
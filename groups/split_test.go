@@ -0,0 +1,139 @@
+//go:build unit
+// +build unit
+
+package groups
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/tirathawat/assessment/expenses"
+)
+
+func TestShares(t *testing.T) {
+	tests := []struct {
+		name    string
+		split   SplitRequest
+		amount  expenses.Money
+		unit    expenses.Money
+		want    []expenses.Money
+		wantErr error
+	}{
+		{
+			name:   "Should split equally and give the leftover cent to the first members",
+			split:  SplitRequest{Method: SplitEqual, Members: []SplitMember{{MemberID: 1}, {MemberID: 2}, {MemberID: 3}}},
+			amount: 10000,
+			unit:   1,
+			want:   []expenses.Money{3334, 3333, 3333},
+		},
+		{
+			name:   "Should keep exact amounts",
+			split:  SplitRequest{Method: SplitExact, Members: []SplitMember{{MemberID: 1, Amount: 2550}, {MemberID: 2, Amount: 7450}}},
+			amount: 10000,
+			unit:   1,
+			want:   []expenses.Money{2550, 7450},
+		},
+		{
+			name:   "Should split by percentage",
+			split:  SplitRequest{Method: SplitPercent, Members: []SplitMember{{MemberID: 1, Percent: 33.33}, {MemberID: 2, Percent: 66.67}}},
+			amount: 999,
+			unit:   1,
+			want:   []expenses.Money{333, 666},
+		},
+		{
+			name:   "Should split by shares",
+			split:  SplitRequest{Method: SplitShares, Members: []SplitMember{{MemberID: 1, Shares: 1}, {MemberID: 2, Shares: 2}, {MemberID: 3, Shares: 0}}},
+			amount: 30000,
+			unit:   1,
+			want:   []expenses.Money{10000, 20000, 0},
+		},
+		{
+			name:   "Should split in whole units of zero decimal currencies",
+			split:  SplitRequest{Method: SplitEqual, Members: []SplitMember{{MemberID: 1}, {MemberID: 2}, {MemberID: 3}}},
+			amount: 100000,
+			unit:   100,
+			want:   []expenses.Money{33400, 33300, 33300},
+		},
+		{
+			name:    "Should return error when exact amounts do not add up",
+			split:   SplitRequest{Method: SplitExact, Members: []SplitMember{{MemberID: 1, Amount: 2550}, {MemberID: 2, Amount: 7000}}},
+			amount:  10000,
+			unit:    1,
+			wantErr: ErrSplitTotal,
+		},
+		{
+			name:    "Should return error when percentages do not add up to 100",
+			split:   SplitRequest{Method: SplitPercent, Members: []SplitMember{{MemberID: 1, Percent: 50}, {MemberID: 2, Percent: 49}}},
+			amount:  10000,
+			unit:    1,
+			wantErr: ErrSplitPercent,
+		},
+		{
+			name:    "Should return error when a member appears twice",
+			split:   SplitRequest{Method: SplitEqual, Members: []SplitMember{{MemberID: 1}, {MemberID: 1}}},
+			amount:  10000,
+			unit:    1,
+			wantErr: ErrSplitDuplicate,
+		},
+		{
+			name:    "Should return error when shares are negative",
+			split:   SplitRequest{Method: SplitShares, Members: []SplitMember{{MemberID: 1, Shares: -1}, {MemberID: 2, Shares: 2}}},
+			amount:  10000,
+			unit:    1,
+			wantErr: ErrSplitNegative,
+		},
+		{
+			name:    "Should return error when every share is zero",
+			split:   SplitRequest{Method: SplitShares, Members: []SplitMember{{MemberID: 1}, {MemberID: 2}}},
+			amount:  10000,
+			unit:    1,
+			wantErr: ErrSplitEmpty,
+		},
+		{
+			name:    "Should return error when exact amounts are finer than the currency",
+			split:   SplitRequest{Method: SplitExact, Members: []SplitMember{{MemberID: 1, Amount: 50050}, {MemberID: 2, Amount: 49950}}},
+			amount:  100000,
+			unit:    100,
+			wantErr: expenses.ErrAmountPrecision,
+		},
+		{
+			name:    "Should return error when the amount is not positive",
+			split:   SplitRequest{Method: SplitEqual, Members: []SplitMember{{MemberID: 1}}},
+			amount:  -100,
+			unit:    1,
+			wantErr: ErrSplitAmount,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			shares, err := test.split.shares(test.amount, test.unit)
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("unexpected error: got %v want %v", err, test.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			allocate(test.amount, test.unit, shares)
+			got := make([]expenses.Money, len(shares))
+			for i, share := range shares {
+				got[i] = share.Amount
+			}
+
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("unexpected amounts: got %v want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestAllocateFollowsAmount(t *testing.T) {
+	shares := []Share{{MemberID: 1, Weight: 2550}, {MemberID: 2, Weight: 7450}}
+	allocate(20000, 1, shares)
+
+	if shares[0].Amount != 5100 || shares[1].Amount != 14900 {
+		t.Errorf("unexpected amounts after the expense changed: got %v", shares)
+	}
+}
@@ -0,0 +1,87 @@
+package groups
+
+import (
+	"github.com/tirathawat/assessment/expenses"
+	"gorm.io/gorm"
+)
+
+type Store interface {
+	Create(group *Group) error
+	List(owner int) ([]Group, error)
+	// Get returns the owner's group with its members, or
+	// gorm.ErrRecordNotFound.
+	Get(owner, id int) (Group, error)
+	AddMember(member *Member) error
+	// AddExpense creates the expense and its split together, recording
+	// actor as the creator in the expense's history.
+	AddExpense(expense *expenses.Expense, split *Split, actor string) error
+	// Splits returns the splits of the group's expenses that are not in
+	// the trash, along with the expenses.
+	Splits(groupID int) ([]Split, error)
+}
+
+type store struct {
+	db *gorm.DB
+}
+
+func NewStore(db *gorm.DB) Store {
+	return &store{db}
+}
+
+func (s *store) Create(group *Group) error {
+	return s.db.Create(group).Error
+}
+
+func (s *store) List(owner int) ([]Group, error) {
+	groups := []Group{}
+	err := s.db.Preload("Members", orderByID).Where("owner_id = ?", owner).Order("id").Find(&groups).Error
+	return groups, err
+}
+
+func (s *store) Get(owner, id int) (Group, error) {
+	var group Group
+	err := s.db.Preload("Members", orderByID).Where("owner_id = ?", owner).First(&group, "id = ?", id).Error
+	return group, err
+}
+
+func (s *store) AddMember(member *Member) error {
+	return s.db.Create(member).Error
+}
+
+func (s *store) AddExpense(expense *expenses.Expense, split *Split, actor string) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(expense).Error; err != nil {
+			return err
+		}
+
+		history := expenses.NewHistory(expenses.ActionCreate, actor, nil, expense)
+		if err := tx.Create(&history).Error; err != nil {
+			return err
+		}
+
+		split.ExpenseID = expense.ID
+		for i := range split.Shares {
+			split.Shares[i].ExpenseID = expense.ID
+		}
+
+		return tx.Omit("Expense").Create(split).Error
+	})
+}
+
+func (s *store) Splits(groupID int) ([]Split, error) {
+	var splits []Split
+	err := s.db.Preload("Expense").Preload("Shares", orderByMember).
+		Where("group_id = ?", groupID).
+		Where("expense_id IN (?)", s.db.Model(&expenses.Expense{}).Select("id")).
+		Order("expense_id").
+		Find(&splits).Error
+	return splits, err
+}
+
+func orderByID(db *gorm.DB) *gorm.DB {
+	return db.Order("id")
+}
+
+func orderByMember(db *gorm.DB) *gorm.DB {
+	return db.Order("member_id")
+}
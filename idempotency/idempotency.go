@@ -0,0 +1,67 @@
+package idempotency
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/tirathawat/assessment/errs"
+)
+
+const Header = "Idempotency-Key"
+
+// maxKeyLength bounds the keys clients may send.
+const maxKeyLength = 255
+
+var (
+	ErrInvalidKey  = errs.New(http.StatusBadRequest, "INVALID_IDEMPOTENCY_KEY", "idempotency key must be between 1 and 255 characters")
+	ErrKeyReused   = errs.New(http.StatusUnprocessableEntity, "IDEMPOTENCY_KEY_REUSED", "idempotency key was already used with a different request")
+	ErrInProgress  = errs.New(http.StatusConflict, "IDEMPOTENCY_KEY_IN_PROGRESS", "a request with this idempotency key is still in progress")
+	ErrStoreFailed = errs.New(http.StatusInternalServerError, "IDEMPOTENCY_STORE_FAILED", "failed to store idempotency key")
+)
+
+// Record is the stored outcome of the first request sent with a key. A
+// record with a zero StatusCode is still being processed.
+type Record struct {
+	Key         string      `gorm:"primaryKey;type:text"`
+	Fingerprint string      `gorm:"type:char(64);not null"`
+	StatusCode  int         `gorm:"not null;default:0"`
+	Header      http.Header `gorm:"serializer:json"`
+	Body        []byte
+	CreatedAt   time.Time `gorm:"not null;default:CURRENT_TIMESTAMP"`
+	ExpiresAt   time.Time `gorm:"not null;index"`
+}
+
+func (Record) TableName() string {
+	return "idempotency_keys"
+}
+
+// scopedKey keeps the keys of different users apart so one user can never
+// replay the response stored for another. The subject is length-prefixed,
+// as either part may contain the separator.
+func scopedKey(subject, key string) string {
+	return strconv.Itoa(len(subject)) + ":" + subject + ":" + key
+}
+
+func (r *Record) completed() bool {
+	return r.StatusCode != 0
+}
+
+// fingerprint identifies a request by what it asks the server to do, so a
+// retry matches only when it targets the same route with the same query,
+// such as dry_run on an import, and the same body in the same media type.
+func fingerprint(method, path, query, contentType string, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(method))
+	hash.Write([]byte{0})
+	hash.Write([]byte(path))
+	hash.Write([]byte{0})
+	hash.Write([]byte(query))
+	hash.Write([]byte{0})
+	hash.Write([]byte(contentType))
+	hash.Write([]byte{0})
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
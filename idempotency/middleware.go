@@ -0,0 +1,143 @@
+package idempotency
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tirathawat/assessment/errs"
+	"github.com/tirathawat/assessment/logs"
+	"github.com/tirathawat/assessment/middleware"
+)
+
+// ReplayedHeader marks responses that were served from a stored record.
+const ReplayedHeader = "Idempotent-Replayed"
+
+// replayedHeaders are the response headers worth keeping for a replay.
+var replayedHeaders = []string{"Content-Type", "Location", "ETag", "Link"}
+
+// Middleware makes requests carrying an Idempotency-Key safe to retry. The
+// first response for a key is stored for ttl and replayed for every retry
+// with the same body. Keys are scoped to the authenticated subject, so the
+// middleware must run after middleware.Auth. Requests without the header
+// are passed through.
+func Middleware(store Store, ttl time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(Header)
+		if key == "" {
+			c.Next()
+			return
+		}
+
+		if len(key) > maxKeyLength {
+			logs.Error().Context(c.Request.Context()).Err(ErrInvalidKey).Msgf("idempotency key too long: %d", len(key))
+			errs.Abort(c, http.StatusBadRequest, ErrInvalidKey)
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			logs.Error().Context(c.Request.Context()).Err(err).Msg("failed to read request body")
+			errs.Abort(c, http.StatusBadRequest, err)
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		now := time.Now()
+		record := Record{
+			Key:         scopedKey(middleware.Subject(c), key),
+			Fingerprint: fingerprint(c.Request.Method, c.Request.URL.Path, c.Request.URL.RawQuery, c.ContentType(), body),
+			ExpiresAt:   now.Add(ttl),
+		}
+
+		existing, reserved, err := store.Reserve(record, now)
+		if err != nil {
+			logs.Error().Context(c.Request.Context()).Err(err).Msgf("failed to reserve idempotency key: %s", key)
+			errs.Abort(c, http.StatusInternalServerError, ErrStoreFailed)
+			return
+		}
+
+		if !reserved {
+			replay(c, existing, record.Fingerprint)
+			return
+		}
+
+		release := func() {
+			if err := store.Release(record.Key); err != nil {
+				logs.Error().Context(c.Request.Context()).Err(err).Msgf("failed to release idempotency key: %s", key)
+			}
+		}
+
+		// A panicking handler must not leave the key reserved until it
+		// expires.
+		defer func() {
+			if r := recover(); r != nil {
+				release()
+				panic(r)
+			}
+		}()
+
+		writer := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+
+		// Server errors are not stored so the client can retry them.
+		if writer.Status() >= http.StatusInternalServerError {
+			release()
+			return
+		}
+
+		record.StatusCode = writer.Status()
+		record.Header = http.Header{}
+		for _, name := range replayedHeaders {
+			if value := writer.Header().Get(name); value != "" {
+				record.Header.Set(name, value)
+			}
+		}
+		record.Body = writer.body.Bytes()
+
+		if err := store.Complete(record); err != nil {
+			logs.Error().Context(c.Request.Context()).Err(err).Msgf("failed to store response for idempotency key: %s", key)
+		}
+	}
+}
+
+func replay(c *gin.Context, existing *Record, fingerprint string) {
+	switch {
+	case existing.Fingerprint != fingerprint:
+		logs.Error().Context(c.Request.Context()).Err(ErrKeyReused).Msgf("idempotency key reused: %s", existing.Key)
+		errs.Abort(c, http.StatusUnprocessableEntity, ErrKeyReused)
+	case !existing.completed():
+		logs.Error().Context(c.Request.Context()).Err(ErrInProgress).Msgf("idempotency key in progress: %s", existing.Key)
+		errs.Abort(c, http.StatusConflict, ErrInProgress)
+	default:
+		for name, values := range existing.Header {
+			for _, value := range values {
+				c.Writer.Header().Add(name, value)
+			}
+		}
+		c.Header(ReplayedHeader, "true")
+		c.Status(existing.StatusCode)
+		c.Writer.Write(existing.Body)
+		c.Abort()
+	}
+}
+
+// responseRecorder keeps a copy of the body while it is written to the
+// client.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
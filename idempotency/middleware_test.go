@@ -0,0 +1,272 @@
+//go:build unit
+// +build unit
+
+package idempotency_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tirathawat/assessment/idempotency"
+	"github.com/tirathawat/assessment/middleware"
+)
+
+type MockStore struct {
+	records map[string]idempotency.Record
+	err     error
+	pending bool
+}
+
+func (m *MockStore) Reserve(record idempotency.Record, now time.Time) (*idempotency.Record, bool, error) {
+	if m.err != nil {
+		return nil, false, m.err
+	}
+
+	if existing, ok := m.records[record.Key]; ok && existing.ExpiresAt.After(now) {
+		return &existing, false, nil
+	}
+
+	m.records[record.Key] = record
+	return nil, true, nil
+}
+
+func (m *MockStore) Complete(record idempotency.Record) error {
+	if m.pending {
+		return nil
+	}
+
+	m.records[record.Key] = record
+	return nil
+}
+
+func (m *MockStore) Release(key string) error {
+	delete(m.records, key)
+	return nil
+}
+
+type request struct {
+	target      string
+	contentType string
+	key         string
+	body        string
+	subject     string
+}
+
+func TestMiddleware(t *testing.T) {
+	tests := []struct {
+		name           string
+		store          *MockStore
+		requests       []request
+		status         int
+		wantStatusCode []int
+		wantCalls      int
+		wantReplayed   bool
+	}{
+		{
+			name:           "Should pass requests without a key through",
+			requests:       []request{{body: `{"a":1}`}, {body: `{"a":1}`}},
+			status:         http.StatusCreated,
+			wantStatusCode: []int{http.StatusCreated, http.StatusCreated},
+			wantCalls:      2,
+		},
+		{
+			name:           "Should replay the stored response on retry",
+			requests:       []request{{key: "k1", body: `{"a":1}`}, {key: "k1", body: `{"a":1}`}},
+			status:         http.StatusCreated,
+			wantStatusCode: []int{http.StatusCreated, http.StatusCreated},
+			wantCalls:      1,
+			wantReplayed:   true,
+		},
+		{
+			name:           "Should replay client errors on retry",
+			requests:       []request{{key: "k1", body: `{}`}, {key: "k1", body: `{}`}},
+			status:         http.StatusBadRequest,
+			wantStatusCode: []int{http.StatusBadRequest, http.StatusBadRequest},
+			wantCalls:      1,
+			wantReplayed:   true,
+		},
+		{
+			name:           "Should keep the keys of different users apart",
+			requests:       []request{{key: "k1", body: `{"a":1}`, subject: "alice"}, {key: "k1", body: `{"a":1}`, subject: "bob"}},
+			status:         http.StatusCreated,
+			wantStatusCode: []int{http.StatusCreated, http.StatusCreated},
+			wantCalls:      2,
+		},
+		{
+			name:           "Should not mix up subjects and keys containing the separator",
+			requests:       []request{{key: "c", body: `{"a":1}`, subject: "a:b"}, {key: "b:c", body: `{"a":1}`, subject: "a"}},
+			status:         http.StatusCreated,
+			wantStatusCode: []int{http.StatusCreated, http.StatusCreated},
+			wantCalls:      2,
+		},
+		{
+			name:           "Should return 422 when a key is reused with a different body",
+			requests:       []request{{key: "k1", body: `{"a":1}`}, {key: "k1", body: `{"a":2}`}},
+			status:         http.StatusCreated,
+			wantStatusCode: []int{http.StatusCreated, http.StatusUnprocessableEntity},
+			wantCalls:      1,
+		},
+		{
+			name: "Should return 422 when a key is reused for a real import after a dry run",
+			requests: []request{
+				{target: "/expenses/import?dry_run=true", contentType: "text/csv", key: "k1", body: "title,amount,note,tags\n"},
+				{target: "/expenses/import", contentType: "text/csv", key: "k1", body: "title,amount,note,tags\n"},
+			},
+			status:         http.StatusOK,
+			wantStatusCode: []int{http.StatusOK, http.StatusUnprocessableEntity},
+			wantCalls:      1,
+		},
+		{
+			name: "Should return 422 when a key is reused with a different content type",
+			requests: []request{
+				{target: "/expenses/import", contentType: "text/csv", key: "k1", body: "{}"},
+				{target: "/expenses/import", contentType: "application/x-ndjson", key: "k1", body: "{}"},
+			},
+			status:         http.StatusOK,
+			wantStatusCode: []int{http.StatusOK, http.StatusUnprocessableEntity},
+			wantCalls:      1,
+		},
+		{
+			name: "Should replay when only the content type parameters differ",
+			requests: []request{
+				{target: "/expenses/import", contentType: "text/csv", key: "k1", body: "{}"},
+				{target: "/expenses/import", contentType: "text/csv; charset=utf-8", key: "k1", body: "{}"},
+			},
+			status:         http.StatusOK,
+			wantStatusCode: []int{http.StatusOK, http.StatusOK},
+			wantCalls:      1,
+			wantReplayed:   true,
+		},
+		{
+			name:           "Should return 409 when the first request is still in progress",
+			store:          &MockStore{records: map[string]idempotency.Record{}, pending: true},
+			requests:       []request{{key: "k1", body: `{"a":1}`}, {key: "k1", body: `{"a":1}`}},
+			status:         http.StatusCreated,
+			wantStatusCode: []int{http.StatusCreated, http.StatusConflict},
+			wantCalls:      1,
+		},
+		{
+			name:           "Should not store server errors",
+			requests:       []request{{key: "k1", body: `{"a":1}`}, {key: "k1", body: `{"a":1}`}},
+			status:         http.StatusInternalServerError,
+			wantStatusCode: []int{http.StatusInternalServerError, http.StatusInternalServerError},
+			wantCalls:      2,
+		},
+		{
+			name:           "Should return 400 when the key is too long",
+			requests:       []request{{key: strings.Repeat("k", 256), body: `{"a":1}`}},
+			status:         http.StatusCreated,
+			wantStatusCode: []int{http.StatusBadRequest},
+		},
+		{
+			name:           "Should return 500 when the store fails",
+			store:          &MockStore{err: errors.New("error")},
+			requests:       []request{{key: "k1", body: `{"a":1}`}},
+			status:         http.StatusCreated,
+			wantStatusCode: []int{http.StatusInternalServerError},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			store := test.store
+			if store == nil {
+				store = &MockStore{records: map[string]idempotency.Record{}}
+			}
+
+			calls := 0
+			r := gin.New()
+			auth := func(c *gin.Context) {
+				subject := c.GetHeader("X-Subject")
+				if subject == "" {
+					subject = "alice"
+				}
+				c.Set(middleware.SubjectKey, subject)
+			}
+			handler := func(c *gin.Context) {
+				calls++
+				c.Header("ETag", `"1"`)
+				c.JSON(test.status, gin.H{"id": calls})
+			}
+			r.POST("/expenses", auth, idempotency.Middleware(store, time.Hour), handler)
+			r.POST("/expenses/import", auth, idempotency.Middleware(store, time.Hour), handler)
+
+			var bodies []string
+			var last *httptest.ResponseRecorder
+			for i, req := range test.requests {
+				target := req.target
+				if target == "" {
+					target = "/expenses"
+				}
+				request := httptest.NewRequest(http.MethodPost, target, strings.NewReader(req.body))
+				if req.contentType != "" {
+					request.Header.Set("Content-Type", req.contentType)
+				}
+				if req.key != "" {
+					request.Header.Set(idempotency.Header, req.key)
+				}
+				request.Header.Set("X-Subject", req.subject)
+
+				last = httptest.NewRecorder()
+				r.ServeHTTP(last, request)
+				if last.Code != test.wantStatusCode[i] {
+					t.Errorf("unexpected status code for request %d: got %v want %v", i, last.Code, test.wantStatusCode[i])
+				}
+				bodies = append(bodies, last.Body.String())
+			}
+
+			if calls != test.wantCalls {
+				t.Errorf("unexpected handler calls: got %v want %v", calls, test.wantCalls)
+			}
+
+			if replayed := last.Header().Get(idempotency.ReplayedHeader) == "true"; replayed != test.wantReplayed {
+				t.Errorf("unexpected replayed header: got %v want %v", replayed, test.wantReplayed)
+			}
+
+			if test.wantReplayed {
+				if bodies[0] != bodies[1] || last.Header().Get("ETag") != `"1"` {
+					t.Errorf("unexpected replayed response: got %s %v want %s", bodies[1], last.Header(), bodies[0])
+				}
+			}
+		})
+	}
+}
+
+func TestMiddlewarePanic(t *testing.T) {
+	store := &MockStore{records: map[string]idempotency.Record{}}
+
+	calls := 0
+	r := gin.New()
+	r.Use(gin.RecoveryWithWriter(io.Discard))
+	r.POST("/expenses", func(c *gin.Context) {
+		c.Set(middleware.SubjectKey, "alice")
+	}, idempotency.Middleware(store, time.Hour), func(c *gin.Context) {
+		calls++
+		if calls == 1 {
+			panic("boom")
+		}
+		c.JSON(http.StatusCreated, gin.H{"id": calls})
+	})
+
+	wantStatusCode := []int{http.StatusInternalServerError, http.StatusCreated}
+	for i, want := range wantStatusCode {
+		request := httptest.NewRequest(http.MethodPost, "/expenses", strings.NewReader(`{"a":1}`))
+		request.Header.Set(idempotency.Header, "k1")
+
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, request)
+		if resp.Code != want {
+			t.Errorf("unexpected status code for request %d: got %v want %v", i, resp.Code, want)
+		}
+	}
+
+	if calls != 2 {
+		t.Errorf("unexpected handler calls: got %v want 2", calls)
+	}
+}
@@ -0,0 +1,61 @@
+package idempotency
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Store interface {
+	// Reserve records a new key. When the key is already taken the existing
+	// record is returned with reserved set to false.
+	Reserve(record Record, now time.Time) (existing *Record, reserved bool, err error)
+	Complete(record Record) error
+	Release(key string) error
+}
+
+type store struct {
+	db *gorm.DB
+}
+
+func NewStore(db *gorm.DB) Store {
+	return &store{db}
+}
+
+func (s *store) Reserve(record Record, now time.Time) (*Record, bool, error) {
+	var existing *Record
+	reserved := false
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		// Expired keys are removed here rather than by a background job so
+		// they never block a new request.
+		if err := tx.Where("expires_at <= ?", now).Delete(&Record{}).Error; err != nil {
+			return err
+		}
+
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&record)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 1 {
+			reserved = true
+			return nil
+		}
+
+		existing = &Record{}
+		return tx.First(existing, "key = ?", record.Key).Error
+	})
+
+	return existing, reserved, err
+}
+
+func (s *store) Complete(record Record) error {
+	return s.db.Model(&Record{Key: record.Key}).
+		Select("status_code", "header", "body").
+		Updates(&record).Error
+}
+
+func (s *store) Release(key string) error {
+	return s.db.Delete(&Record{Key: key}).Error
+}
@@ -0,0 +1,33 @@
+package logs
+
+import "context"
+
+// Keys of the values that middleware adds to the context of a request.
+const (
+	RequestIDKey = "request_id"
+	UserIDKey    = "user_id"
+)
+
+type valuesKey struct{}
+
+// WithValue returns a copy of ctx carrying the value under key. Events
+// given the context, or one derived from it, log the value as a field.
+func WithValue(ctx context.Context, key string, value interface{}) context.Context {
+	parent := values(ctx)
+	merged := make(map[string]interface{}, len(parent)+1)
+	for k, v := range parent {
+		merged[k] = v
+	}
+	merged[key] = value
+
+	return context.WithValue(ctx, valuesKey{}, merged)
+}
+
+func values(ctx context.Context) map[string]interface{} {
+	if ctx == nil {
+		return nil
+	}
+
+	v, _ := ctx.Value(valuesKey{}).(map[string]interface{})
+	return v
+}
@@ -3,6 +3,7 @@ package logs
 import (
 	"context"
 	"fmt"
+	"path/filepath"
 	"runtime"
 	"time"
 
@@ -51,26 +52,44 @@ func (event *Event) Msgf(msg string, a ...interface{}) {
 	event.flush()
 }
 
+// flush writes the event with its fields, error and context values as
+// top-level keys, and the nested content object when the logger has it.
 func (event Event) flush() {
 	if event.logger == nil {
 		return
 	}
 
-	formattedMsg := event.message
-	if event.err != nil {
-		formattedMsg = event.err.Error() + ": " + formattedMsg
+	fields := map[string]interface{}{}
+	for key, value := range values(event.ctx) {
+		fields[key] = value
+	}
+	for key, value := range event.fields {
+		fields[key] = value
 	}
 
-	event.logger.logger.WithLevel(event.level).Fields(map[string]interface{}{
-		"timestamp": zerolog.TimestampFunc().UTC().Format(time.RFC3339),
-		"content": map[string]interface{}{
-			"level":      event.level.String(),
-			"message":    formattedMsg,
-			"filename":   event.filename,
-			"linenumber": event.lineNumber,
-		},
-	}).Msg(formattedMsg)
+	e := event.logger.logger.WithLevel(event.level).
+		Str("timestamp", zerolog.TimestampFunc().UTC().Format(time.RFC3339)).
+		Str("caller", fmt.Sprintf("%s:%d", filepath.Base(event.filename), event.lineNumber)).
+		Fields(fields).
+		Err(event.err)
+
+	if event.logger.content {
+		formattedMsg := event.message
+		if event.err != nil {
+			formattedMsg = event.err.Error() + ": " + formattedMsg
+		}
+
+		e = e.Fields(map[string]interface{}{
+			"content": map[string]interface{}{
+				"level":      event.level.String(),
+				"message":    formattedMsg,
+				"filename":   event.filename,
+				"linenumber": event.lineNumber,
+			},
+		})
+	}
 
+	e.Msg(event.message)
 }
 
 func Debug() *Event {
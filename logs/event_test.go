@@ -0,0 +1,110 @@
+//go:build unit
+// +build unit
+
+package logs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+func capture(t *testing.T, content bool, log func()) map[string]interface{} {
+	t.Helper()
+
+	var buf bytes.Buffer
+	saved := logs
+	logs = &Logger{content: content, logger: zerolog.New(&buf)}
+	defer func() { logs = saved }()
+
+	log()
+
+	var got map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("unmarshal %q: %v", buf.String(), err)
+	}
+
+	return got
+}
+
+func TestFlush(t *testing.T) {
+	ctx := WithValue(context.Background(), RequestIDKey, "req-1")
+	ctx = WithValue(ctx, UserIDKey, 7)
+
+	got := capture(t, false, func() {
+		Error().Context(ctx).Err(errors.New("boom")).Value("expense_id", 3).Msgf("failed to get expense: %d", 3)
+	})
+
+	want := map[string]interface{}{
+		"level":      "error",
+		"message":    "failed to get expense: 3",
+		"error":      "boom",
+		"expense_id": float64(3),
+		"request_id": "req-1",
+		"user_id":    float64(7),
+	}
+	for key, value := range want {
+		if got[key] != value {
+			t.Errorf("%s = %v, want %v", key, got[key], value)
+		}
+	}
+	for _, key := range []string{"timestamp", "caller"} {
+		if _, ok := got[key]; !ok {
+			t.Errorf("%s is missing", key)
+		}
+	}
+	if _, ok := got["content"]; ok {
+		t.Errorf("content = %v, want none", got["content"])
+	}
+}
+
+func TestFlushContent(t *testing.T) {
+	got := capture(t, true, func() {
+		Info().Err(errors.New("boom")).Msg("done")
+	})
+
+	content, ok := got["content"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("content = %v, want an object", got["content"])
+	}
+	if content["message"] != "boom: done" {
+		t.Errorf("content message = %v, want %q", content["message"], "boom: done")
+	}
+	if content["level"] != "info" {
+		t.Errorf("content level = %v, want %q", content["level"], "info")
+	}
+	if got["message"] != "done" {
+		t.Errorf("message = %v, want %q", got["message"], "done")
+	}
+}
+
+func TestFlushFieldsOverrideContext(t *testing.T) {
+	ctx := WithValue(context.Background(), RequestIDKey, "req-1")
+
+	got := capture(t, false, func() {
+		Info().Context(ctx).Value(RequestIDKey, "req-2").Msg("done")
+	})
+
+	if got[RequestIDKey] != "req-2" {
+		t.Errorf("request_id = %v, want %q", got[RequestIDKey], "req-2")
+	}
+	if _, ok := got["error"]; ok {
+		t.Errorf("error = %v, want none", got["error"])
+	}
+}
+
+func TestWithValueCopies(t *testing.T) {
+	parent := WithValue(context.Background(), RequestIDKey, "req-1")
+	child := WithValue(parent, UserIDKey, 7)
+
+	if _, ok := values(parent)[UserIDKey]; ok {
+		t.Error("parent context gained the value of its child")
+	}
+	if values(child)[RequestIDKey] != "req-1" {
+		t.Errorf("child request_id = %v, want %q", values(child)[RequestIDKey], "req-1")
+	}
+}
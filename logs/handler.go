@@ -0,0 +1,47 @@
+package logs
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tirathawat/assessment/errs"
+)
+
+// LevelBody is the body of the log level endpoints.
+type LevelBody struct {
+	Level string `json:"level" binding:"required"`
+}
+
+type Handler interface {
+	Level(c *gin.Context)
+	SetLevel(c *gin.Context)
+}
+
+type handler struct{}
+
+func NewHandler() Handler {
+	return &handler{}
+}
+
+func (h *handler) Level(c *gin.Context) {
+	c.JSON(http.StatusOK, LevelBody{Level: Level()})
+}
+
+func (h *handler) SetLevel(c *gin.Context) {
+	var body LevelBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		Error().Context(c.Request.Context()).Err(err).Msg("failed to bind request body")
+		errs.JSON(c, http.StatusBadRequest, err)
+		return
+	}
+
+	previous := Level()
+	if err := SetLevel(body.Level); err != nil {
+		Error().Context(c.Request.Context()).Err(err).Msg("failed to change log level")
+		errs.JSON(c, http.StatusBadRequest, err)
+		return
+	}
+
+	Warn().Context(c.Request.Context()).Value("previous", previous).Msgf("log level changed to %s", Level())
+	c.JSON(http.StatusOK, LevelBody{Level: Level()})
+}
@@ -0,0 +1,96 @@
+//go:build unit
+// +build unit
+
+package logs_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tirathawat/assessment/errs"
+	"github.com/tirathawat/assessment/logs"
+)
+
+func TestSetLevel(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		wantStatusCode int
+		wantLevel      string
+		wantCode       string
+	}{
+		{
+			name:           "Should change the level",
+			body:           `{"level":"debug"}`,
+			wantStatusCode: http.StatusOK,
+			wantLevel:      "debug",
+		},
+		{
+			name:           "Should accept a level in upper case",
+			body:           `{"level":"WARN"}`,
+			wantStatusCode: http.StatusOK,
+			wantLevel:      "warn",
+		},
+		{
+			name:           "Should return 400 for an unknown level",
+			body:           `{"level":"verbose"}`,
+			wantStatusCode: http.StatusBadRequest,
+			wantLevel:      "info",
+			wantCode:       "INVALID_LOG_LEVEL",
+		},
+		{
+			name:           "Should return 400 for a level that silences errors",
+			body:           `{"level":"panic"}`,
+			wantStatusCode: http.StatusBadRequest,
+			wantLevel:      "info",
+			wantCode:       "INVALID_LOG_LEVEL",
+		},
+		{
+			name:           "Should return 400 without a level",
+			body:           `{}`,
+			wantStatusCode: http.StatusBadRequest,
+			wantLevel:      "info",
+			wantCode:       errs.CodeValidationFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs.SetLevel("info")
+			defer logs.SetLevel("info")
+
+			gin.SetMode(gin.TestMode)
+			r := gin.New()
+			h := logs.NewHandler()
+			r.GET("/admin/log-level", h.Level)
+			r.PUT("/admin/log-level", h.SetLevel)
+
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/admin/log-level", strings.NewReader(tt.body)))
+
+			if rec.Code != tt.wantStatusCode {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatusCode, rec.Body)
+			}
+			if tt.wantCode != "" {
+				var problem errs.Problem
+				json.Unmarshal(rec.Body.Bytes(), &problem)
+				if problem.Code != tt.wantCode {
+					t.Errorf("code = %q, want %q", problem.Code, tt.wantCode)
+				}
+			}
+
+			rec = httptest.NewRecorder()
+			r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/log-level", nil))
+
+			var got logs.LevelBody
+			json.Unmarshal(rec.Body.Bytes(), &got)
+			if got.Level != tt.wantLevel {
+				t.Errorf("level = %q, want %q", got.Level, tt.wantLevel)
+			}
+		})
+	}
+}
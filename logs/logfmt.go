@@ -0,0 +1,91 @@
+package logs
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// leadingKeys are written first by logfmtWriter, in this order.
+var leadingKeys = []string{"timestamp", "level", "message"}
+
+// logfmtWriter rewrites each JSON line zerolog writes as key=value pairs.
+// Objects and arrays are written as quoted JSON.
+type logfmtWriter struct {
+	out io.Writer
+}
+
+func (w logfmtWriter) Write(p []byte) (int, error) {
+	decoder := json.NewDecoder(bytes.NewReader(p))
+	decoder.UseNumber()
+
+	var entry map[string]interface{}
+	if err := decoder.Decode(&entry); err != nil {
+		return 0, err
+	}
+
+	keys := make([]string, 0, len(entry))
+	for key := range entry {
+		if !isLeadingKey(key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var buf bytes.Buffer
+	for _, key := range append(leadingKeys, keys...) {
+		value, ok := entry[key]
+		if !ok {
+			continue
+		}
+		if buf.Len() > 0 {
+			buf.WriteByte(' ')
+		}
+		buf.WriteString(key)
+		buf.WriteByte('=')
+		buf.WriteString(logfmtValue(value))
+	}
+	buf.WriteByte('\n')
+
+	if _, err := w.out.Write(buf.Bytes()); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+func isLeadingKey(key string) bool {
+	for _, leading := range leadingKeys {
+		if key == leading {
+			return true
+		}
+	}
+
+	return false
+}
+
+func logfmtValue(value interface{}) string {
+	var s string
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		s = v
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		b, _ := json.Marshal(v)
+		s = string(b)
+	}
+
+	if s == "" || strings.ContainsAny(s, " =\"\\") || strings.IndexFunc(s, func(r rune) bool { return r < ' ' }) >= 0 {
+		return strconv.Quote(s)
+	}
+
+	return s
+}
@@ -0,0 +1,49 @@
+//go:build unit
+// +build unit
+
+package logs
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestLogfmtWriter(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "Should write the leading keys first and the rest sorted",
+			input: `{"user_id":7,"message":"done","level":"info","caller":"handler.go:12","timestamp":"2026-01-01T00:00:00Z"}`,
+			want:  "timestamp=2026-01-01T00:00:00Z level=info message=done caller=handler.go:12 user_id=7\n",
+		},
+		{
+			name:  "Should quote values with spaces, quotes and newlines",
+			input: `{"level":"error","message":"failed to get expense","error":"say \"hi\"\n"}`,
+			want:  `level=error message="failed to get expense" error="say \"hi\"\n"` + "\n",
+		},
+		{
+			name:  "Should write objects as quoted JSON",
+			input: `{"level":"info","content":{"linenumber":3},"ok":true,"empty":"","none":null}`,
+			want:  `level=info content="{\"linenumber\":3}" empty="" none=null ok=true` + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			n, err := logfmtWriter{&buf}.Write([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("write: %v", err)
+			}
+			if n != len(tt.input) {
+				t.Errorf("n = %d, want %d", n, len(tt.input))
+			}
+			if buf.String() != tt.want {
+				t.Errorf("got %q, want %q", buf.String(), tt.want)
+			}
+		})
+	}
+}
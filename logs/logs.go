@@ -1,35 +1,132 @@
 package logs
 
 import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/rs/zerolog"
+	"github.com/tirathawat/assessment/config"
+	"github.com/tirathawat/assessment/errs"
+)
+
+const (
+	FormatJSON    = "json"
+	FormatConsole = "console"
+	FormatLogfmt  = "logfmt"
+)
+
+const (
+	OutputStdout = "stdout"
+	OutputStderr = "stderr"
+	OutputFile   = "file"
+)
+
+var (
+	ErrInvalidLevel  = errs.New(http.StatusBadRequest, "INVALID_LOG_LEVEL", "invalid log level")
+	ErrInvalidFormat = errors.New("invalid log format")
+	ErrInvalidOutput = errors.New("invalid log output")
 )
 
 type Logger struct {
-	debugMode bool
-	logger    zerolog.Logger
+	content bool
+	logger  zerolog.Logger
+	output  io.Closer
 }
 
-func (l *Logger) createLogger() {
-	var logger zerolog.Logger
-	if l.debugMode {
-		logger = zerolog.New(zerolog.ConsoleWriter{Out: os.Stderr, TimeFormat: time.Stamp})
-	} else {
-		logger = zerolog.New(os.Stdout).With().Logger()
+// createLogger writes to w in format. The level is kept globally so it can
+// be changed while the logger is in use.
+func (l *Logger) createLogger(w io.Writer, format string) error {
+	switch format {
+	case FormatJSON:
+		l.logger = zerolog.New(w)
+	case FormatConsole:
+		_, file := w.(*rotatingFile)
+		l.logger = zerolog.New(zerolog.ConsoleWriter{Out: w, TimeFormat: time.Stamp, NoColor: file})
+	case FormatLogfmt:
+		l.logger = zerolog.New(logfmtWriter{w})
+	default:
+		return fmt.Errorf("%w: %s", ErrInvalidFormat, format)
 	}
-	logger.Level(zerolog.InfoLevel)
-	l.logger = logger
+
+	return nil
 }
 
 var logs *Logger
 
 func init() {
-	logs = &Logger{}
-	logs.createLogger()
+	logs = &Logger{content: true}
+	logs.createLogger(os.Stdout, FormatJSON)
+	zerolog.SetGlobalLevel(zerolog.InfoLevel)
+}
+
+// Setup configures the level, format and output of the logs from cfg.
+func Setup(cfg *config.AppConfig) error {
+	level, err := parseLevel(cfg.LogLevel)
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer
+	var output io.Closer
+	switch cfg.LogOutput {
+	case OutputStdout:
+		w = os.Stdout
+	case OutputStderr:
+		w = os.Stderr
+	case OutputFile:
+		file, err := openRotatingFile(cfg.LogFile, cfg.LogMaxSize, cfg.LogMaxAge, cfg.LogMaxBackups)
+		if err != nil {
+			return err
+		}
+		w, output = file, file
+	default:
+		return fmt.Errorf("%w: %s", ErrInvalidOutput, cfg.LogOutput)
+	}
+
+	logger := &Logger{content: cfg.LogContent, output: output}
+	if err := logger.createLogger(w, cfg.LogFormat); err != nil {
+		if output != nil {
+			output.Close()
+		}
+		return err
+	}
+
+	if logs.output != nil {
+		logs.output.Close()
+	}
+	logs = logger
+	zerolog.SetGlobalLevel(level)
+	return nil
+}
+
+// Level returns the lowest level logged.
+func Level() string {
+	return zerolog.GlobalLevel().String()
 }
 
-func Setup() {
-	logs.createLogger()
+// SetLevel changes the lowest level logged, such as debug or warn.
+func SetLevel(level string) error {
+	l, err := parseLevel(level)
+	if err != nil {
+		return err
+	}
+
+	zerolog.SetGlobalLevel(l)
+	return nil
+}
+
+// parseLevel accepts debug, info, warn and error. Higher levels would
+// silence the errors the application logs, so they are refused.
+func parseLevel(level string) (zerolog.Level, error) {
+	l, err := zerolog.ParseLevel(strings.ToLower(level))
+	if err != nil || l < zerolog.DebugLevel || l > zerolog.ErrorLevel {
+		return zerolog.NoLevel, fmt.Errorf("%w: %s", ErrInvalidLevel, level)
+	}
+
+	return l, nil
 }
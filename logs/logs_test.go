@@ -0,0 +1,86 @@
+//go:build unit
+// +build unit
+
+package logs
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tirathawat/assessment/config"
+)
+
+func TestSetup(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     config.AppConfig
+		wantErr error
+	}{
+		{
+			name:    "Should reject an unknown level",
+			cfg:     config.AppConfig{LogLevel: "verbose", LogFormat: FormatJSON, LogOutput: OutputStdout},
+			wantErr: ErrInvalidLevel,
+		},
+		{
+			name:    "Should reject an unknown format",
+			cfg:     config.AppConfig{LogLevel: "info", LogFormat: "xml", LogOutput: OutputStdout},
+			wantErr: ErrInvalidFormat,
+		},
+		{
+			name:    "Should reject an unknown output",
+			cfg:     config.AppConfig{LogLevel: "info", LogFormat: FormatJSON, LogOutput: "syslog"},
+			wantErr: ErrInvalidOutput,
+		},
+		{
+			name: "Should accept console on stderr",
+			cfg:  config.AppConfig{LogLevel: "warn", LogFormat: FormatConsole, LogOutput: OutputStderr},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			saved := logs
+			defer func() { logs = saved; SetLevel("info") }()
+
+			err := Setup(&tt.cfg)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil && logs != saved {
+				t.Error("logger was replaced by an invalid configuration")
+			}
+		})
+	}
+}
+
+func TestSetupFile(t *testing.T) {
+	saved := logs
+	defer func() { logs = saved; SetLevel("info") }()
+
+	path := filepath.Join(t.TempDir(), "app.log")
+	err := Setup(&config.AppConfig{
+		LogLevel:   "warn",
+		LogFormat:  FormatLogfmt,
+		LogOutput:  OutputFile,
+		LogFile:    path,
+		LogMaxSize: 1024,
+	})
+	if err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+
+	Info().Msg("hidden")
+	Warn().Value("expense_id", 3).Msg("shown")
+	logs.output.Close()
+
+	got, _ := os.ReadFile(path)
+	if strings.Contains(string(got), "hidden") {
+		t.Errorf("file = %q, want no info logs at warn", got)
+	}
+	if !strings.Contains(string(got), "level=warn message=shown") || !strings.Contains(string(got), "expense_id=3") {
+		t.Errorf("file = %q, want the warning in logfmt", got)
+	}
+}
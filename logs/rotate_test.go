@@ -0,0 +1,108 @@
+//go:build unit
+// +build unit
+
+package logs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRotatingFileSize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "app.log")
+	f, err := openRotatingFile(path, 10, 0, 2)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer f.Close()
+
+	clock := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	f.now = func() time.Time {
+		clock = clock.Add(time.Second)
+		return clock
+	}
+
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		if _, err := f.Write([]byte(line)); err != nil {
+			t.Fatalf("write %q: %v", line, err)
+		}
+	}
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if string(got) != "fourth\n" {
+		t.Errorf("current file = %q, want %q", got, "fourth\n")
+	}
+
+	backups, _ := filepath.Glob(path + ".*")
+	if len(backups) != 2 {
+		t.Fatalf("backups = %v, want 2", backups)
+	}
+	oldest, _ := os.ReadFile(backups[0])
+	if string(oldest) != "second\n" {
+		t.Errorf("oldest backup = %q, want %q", oldest, "second\n")
+	}
+}
+
+func TestRotatingFileAge(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	f, err := openRotatingFile(path, 0, time.Hour, 0)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer f.Close()
+
+	clock := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	f.now = func() time.Time { return clock }
+	f.opened = clock
+
+	f.Write([]byte("first\n"))
+	clock = clock.Add(30 * time.Minute)
+	f.Write([]byte("second\n"))
+
+	if backups, _ := filepath.Glob(path + ".*"); len(backups) != 0 {
+		t.Fatalf("backups = %v, want none before the file is an hour old", backups)
+	}
+
+	clock = clock.Add(30 * time.Minute)
+	f.Write([]byte("third\n"))
+
+	backups, _ := filepath.Glob(path + ".*")
+	if len(backups) != 1 {
+		t.Fatalf("backups = %v, want 1", backups)
+	}
+	rotated, _ := os.ReadFile(backups[0])
+	if string(rotated) != "first\nsecond\n" {
+		t.Errorf("backup = %q, want %q", rotated, "first\nsecond\n")
+	}
+	current, _ := os.ReadFile(path)
+	if string(current) != "third\n" {
+		t.Errorf("current file = %q, want %q", current, "third\n")
+	}
+}
+
+func TestRotatingFileAppends(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	if err := os.WriteFile(path, []byte("before\n"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	f, err := openRotatingFile(path, 100, 0, 0)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	f.Write([]byte("after\n"))
+	f.Close()
+
+	got, _ := os.ReadFile(path)
+	if string(got) != "before\nafter\n" {
+		t.Errorf("file = %q, want %q", got, "before\nafter\n")
+	}
+	if f.size != int64(len(got)) {
+		t.Errorf("size = %d, want %d", f.size, len(got))
+	}
+}
@@ -1,37 +1,192 @@
 package middleware
 
 import (
+	"crypto/rsa"
 	"errors"
+	"fmt"
 	"net/http"
+	"os"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/tirathawat/assessment/config"
 	"github.com/tirathawat/assessment/errs"
 	"github.com/tirathawat/assessment/logs"
 )
 
 var (
-	ErrUnauthorized = errors.New("unauthorized")
-	ErrInvalidToken = errors.New("invalid token")
+	ErrUnauthorized   = errs.New(http.StatusUnauthorized, "UNAUTHORIZED", "unauthorized")
+	ErrInvalidToken   = errs.New(http.StatusUnauthorized, "INVALID_TOKEN", "invalid token")
+	ErrMissingSubject = errs.New(http.StatusUnauthorized, "TOKEN_MISSING_SUBJECT", "token has no subject")
+	ErrMissingExpiry  = errs.New(http.StatusUnauthorized, "TOKEN_MISSING_EXPIRY", "token has no expiry")
+	ErrInvalidIssuer  = errs.New(http.StatusUnauthorized, "TOKEN_INVALID_ISSUER", "token has an invalid issuer")
+	ErrNoAuthKey      = errors.New("JWT_SECRET or JWT_PUBLIC_KEY_FILE is required unless legacy tokens are enabled")
 )
 
-func Auth() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		token := c.GetHeader("Authorization")
-		if token == "" {
-			logs.Error().Msg("unauthorized")
-			c.JSON(http.StatusUnauthorized, errs.Error(ErrUnauthorized))
-			c.Abort()
-			return
+// SubjectKey is the gin context key holding the authenticated subject.
+const SubjectKey = "subject"
+
+// ActorKey is the gin context key naming the credential acting for the
+// subject when it is not the subject itself, such as an API key.
+const ActorKey = "actor"
+
+// legacyTokenLayout is the date that used to be accepted as a token. It is
+// only honoured while AUTH_LEGACY_TOKENS is enabled.
+const legacyTokenLayout = "January 2, 2006"
+
+type auth struct {
+	secret        []byte
+	publicKey     *rsa.PublicKey
+	issuer        string
+	legacy        bool
+	legacySubject string
+	admins        map[string]bool
+}
+
+// tokenClaims are the claims read from a JWT. Scope is a space-separated
+// list, as in OAuth 2.0 access tokens.
+type tokenClaims struct {
+	jwt.RegisteredClaims
+	Scope string `json:"scope,omitempty"`
+}
+
+// Auth accepts bearer JWTs signed with HS256 using JWT_SECRET or with
+// RS256 using the key in JWT_PUBLIC_KEY_FILE, and stores their subject in
+// the context along with UserScopes, plus ScopeAdmin for tokens whose scope
+// claim has it and for ADMIN_SUBJECTS. Requests already authenticated, such
+// as by an API key, are passed through.
+func Auth(cfg *config.AppConfig) (gin.HandlerFunc, error) {
+	a := &auth{
+		secret:        []byte(cfg.JWTSecret),
+		issuer:        cfg.JWTIssuer,
+		legacy:        cfg.LegacyTokens,
+		legacySubject: cfg.LegacySubject,
+		admins:        map[string]bool{},
+	}
+
+	for _, subject := range cfg.AdminSubjects {
+		a.admins[subject] = true
+	}
+
+	if cfg.JWTPublicKeyFile != "" {
+		pem, err := os.ReadFile(cfg.JWTPublicKeyFile)
+		if err != nil {
+			return nil, err
 		}
 
-		if _, err := time.Parse("January 2, 2006", token); err != nil {
-			logs.Error().Err(err).Msg("invalid token")
-			c.JSON(http.StatusUnauthorized, errs.Error(ErrInvalidToken))
-			c.Abort()
-			return
+		if a.publicKey, err = jwt.ParseRSAPublicKeyFromPEM(pem); err != nil {
+			return nil, fmt.Errorf("%s: %w", cfg.JWTPublicKeyFile, err)
 		}
+	}
+
+	if len(a.secret) == 0 && a.publicKey == nil && !a.legacy {
+		return nil, ErrNoAuthKey
+	}
+
+	return a.handle, nil
+}
 
+func (a *auth) handle(c *gin.Context) {
+	if Subject(c) != "" {
 		c.Next()
+		return
+	}
+
+	header := c.GetHeader("Authorization")
+	if header == "" {
+		logs.Error().Context(c.Request.Context()).Msg("unauthorized")
+		errs.JSON(c, http.StatusUnauthorized, ErrUnauthorized)
+		c.Abort()
+		return
+	}
+
+	subject, admin, err := a.authenticate(header)
+	if err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msg("invalid token")
+		errs.JSON(c, http.StatusUnauthorized, ErrInvalidToken)
+		c.Abort()
+		return
 	}
+
+	c.Set(SubjectKey, subject)
+	scopes := append([]string{}, UserScopes...)
+	if admin || a.admins[subject] {
+		scopes = append(scopes, ScopeAdmin)
+	}
+	c.Set(ScopesKey, scopes)
+	c.Next()
+}
+
+// authenticate returns the subject of the token in header and whether the
+// token grants the admin scope.
+func (a *auth) authenticate(header string) (string, bool, error) {
+	const bearer = "Bearer "
+	if !strings.HasPrefix(header, bearer) {
+		if _, err := time.Parse(legacyTokenLayout, header); err != nil || !a.legacy {
+			return "", false, ErrInvalidToken
+		}
+
+		return a.legacySubject, false, nil
+	}
+
+	var claims tokenClaims
+	token := strings.TrimSpace(strings.TrimPrefix(header, bearer))
+	_, err := jwt.ParseWithClaims(token, &claims, a.key, jwt.WithValidMethods([]string{"HS256", "RS256"}))
+	if err != nil {
+		return "", false, err
+	}
+
+	if claims.ExpiresAt == nil {
+		return "", false, ErrMissingExpiry
+	}
+
+	if a.issuer != "" && !claims.VerifyIssuer(a.issuer, true) {
+		return "", false, ErrInvalidIssuer
+	}
+
+	if claims.Subject == "" {
+		return "", false, ErrMissingSubject
+	}
+
+	for _, scope := range strings.Fields(claims.Scope) {
+		if scope == ScopeAdmin {
+			return claims.Subject, true, nil
+		}
+	}
+
+	return claims.Subject, false, nil
+}
+
+// key picks the verification key for the token's algorithm, so a token
+// cannot switch to an algorithm that has not been configured.
+func (a *auth) key(token *jwt.Token) (interface{}, error) {
+	switch token.Method.Alg() {
+	case "HS256":
+		if len(a.secret) > 0 {
+			return a.secret, nil
+		}
+	case "RS256":
+		if a.publicKey != nil {
+			return a.publicKey, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s is not configured", ErrInvalidToken, token.Method.Alg())
+}
+
+// Subject returns the subject authenticated by Auth.
+func Subject(c *gin.Context) string {
+	return c.GetString(SubjectKey)
+}
+
+// Actor names who is making the request for audit records: the credential
+// acting for the subject when there is one, otherwise the subject.
+func Actor(c *gin.Context) string {
+	if actor := c.GetString(ActorKey); actor != "" {
+		return actor
+	}
+
+	return Subject(c)
 }
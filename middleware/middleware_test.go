@@ -0,0 +1,217 @@
+//go:build unit
+// +build unit
+
+package middleware_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/tirathawat/assessment/config"
+	"github.com/tirathawat/assessment/middleware"
+)
+
+const (
+	secret = "test-secret"
+	issuer = "assessment"
+)
+
+func claims(subject, iss string, expiresAt time.Time) jwt.RegisteredClaims {
+	c := jwt.RegisteredClaims{Subject: subject, Issuer: iss}
+	if !expiresAt.IsZero() {
+		c.ExpiresAt = jwt.NewNumericDate(expiresAt)
+	}
+	return c
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, key interface{}, c jwt.Claims) string {
+	token, err := jwt.NewWithClaims(method, c).SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return "Bearer " + token
+}
+
+func writePublicKey(t *testing.T, key *rsa.PrivateKey) string {
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "jwt.pub")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestAuth(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hsConfig := &config.AppConfig{JWTSecret: secret, JWTIssuer: issuer, LegacySubject: "legacy"}
+	rsConfig := &config.AppConfig{JWTPublicKeyFile: writePublicKey(t, rsaKey), JWTIssuer: issuer}
+	legacyConfig := &config.AppConfig{JWTSecret: secret, LegacyTokens: true, LegacySubject: "legacy"}
+	valid := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name           string
+		cfg            *config.AppConfig
+		token          string
+		wantStatusCode int
+		wantSubject    string
+	}{
+		{
+			name:           "Should return 401 when token is missing",
+			cfg:            hsConfig,
+			wantStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:           "Should accept HS256 token signed with the secret",
+			cfg:            hsConfig,
+			token:          sign(t, jwt.SigningMethodHS256, []byte(secret), claims("user-1", issuer, valid)),
+			wantStatusCode: http.StatusOK,
+			wantSubject:    "user-1",
+		},
+		{
+			name:           "Should return 401 when HS256 token is signed with another secret",
+			cfg:            hsConfig,
+			token:          sign(t, jwt.SigningMethodHS256, []byte("other"), claims("user-1", issuer, valid)),
+			wantStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:           "Should return 401 when token is expired",
+			cfg:            hsConfig,
+			token:          sign(t, jwt.SigningMethodHS256, []byte(secret), claims("user-1", issuer, time.Now().Add(-time.Minute))),
+			wantStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:           "Should return 401 when token never expires",
+			cfg:            hsConfig,
+			token:          sign(t, jwt.SigningMethodHS256, []byte(secret), claims("user-1", issuer, time.Time{})),
+			wantStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:           "Should return 401 when issuer does not match",
+			cfg:            hsConfig,
+			token:          sign(t, jwt.SigningMethodHS256, []byte(secret), claims("user-1", "someone-else", valid)),
+			wantStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:           "Should return 401 when subject is missing",
+			cfg:            hsConfig,
+			token:          sign(t, jwt.SigningMethodHS256, []byte(secret), claims("", issuer, valid)),
+			wantStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:           "Should return 401 when token is unsigned",
+			cfg:            hsConfig,
+			token:          sign(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, claims("user-1", issuer, valid)),
+			wantStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:           "Should accept RS256 token signed with the private key",
+			cfg:            rsConfig,
+			token:          sign(t, jwt.SigningMethodRS256, rsaKey, claims("user-2", issuer, valid)),
+			wantStatusCode: http.StatusOK,
+			wantSubject:    "user-2",
+		},
+		{
+			name:           "Should return 401 when RS256 token is signed with another key",
+			cfg:            rsConfig,
+			token:          sign(t, jwt.SigningMethodRS256, otherKey, claims("user-2", issuer, valid)),
+			wantStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:           "Should return 401 when RS256 is not configured",
+			cfg:            hsConfig,
+			token:          sign(t, jwt.SigningMethodRS256, rsaKey, claims("user-2", issuer, valid)),
+			wantStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:           "Should return 401 for legacy token when legacy tokens are disabled",
+			cfg:            hsConfig,
+			token:          "January 2, 2006",
+			wantStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:           "Should accept legacy token when legacy tokens are enabled",
+			cfg:            legacyConfig,
+			token:          "January 2, 2006",
+			wantStatusCode: http.StatusOK,
+			wantSubject:    "legacy",
+		},
+		{
+			name:           "Should return 401 for invalid legacy token",
+			cfg:            legacyConfig,
+			token:          "invalid token",
+			wantStatusCode: http.StatusUnauthorized,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			auth, err := middleware.Auth(test.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var subject string
+			r := gin.New()
+			r.GET("/", auth, func(c *gin.Context) {
+				subject = middleware.Subject(c)
+				c.Status(http.StatusOK)
+			})
+
+			request := httptest.NewRequest(http.MethodGet, "/", nil)
+			if test.token != "" {
+				request.Header.Set("Authorization", test.token)
+			}
+
+			resp := httptest.NewRecorder()
+			r.ServeHTTP(resp, request)
+
+			if resp.Code != test.wantStatusCode {
+				t.Errorf("unexpected status code: got %v want %v", resp.Code, test.wantStatusCode)
+			}
+
+			if subject != test.wantSubject {
+				t.Errorf("unexpected subject: got %v want %v", subject, test.wantSubject)
+			}
+		})
+	}
+}
+
+func TestAuthConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  *config.AppConfig
+	}{
+		{name: "Should return error when no key is configured", cfg: &config.AppConfig{}},
+		{name: "Should return error when public key file is missing", cfg: &config.AppConfig{JWTPublicKeyFile: filepath.Join(t.TempDir(), "missing.pub")}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := middleware.Auth(test.cfg); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tirathawat/assessment/logs"
+)
+
+// RequestIDHeader carries the ID of a request in both directions.
+const RequestIDHeader = "X-Request-ID"
+
+// validRequestID limits the IDs taken from clients to what is safe to log
+// and echo back.
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,128}$`)
+
+// RequestID identifies each request by the ID in its X-Request-ID header,
+// or a new one when it has none or an invalid one. The ID is echoed in the
+// response and added to the request context, so every log of the request
+// carries it.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID.MatchString(id) {
+			id = newRequestID()
+		}
+
+		c.Header(RequestIDHeader, id)
+		c.Request = c.Request.WithContext(logs.WithValue(c.Request.Context(), logs.RequestIDKey, id))
+		c.Next()
+	}
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		logs.Warn().Err(err).Msg("failed to generate request id")
+	}
+
+	return hex.EncodeToString(b)
+}
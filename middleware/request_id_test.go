@@ -0,0 +1,66 @@
+//go:build unit
+// +build unit
+
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tirathawat/assessment/middleware"
+)
+
+func TestRequestID(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   string
+	}{
+		{
+			name:   "Should keep the ID sent by the client",
+			header: "abc-123",
+			want:   "abc-123",
+		},
+		{
+			name: "Should generate an ID when none was sent",
+		},
+		{
+			name:   "Should replace an invalid ID",
+			header: "bad id\n",
+		},
+		{
+			name:   "Should replace an ID that is too long",
+			header: strings.Repeat("a", 129),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			r := gin.New()
+			r.Use(middleware.RequestID())
+			r.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Set(middleware.RequestIDHeader, tt.header)
+			}
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			got := rec.Header().Get(middleware.RequestIDHeader)
+			if tt.want != "" {
+				if got != tt.want {
+					t.Errorf("request id = %q, want %q", got, tt.want)
+				}
+				return
+			}
+			if len(got) != 32 || got == tt.header {
+				t.Errorf("request id = %q, want a generated id", got)
+			}
+		})
+	}
+}
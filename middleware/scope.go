@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tirathawat/assessment/errs"
+	"github.com/tirathawat/assessment/logs"
+)
+
+const (
+	ScopeRead  = "expenses:read"
+	ScopeWrite = "expenses:write"
+	ScopeAdmin = "expenses:admin"
+)
+
+// ScopesKey is the gin context key holding the scopes granted to the
+// request.
+const ScopesKey = "scopes"
+
+// UserScopes are granted to users signed in with a token. The admin scope
+// is added only for tokens that carry it or subjects listed in
+// ADMIN_SUBJECTS. API keys are limited to the scopes they were created
+// with.
+var UserScopes = []string{ScopeRead, ScopeWrite}
+
+var ErrForbidden = errs.New(http.StatusForbidden, "INSUFFICIENT_SCOPE", "insufficient scope")
+
+// implied lists the scopes each scope includes, so a key that may write
+// may also read what it wrote.
+var implied = map[string][]string{
+	ScopeRead:  {ScopeRead},
+	ScopeWrite: {ScopeRead, ScopeWrite},
+	ScopeAdmin: {ScopeRead, ScopeWrite, ScopeAdmin},
+}
+
+// Granted reports whether any of the scopes includes scope.
+func Granted(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		for _, i := range implied[s] {
+			if i == scope {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// RequireScope rejects requests that were not granted scope. It must run
+// after the request has been authenticated.
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !Granted(c.GetStringSlice(ScopesKey), scope) {
+			logs.Error().Context(c.Request.Context()).Err(ErrForbidden).Msgf("missing scope: %s", scope)
+			errs.Abort(c, http.StatusForbidden, ErrForbidden)
+			return
+		}
+
+		c.Next()
+	}
+}
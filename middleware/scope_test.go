@@ -0,0 +1,172 @@
+//go:build unit
+// +build unit
+
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/tirathawat/assessment/config"
+	"github.com/tirathawat/assessment/middleware"
+)
+
+func TestRequireScope(t *testing.T) {
+	tests := []struct {
+		name           string
+		scopes         []string
+		scope          string
+		wantStatusCode int
+	}{
+		{
+			name:           "Should allow a request with the scope",
+			scopes:         []string{middleware.ScopeRead},
+			scope:          middleware.ScopeRead,
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name:           "Should allow reads with the write scope",
+			scopes:         []string{middleware.ScopeWrite},
+			scope:          middleware.ScopeRead,
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name:           "Should allow writes with the admin scope",
+			scopes:         []string{middleware.ScopeAdmin},
+			scope:          middleware.ScopeWrite,
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name:           "Should return 403 when writing with the read scope",
+			scopes:         []string{middleware.ScopeRead},
+			scope:          middleware.ScopeWrite,
+			wantStatusCode: http.StatusForbidden,
+		},
+		{
+			name:           "Should return 403 when administering with the write scope",
+			scopes:         []string{middleware.ScopeRead, middleware.ScopeWrite},
+			scope:          middleware.ScopeAdmin,
+			wantStatusCode: http.StatusForbidden,
+		},
+		{
+			name:           "Should return 403 when no scopes were granted",
+			scope:          middleware.ScopeRead,
+			wantStatusCode: http.StatusForbidden,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := gin.New()
+			r.GET("/", func(c *gin.Context) {
+				if test.scopes != nil {
+					c.Set(middleware.ScopesKey, test.scopes)
+				}
+			}, middleware.RequireScope(test.scope), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			resp := httptest.NewRecorder()
+			r.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			if resp.Code != test.wantStatusCode {
+				t.Errorf("unexpected status code: got %v want %v", resp.Code, test.wantStatusCode)
+			}
+		})
+	}
+}
+
+func TestAuthScopes(t *testing.T) {
+	auth, err := middleware.Auth(&config.AppConfig{JWTSecret: secret})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	adminAuth, err := middleware.Auth(&config.AppConfig{JWTSecret: secret, AdminSubjects: []string{"operator"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scoped := func(subject, scope string) jwt.Claims {
+		return struct {
+			jwt.RegisteredClaims
+			Scope string `json:"scope"`
+		}{claims(subject, "", time.Now().Add(time.Hour)), scope}
+	}
+
+	tests := []struct {
+		name      string
+		auth      gin.HandlerFunc
+		claims    jwt.Claims
+		wantAdmin bool
+	}{
+		{
+			name:   "Should grant read and write but not admin to a signed token",
+			auth:   auth,
+			claims: claims("user-1", "", time.Now().Add(time.Hour)),
+		},
+		{
+			name:      "Should grant admin to a token with the admin scope",
+			auth:      auth,
+			claims:    scoped("user-1", "openid "+middleware.ScopeAdmin),
+			wantAdmin: true,
+		},
+		{
+			name:   "Should not grant admin for other scopes",
+			auth:   auth,
+			claims: scoped("user-1", middleware.ScopeAdmin+"s"),
+		},
+		{
+			name:      "Should grant admin to a configured admin subject",
+			auth:      adminAuth,
+			claims:    claims("operator", "", time.Now().Add(time.Hour)),
+			wantAdmin: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var scopes []string
+			r := gin.New()
+			r.GET("/", test.auth, func(c *gin.Context) {
+				scopes = c.GetStringSlice(middleware.ScopesKey)
+			})
+
+			request := httptest.NewRequest(http.MethodGet, "/", nil)
+			request.Header.Set("Authorization", sign(t, jwt.SigningMethodHS256, []byte(secret), test.claims))
+			r.ServeHTTP(httptest.NewRecorder(), request)
+
+			if !middleware.Granted(scopes, middleware.ScopeWrite) {
+				t.Errorf("unexpected scopes: got %v want %v", scopes, middleware.UserScopes)
+			}
+			if admin := middleware.Granted(scopes, middleware.ScopeAdmin); admin != test.wantAdmin {
+				t.Errorf("unexpected admin scope: got %v want %v", admin, test.wantAdmin)
+			}
+		})
+	}
+
+	t.Run("Should pass through requests that are already authenticated", func(t *testing.T) {
+		var subject string
+		var scopes []string
+		r := gin.New()
+		r.GET("/", func(c *gin.Context) {
+			c.Set(middleware.SubjectKey, "service")
+			c.Set(middleware.ScopesKey, []string{middleware.ScopeRead})
+		}, auth, func(c *gin.Context) {
+			subject = middleware.Subject(c)
+			scopes = c.GetStringSlice(middleware.ScopesKey)
+			c.Status(http.StatusOK)
+		})
+
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		if resp.Code != http.StatusOK || subject != "service" || middleware.Granted(scopes, middleware.ScopeWrite) {
+			t.Errorf("unexpected response: got %v %v %v want 200 service [%s]", resp.Code, subject, scopes, middleware.ScopeRead)
+		}
+	})
+}
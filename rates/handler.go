@@ -0,0 +1,55 @@
+package rates
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tirathawat/assessment/errs"
+	"github.com/tirathawat/assessment/logs"
+)
+
+var (
+	ErrListFailed   = errs.New(http.StatusInternalServerError, "EXCHANGE_RATE_LIST_FAILED", "failed to list exchange rates")
+	ErrUpsertFailed = errs.New(http.StatusInternalServerError, "EXCHANGE_RATE_SAVE_FAILED", "failed to save exchange rates")
+)
+
+type Handler interface {
+	List(c *gin.Context)
+	Upsert(c *gin.Context)
+}
+
+type handler struct {
+	store Store
+}
+
+func NewHandler(store Store) Handler {
+	return &handler{store}
+}
+
+func (h *handler) List(c *gin.Context) {
+	rates, err := h.store.List()
+	if err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msg("failed to list exchange rates")
+		errs.JSON(c, http.StatusInternalServerError, ErrListFailed)
+		return
+	}
+
+	c.JSON(http.StatusOK, rates)
+}
+
+func (h *handler) Upsert(c *gin.Context) {
+	var body []Rate
+	if err := c.ShouldBindJSON(&body); err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msg("failed to bind request body")
+		errs.JSON(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := h.store.Upsert(body); err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("failed to save exchange rates: %v", body)
+		errs.JSON(c, http.StatusInternalServerError, ErrUpsertFailed)
+		return
+	}
+
+	c.JSON(http.StatusOK, body)
+}
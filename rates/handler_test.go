@@ -0,0 +1,134 @@
+//go:build unit
+// +build unit
+
+package rates_test
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/tirathawat/assessment/rates"
+	"github.com/tirathawat/assessment/testutils"
+)
+
+type MockStore struct {
+	rates []rates.Rate
+	err   error
+	saved []rates.Rate
+}
+
+func (m *MockStore) List() ([]rates.Rate, error) {
+	return m.rates, m.err
+}
+
+func (m *MockStore) Upsert(list []rates.Rate) error {
+	m.saved = list
+	return m.err
+}
+
+func TestList(t *testing.T) {
+	tests := []struct {
+		name           string
+		store          *MockStore
+		want           []rates.Rate
+		wantStatusCode int
+	}{
+		{
+			name:           "Should return 200 when list rates successfully",
+			store:          &MockStore{rates: []rates.Rate{{Currency: "USD", EffectiveOn: date(t, "2023-01-01"), Rate: 35}}},
+			want:           []rates.Rate{{Currency: "USD", EffectiveOn: date(t, "2023-01-01"), Rate: 35}},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name:           "Should return 500 when store error",
+			store:          &MockStore{err: errors.New("error")},
+			wantStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			httpRequest := &testutils.HTTPRequest{
+				Method:   http.MethodGet,
+				Endpoint: "rates",
+			}
+
+			var list []rates.Rate
+			statusCode, err := httpRequest.MakeTestHTTPRequest(rates.NewHandler(test.store).List, &list)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if statusCode != test.wantStatusCode {
+				t.Errorf("unexpected status code: got %v want %v", statusCode, test.wantStatusCode)
+			}
+
+			if statusCode == http.StatusOK && !reflect.DeepEqual(list, test.want) {
+				t.Errorf("unexpected rates: got %v want %v", list, test.want)
+			}
+		})
+	}
+}
+
+func TestUpsert(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		store          *MockStore
+		wantSaved      []rates.Rate
+		wantStatusCode int
+	}{
+		{
+			name:           "Should return 200 when save rates successfully",
+			body:           `[{"currency":"USD","effective_on":"2023-01-01","rate":35}]`,
+			store:          &MockStore{},
+			wantSaved:      []rates.Rate{{Currency: "USD", EffectiveOn: date(t, "2023-01-01"), Rate: 35}},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name:           "Should return 400 when rate is not positive",
+			body:           `[{"currency":"USD","effective_on":"2023-01-01","rate":0}]`,
+			store:          &MockStore{},
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:           "Should return 400 when date is invalid",
+			body:           `[{"currency":"USD","effective_on":"01/01/2023","rate":35}]`,
+			store:          &MockStore{},
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:           "Should return 500 when store error",
+			body:           `[{"currency":"USD","effective_on":"2023-01-01","rate":35}]`,
+			store:          &MockStore{err: errors.New("error")},
+			wantSaved:      []rates.Rate{{Currency: "USD", EffectiveOn: date(t, "2023-01-01"), Rate: 35}},
+			wantStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			httpRequest := &testutils.HTTPRequest{
+				Method:   http.MethodPut,
+				Endpoint: "rates",
+				Body:     test.body,
+			}
+
+			var list []rates.Rate
+			statusCode, err := httpRequest.MakeTestHTTPRequest(rates.NewHandler(test.store).Upsert, &list)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if statusCode != test.wantStatusCode {
+				t.Errorf("unexpected status code: got %v want %v", statusCode, test.wantStatusCode)
+			}
+
+			if !reflect.DeepEqual(test.store.saved, test.wantSaved) {
+				t.Errorf("unexpected saved rates: got %v want %v", test.store.saved, test.wantSaved)
+			}
+		})
+	}
+}
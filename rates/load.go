@@ -0,0 +1,91 @@
+package rates
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin/binding"
+)
+
+// LoadFile reads rates from a .json file holding an array of rates or from
+// a .csv file with the columns currency,effective_on,rate. The rates are
+// validated with the same rules as the admin endpoint.
+func LoadFile(path string) ([]Rate, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var rates []Rate
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = json.NewDecoder(f).Decode(&rates)
+	case ".csv":
+		rates, err = readCSV(f)
+	default:
+		err = fmt.Errorf("unsupported rates file: %s", path)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := binding.Validator.ValidateStruct(rates); err != nil {
+		return nil, err
+	}
+
+	return rates, nil
+}
+
+func readCSV(r io.Reader) ([]Rate, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 3
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var rates []Rate
+	for i, record := range records {
+		if i == 0 && strings.EqualFold(record[0], "currency") {
+			continue
+		}
+
+		date, err := ParseDate(record[1])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+
+		value, err := strconv.ParseFloat(record[2], 64)
+		if err != nil || value <= 0 {
+			return nil, fmt.Errorf("line %d: invalid rate %q", i+1, record[2])
+		}
+
+		rates = append(rates, Rate{
+			Currency:    strings.ToUpper(strings.TrimSpace(record[0])),
+			EffectiveOn: date,
+			Rate:        value,
+		})
+	}
+
+	return rates, nil
+}
+
+// Seed loads the rates file at path into the store.
+func Seed(store Store, path string) error {
+	rates, err := LoadFile(path)
+	if err != nil {
+		return err
+	}
+
+	return store.Upsert(rates)
+}
@@ -0,0 +1,144 @@
+package rates
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tirathawat/assessment/errs"
+)
+
+const dateLayout = "2006-01-02"
+
+var ErrRateNotFound = errs.New(http.StatusUnprocessableEntity, "EXCHANGE_RATE_NOT_FOUND", "exchange rate not found")
+
+// Rate is the value of one unit of Currency in the base currency, effective
+// from EffectiveOn until the next rate for the same currency.
+type Rate struct {
+	ID          int     `gorm:"primary_key" json:"-"`
+	Currency    string  `gorm:"type:char(3);not null;uniqueIndex:idx_exchange_rates_currency_date" json:"currency" binding:"required,iso4217"`
+	EffectiveOn Date    `gorm:"type:date;not null;uniqueIndex:idx_exchange_rates_currency_date" json:"effective_on" binding:"required"`
+	Rate        float64 `gorm:"type:numeric(19,8);not null" json:"rate" binding:"required,gt=0"`
+}
+
+func (Rate) TableName() string {
+	return "exchange_rates"
+}
+
+// Date is a calendar day without a time of day, rendered as 2006-01-02.
+type Date struct {
+	time.Time
+}
+
+func ParseDate(s string) (Date, error) {
+	t, err := time.Parse(dateLayout, strings.TrimSpace(s))
+	if err != nil {
+		return Date{}, err
+	}
+
+	return Date{t}, nil
+}
+
+// DateOf returns the calendar day of t in its own location.
+func DateOf(t time.Time) Date {
+	year, month, day := t.Date()
+	return Date{time.Date(year, month, day, 0, 0, 0, 0, time.UTC)}
+}
+
+func (d Date) String() string {
+	return d.Format(dateLayout)
+}
+
+func (d Date) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + d.String() + `"`), nil
+}
+
+func (d *Date) UnmarshalJSON(data []byte) error {
+	date, err := ParseDate(strings.Trim(string(data), `"`))
+	if err != nil {
+		return err
+	}
+
+	*d = date
+	return nil
+}
+
+func (d Date) Value() (driver.Value, error) {
+	return d.String(), nil
+}
+
+func (d *Date) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case time.Time:
+		*d = DateOf(v)
+		return nil
+	case string:
+		return d.UnmarshalJSON([]byte(v))
+	case []byte:
+		return d.UnmarshalJSON(v)
+	}
+
+	return fmt.Errorf("cannot scan %T into Date", src)
+}
+
+// Table answers conversion questions from an in-memory set of rates.
+type Table struct {
+	base  string
+	rates map[string][]Rate
+}
+
+func NewTable(base string, rates []Rate) *Table {
+	t := &Table{base: base, rates: map[string][]Rate{}}
+	for _, rate := range rates {
+		t.rates[rate.Currency] = append(t.rates[rate.Currency], rate)
+	}
+
+	for _, list := range t.rates {
+		sort.Slice(list, func(i, j int) bool {
+			return list[i].EffectiveOn.Before(list[j].EffectiveOn.Time)
+		})
+	}
+
+	return t
+}
+
+// Rate returns the value of one unit of currency in the base currency
+// using the latest rate effective on the given day.
+func (t *Table) Rate(currency string, on Date) (float64, error) {
+	if currency == t.base {
+		return 1, nil
+	}
+
+	list := t.rates[currency]
+	i := sort.Search(len(list), func(i int) bool {
+		return list[i].EffectiveOn.After(on.Time)
+	})
+
+	if i == 0 {
+		return 0, fmt.Errorf("%w: %s on %s", ErrRateNotFound, currency, on)
+	}
+
+	return list[i-1].Rate, nil
+}
+
+// Factor returns the multiplier that converts an amount in from into to.
+func (t *Table) Factor(from, to string, on Date) (float64, error) {
+	if from == to {
+		return 1, nil
+	}
+
+	fromRate, err := t.Rate(from, on)
+	if err != nil {
+		return 0, err
+	}
+
+	toRate, err := t.Rate(to, on)
+	if err != nil {
+		return 0, err
+	}
+
+	return fromRate / toRate, nil
+}
@@ -0,0 +1,138 @@
+//go:build unit
+// +build unit
+
+package rates_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/tirathawat/assessment/rates"
+)
+
+func date(t *testing.T, s string) rates.Date {
+	d, err := rates.ParseDate(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return d
+}
+
+func TestTableFactor(t *testing.T) {
+	table := rates.NewTable("THB", []rates.Rate{
+		{Currency: "USD", EffectiveOn: date(t, "2023-02-01"), Rate: 33},
+		{Currency: "USD", EffectiveOn: date(t, "2023-01-01"), Rate: 35},
+		{Currency: "JPY", EffectiveOn: date(t, "2023-01-01"), Rate: 0.25},
+	})
+
+	tests := []struct {
+		name    string
+		from    string
+		to      string
+		on      string
+		want    float64
+		wantErr error
+	}{
+		{name: "Should return 1 for the same currency", from: "EUR", to: "EUR", on: "2023-01-15", want: 1},
+		{name: "Should convert into base currency", from: "USD", to: "THB", on: "2023-01-15", want: 35},
+		{name: "Should use rate effective on the day", from: "USD", to: "THB", on: "2023-02-01", want: 33},
+		{name: "Should convert from base currency", from: "THB", to: "USD", on: "2023-03-01", want: 1.0 / 33},
+		{name: "Should cross convert through base currency", from: "USD", to: "JPY", on: "2023-01-15", want: 140},
+		{name: "Should return error when no rate is effective yet", from: "USD", to: "THB", on: "2022-12-31", wantErr: rates.ErrRateNotFound},
+		{name: "Should return error when currency is unknown", from: "EUR", to: "THB", on: "2023-01-15", wantErr: rates.ErrRateNotFound},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := table.Factor(test.from, test.to, date(t, test.on))
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("unexpected error: got %v want %v", err, test.wantErr)
+			}
+
+			if got != test.want {
+				t.Errorf("unexpected factor: got %v want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestDateOf(t *testing.T) {
+	bangkok, err := time.LoadLocation("Asia/Bangkok")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := rates.DateOf(time.Date(2023, 1, 1, 20, 0, 0, 0, time.UTC).In(bangkok))
+	if got.String() != "2023-01-02" {
+		t.Errorf("unexpected date: got %v want 2023-01-02", got)
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	want := []rates.Rate{
+		{Currency: "USD", EffectiveOn: date(t, "2023-01-01"), Rate: 35},
+		{Currency: "JPY", EffectiveOn: date(t, "2023-01-01"), Rate: 0.25},
+	}
+
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		want    []rates.Rate
+		wantErr bool
+	}{
+		{
+			name:    "Should load csv file with header",
+			file:    "rates.csv",
+			content: "currency,effective_on,rate\nusd,2023-01-01,35\nJPY, 2023-01-01, 0.25\n",
+			want:    want,
+		},
+		{
+			name:    "Should load json file",
+			file:    "rates.json",
+			content: `[{"currency":"USD","effective_on":"2023-01-01","rate":35},{"currency":"JPY","effective_on":"2023-01-01","rate":0.25}]`,
+			want:    want,
+		},
+		{
+			name:    "Should return error when rate is invalid",
+			file:    "rates.csv",
+			content: "USD,2023-01-01,-1\n",
+			wantErr: true,
+		},
+		{
+			name:    "Should return error when currency is invalid",
+			file:    "rates.json",
+			content: `[{"currency":"XYZ","effective_on":"2023-01-01","rate":1}]`,
+			wantErr: true,
+		},
+		{
+			name:    "Should return error when file type is unsupported",
+			file:    "rates.txt",
+			content: "USD,2023-01-01,35\n",
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), test.file)
+			if err := os.WriteFile(path, []byte(test.content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := rates.LoadFile(path)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("unexpected error: got %v want error %v", err, test.wantErr)
+			}
+
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("unexpected rates: got %v want %v", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package rates
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Store interface {
+	List() ([]Rate, error)
+	Upsert(rates []Rate) error
+}
+
+type store struct {
+	db *gorm.DB
+}
+
+func NewStore(db *gorm.DB) Store {
+	return &store{db}
+}
+
+func (s *store) List() ([]Rate, error) {
+	var rates []Rate
+	err := s.db.Order("currency, effective_on").Find(&rates).Error
+	return rates, err
+}
+
+// Upsert stores the rates, replacing any rate already recorded for the same
+// currency and day.
+func (s *store) Upsert(rates []Rate) error {
+	if len(rates) == 0 {
+		return nil
+	}
+
+	return s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "currency"}, {Name: "effective_on"}},
+		DoUpdates: clause.AssignmentColumns([]string{"rate"}),
+	}).Create(&rates).Error
+}
@@ -0,0 +1,153 @@
+package recurring
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tirathawat/assessment/config"
+	"github.com/tirathawat/assessment/errs"
+	"github.com/tirathawat/assessment/logs"
+	"github.com/tirathawat/assessment/middleware"
+	"github.com/tirathawat/assessment/users"
+	"gorm.io/gorm"
+)
+
+type Handler interface {
+	Create(c *gin.Context)
+	List(c *gin.Context)
+	Get(c *gin.Context)
+	Delete(c *gin.Context)
+}
+
+type handler struct {
+	store    Store
+	timeZone *time.Location
+	currency string
+}
+
+func NewHandler(store Store, cfg *config.AppConfig) Handler {
+	return &handler{
+		store:    store,
+		timeZone: cfg.TimeZone.Get(),
+		currency: cfg.DefaultCurrency,
+	}
+}
+
+// Create adds a template. Occurrences that are already due, because the
+// template starts in the past, are created by the scheduler's next run.
+func (h *handler) Create(c *gin.Context) {
+	owner, ok := users.ID(c)
+	if !ok {
+		logs.Error().Context(c.Request.Context()).Msg("no authenticated user")
+		errs.JSON(c, http.StatusUnauthorized, middleware.ErrUnauthorized)
+		return
+	}
+
+	var body RequestBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msg("failed to bind request body")
+		errs.JSON(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if body.Currency == "" {
+		body.Currency = h.currency
+	}
+
+	if err := body.validate(time.Now()); err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("invalid request body: %v", body)
+		errs.JSON(c, http.StatusBadRequest, err)
+		return
+	}
+
+	template := body.Template(owner, h.timeZone)
+	if err := h.store.Create(&template); err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("failed to create recurring template: %s", body.Title)
+		errs.JSON(c, http.StatusInternalServerError, ErrCreateFailed)
+		return
+	}
+
+	c.JSON(http.StatusCreated, template)
+}
+
+func (h *handler) List(c *gin.Context) {
+	owner, ok := users.ID(c)
+	if !ok {
+		logs.Error().Context(c.Request.Context()).Msg("no authenticated user")
+		errs.JSON(c, http.StatusUnauthorized, middleware.ErrUnauthorized)
+		return
+	}
+
+	templates, err := h.store.List(owner)
+	if err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msg("failed to list recurring templates")
+		errs.JSON(c, http.StatusInternalServerError, ErrListFailed)
+		return
+	}
+
+	c.JSON(http.StatusOK, templates)
+}
+
+func (h *handler) Get(c *gin.Context) {
+	owner, ok := users.ID(c)
+	if !ok {
+		logs.Error().Context(c.Request.Context()).Msg("no authenticated user")
+		errs.JSON(c, http.StatusUnauthorized, middleware.ErrUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("invalid id: %s", c.Param("id"))
+		errs.JSON(c, http.StatusBadRequest, ErrInvalidID)
+		return
+	}
+
+	template, err := h.store.Get(owner, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("recurring template not found: %d", id)
+		errs.JSON(c, http.StatusNotFound, ErrNotFound)
+		return
+	}
+	if err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("failed to get recurring template: %d", id)
+		errs.JSON(c, http.StatusInternalServerError, ErrGetFailed)
+		return
+	}
+
+	c.JSON(http.StatusOK, template)
+}
+
+func (h *handler) Delete(c *gin.Context) {
+	owner, ok := users.ID(c)
+	if !ok {
+		logs.Error().Context(c.Request.Context()).Msg("no authenticated user")
+		errs.JSON(c, http.StatusUnauthorized, middleware.ErrUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("invalid id: %s", c.Param("id"))
+		errs.JSON(c, http.StatusBadRequest, ErrInvalidID)
+		return
+	}
+
+	deleted, err := h.store.Delete(owner, id)
+	if err != nil {
+		logs.Error().Context(c.Request.Context()).Err(err).Msgf("failed to delete recurring template: %d", id)
+		errs.JSON(c, http.StatusInternalServerError, ErrDeleteFailed)
+		return
+	}
+
+	if !deleted {
+		logs.Error().Context(c.Request.Context()).Msgf("recurring template not found: %d", id)
+		errs.JSON(c, http.StatusNotFound, ErrNotFound)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,238 @@
+//go:build unit
+// +build unit
+
+package recurring_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tirathawat/assessment/config"
+	"github.com/tirathawat/assessment/recurring"
+	"github.com/tirathawat/assessment/testutils"
+	"github.com/tirathawat/assessment/users"
+	"gorm.io/gorm"
+)
+
+const ownerID = 7
+
+var cfg = &config.AppConfig{
+	DefaultCurrency:   "THB",
+	TimeZone:          config.Location{Location: time.UTC},
+	RecurringInterval: time.Hour,
+}
+
+type MockStore struct {
+	templates []recurring.Template
+	err       error
+
+	due     []int
+	pending map[int]int
+	failing int
+	calls   []int
+}
+
+func (m *MockStore) Create(template *recurring.Template) error {
+	return m.err
+}
+
+func (m *MockStore) List(owner int) ([]recurring.Template, error) {
+	return m.templates, m.err
+}
+
+func (m *MockStore) Get(owner, id int) (recurring.Template, error) {
+	for _, template := range m.templates {
+		if template.ID == id {
+			return template, m.err
+		}
+	}
+
+	return recurring.Template{}, gorm.ErrRecordNotFound
+}
+
+func (m *MockStore) Delete(owner, id int) (bool, error) {
+	_, err := m.Get(owner, id)
+	return err == nil, m.err
+}
+
+func (m *MockStore) Due(now time.Time) ([]int, error) {
+	return m.due, m.err
+}
+
+func (m *MockStore) Materialize(id int, now time.Time, loc *time.Location, limit int) (int, error) {
+	m.calls = append(m.calls, id)
+	if id == m.failing {
+		return 0, errors.New("error")
+	}
+
+	handled := m.pending[id]
+	if handled > limit {
+		handled = limit
+	}
+	m.pending[id] -= handled
+
+	return handled, nil
+}
+
+func asUser(handler gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(users.IDKey, ownerID)
+		handler(c)
+	}
+}
+
+func TestCreate(t *testing.T) {
+	start := time.Now().UTC().Truncate(time.Second).AddDate(0, 0, -1)
+	startsAt := start.Format(time.RFC3339)
+	body := func(fields string) string {
+		return fmt.Sprintf(`{"title": "rent", "amount": 12000, "note": "flat", "tags": ["home"], %s}`, fields)
+	}
+
+	tests := []struct {
+		name           string
+		cfg            *config.AppConfig
+		body           string
+		store          *MockStore
+		wantStatusCode int
+		wantNextAt     string
+	}{
+		{
+			name:           "Should return 201 scheduled from the start",
+			body:           body(`"frequency": "monthly", "starts_at": "` + startsAt + `"`),
+			store:          &MockStore{},
+			wantStatusCode: http.StatusCreated,
+			wantNextAt:     startsAt,
+		},
+		{
+			name:           "Should fall back to UTC without a configured time zone",
+			cfg:            &config.AppConfig{DefaultCurrency: "THB"},
+			body:           body(`"frequency": "monthly", "starts_at": "` + startsAt + `"`),
+			store:          &MockStore{},
+			wantStatusCode: http.StatusCreated,
+			wantNextAt:     startsAt,
+		},
+		{
+			name:           "Should return 400 when frequency is unknown",
+			body:           body(`"frequency": "hourly", "starts_at": "` + startsAt + `"`),
+			store:          &MockStore{},
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:           "Should return 400 when amount is negative",
+			body:           fmt.Sprintf(`{"title": "rent", "amount": -1, "note": "flat", "tags": ["home"], "frequency": "monthly", "starts_at": %q}`, startsAt),
+			store:          &MockStore{},
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:           "Should return 400 when it ends before it starts",
+			body:           body(`"frequency": "monthly", "starts_at": "` + startsAt + `", "ends_at": "` + start.AddDate(0, 0, -1).Format(time.RFC3339) + `"`),
+			store:          &MockStore{},
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:           "Should return 400 when it starts more than 90 days ago",
+			body:           body(`"frequency": "daily", "starts_at": "` + start.AddDate(0, 0, -90).Format(time.RFC3339) + `"`),
+			store:          &MockStore{},
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:           "Should return 500 when store error",
+			body:           body(`"frequency": "monthly", "starts_at": "` + startsAt + `"`),
+			store:          &MockStore{err: errors.New("error")},
+			wantStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			httpRequest := &testutils.HTTPRequest{Method: http.MethodPost, Endpoint: "recurring", Body: test.body}
+			cfg := cfg
+			if test.cfg != nil {
+				cfg = test.cfg
+			}
+
+			template := &recurring.Template{}
+			statusCode, err := httpRequest.MakeTestHTTPRequest(asUser(recurring.NewHandler(test.store, cfg).Create), template)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if statusCode != test.wantStatusCode {
+				t.Errorf("unexpected status code: got %v want %v", statusCode, test.wantStatusCode)
+			}
+
+			if statusCode != http.StatusCreated {
+				return
+			}
+
+			if template.Currency != "THB" || template.Interval != 1 || template.Amount != 1200000 {
+				t.Errorf("unexpected template: got %+v", template)
+			}
+
+			if template.NextAt == nil || template.NextAt.Format(time.RFC3339) != test.wantNextAt {
+				t.Errorf("unexpected next_at: got %v want %v", template.NextAt, test.wantNextAt)
+			}
+		})
+	}
+}
+
+func TestGet(t *testing.T) {
+	tests := []struct {
+		name           string
+		id             string
+		store          *MockStore
+		wantStatusCode int
+	}{
+		{name: "Should return 200 when template exists", id: "1", store: &MockStore{templates: []recurring.Template{{ID: 1}}}, wantStatusCode: http.StatusOK},
+		{name: "Should return 404 when template not found", id: "2", store: &MockStore{templates: []recurring.Template{{ID: 1}}}, wantStatusCode: http.StatusNotFound},
+		{name: "Should return 400 when id is not a number", id: "invalid", store: &MockStore{}, wantStatusCode: http.StatusBadRequest},
+		{name: "Should return 500 when store error", id: "1", store: &MockStore{templates: []recurring.Template{{ID: 1}}, err: errors.New("error")}, wantStatusCode: http.StatusInternalServerError},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			httpRequest := &testutils.HTTPRequest{Method: http.MethodGet, Endpoint: "recurring"}
+
+			resp, err := httpRequest.MakeTestHTTPResponse(asUser(recurring.NewHandler(test.store, cfg).Get), gin.Param{Key: "id", Value: test.id})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if resp.Code != test.wantStatusCode {
+				t.Errorf("unexpected status code: got %v want %v", resp.Code, test.wantStatusCode)
+			}
+		})
+	}
+}
+
+func TestDelete(t *testing.T) {
+	tests := []struct {
+		name           string
+		id             string
+		wantStatusCode int
+	}{
+		{name: "Should return 204 when delete template successfully", id: "1", wantStatusCode: http.StatusNoContent},
+		{name: "Should return 404 when template not found", id: "2", wantStatusCode: http.StatusNotFound},
+		{name: "Should return 400 when id is not a number", id: "invalid", wantStatusCode: http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			store := &MockStore{templates: []recurring.Template{{ID: 1}}}
+			httpRequest := &testutils.HTTPRequest{Method: http.MethodDelete, Endpoint: "recurring"}
+
+			resp, err := httpRequest.MakeTestHTTPResponse(asUser(recurring.NewHandler(store, cfg).Delete), gin.Param{Key: "id", Value: test.id})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if resp.Code != test.wantStatusCode {
+				t.Errorf("unexpected status code: got %v want %v", resp.Code, test.wantStatusCode)
+			}
+		})
+	}
+}
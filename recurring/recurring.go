@@ -0,0 +1,226 @@
+package recurring
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/tirathawat/assessment/errs"
+	"github.com/tirathawat/assessment/expenses"
+)
+
+const (
+	FrequencyDaily   = "daily"
+	FrequencyWeekly  = "weekly"
+	FrequencyMonthly = "monthly"
+	FrequencyYearly  = "yearly"
+)
+
+var (
+	ErrNotFound       = errs.New(http.StatusNotFound, "RECURRING_TEMPLATE_NOT_FOUND", "recurring template not found")
+	ErrInvalidID      = errs.New(http.StatusBadRequest, "INVALID_ID", "invalid id")
+	ErrCreateFailed   = errs.New(http.StatusInternalServerError, "RECURRING_TEMPLATE_CREATE_FAILED", "failed to create recurring template")
+	ErrListFailed     = errs.New(http.StatusInternalServerError, "RECURRING_TEMPLATE_LIST_FAILED", "failed to list recurring templates")
+	ErrGetFailed      = errs.New(http.StatusInternalServerError, "RECURRING_TEMPLATE_GET_FAILED", "failed to get recurring template")
+	ErrDeleteFailed   = errs.New(http.StatusInternalServerError, "RECURRING_TEMPLATE_DELETE_FAILED", "failed to delete recurring template")
+	ErrInvalidAmount  = errs.New(http.StatusBadRequest, "AMOUNT_NOT_POSITIVE", "amount must be positive")
+	ErrEndsBefore     = errs.New(http.StatusBadRequest, "ENDS_BEFORE_STARTS", "ends_at must not be before starts_at")
+	ErrStartsTooEarly = errs.New(http.StatusBadRequest, "STARTS_TOO_EARLY", "starts_at must not be more than 90 days in the past")
+)
+
+// maxBackfill bounds how far in the past a template may start, as every
+// occurrence since its start is created on the next scheduler run.
+const maxBackfill = 90 * 24 * time.Hour
+
+// Template describes an expense that repeats every Interval days, weeks,
+// months or years from StartsAt, until EndsAt or until Count expenses have
+// been created, whichever comes first. Without either it repeats forever.
+//
+// Occurrences counts the expenses created so far and NextAt is when the
+// next one is due, or nil once the template has finished.
+type Template struct {
+	ID       int            `gorm:"primary_key" json:"id"`
+	OwnerID  int            `gorm:"not null;index" json:"-"`
+	Title    string         `gorm:"type:text;not null" json:"title"`
+	Amount   expenses.Money `gorm:"type:numeric(19,2);not null" json:"amount"`
+	Currency string         `gorm:"type:char(3);not null" json:"currency"`
+	Note     string         `gorm:"type:text;not null" json:"note"`
+	Tags     pq.StringArray `gorm:"type:text[]" json:"tags"`
+
+	Frequency string     `gorm:"type:text;not null" json:"frequency"`
+	Interval  int        `gorm:"not null;default:1" json:"interval"`
+	StartsAt  time.Time  `gorm:"not null" json:"starts_at"`
+	EndsAt    *time.Time `json:"ends_at"`
+	Count     *int       `json:"count"`
+
+	Occurrences int        `gorm:"not null;default:0" json:"occurrences"`
+	NextAt      *time.Time `gorm:"index" json:"next_at"`
+
+	CreatedAt time.Time `gorm:"not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt time.Time `gorm:"not null;default:CURRENT_TIMESTAMP" json:"updated_at"`
+}
+
+func (Template) TableName() string {
+	return "recurring_templates"
+}
+
+// Occurrence records that the Sequence-th expense of a template has been
+// created. Its key is what keeps an occurrence from being created twice.
+// The record outlives the expense, so a purged expense is not recreated.
+type Occurrence struct {
+	TemplateID int       `gorm:"primaryKey;autoIncrement:false"`
+	Sequence   int       `gorm:"primaryKey;autoIncrement:false"`
+	At         time.Time `gorm:"not null"`
+	ExpenseID  *int      `gorm:"index"`
+	CreatedAt  time.Time `gorm:"not null;default:CURRENT_TIMESTAMP"`
+
+	Template Template          `gorm:"foreignKey:TemplateID;constraint:OnDelete:CASCADE"`
+	Expense  *expenses.Expense `gorm:"foreignKey:ExpenseID;constraint:OnDelete:SET NULL"`
+}
+
+func (Occurrence) TableName() string {
+	return "recurring_occurrences"
+}
+
+type RequestBody struct {
+	Title     string         `json:"title" binding:"required"`
+	Amount    expenses.Money `json:"amount" binding:"required"`
+	Currency  string         `json:"currency" binding:"omitempty,iso4217"`
+	Note      string         `json:"note" binding:"required"`
+	Tags      []string       `json:"tags" binding:"required"`
+	Frequency string         `json:"frequency" binding:"required,oneof=daily weekly monthly yearly"`
+	Interval  int            `json:"interval" binding:"omitempty,min=1,max=1000"`
+	StartsAt  time.Time      `json:"starts_at" binding:"required"`
+	EndsAt    *time.Time     `json:"ends_at"`
+	Count     *int           `json:"count" binding:"omitempty,min=1"`
+}
+
+func (body *RequestBody) validate(now time.Time) error {
+	if body.Amount <= 0 {
+		return ErrInvalidAmount
+	}
+
+	if body.Amount%expenses.CurrencyUnit(body.Currency) != 0 {
+		return expenses.ErrAmountPrecision
+	}
+
+	if body.EndsAt != nil && body.EndsAt.Before(body.StartsAt) {
+		return ErrEndsBefore
+	}
+
+	if body.StartsAt.Before(now.Add(-maxBackfill)) {
+		return ErrStartsTooEarly
+	}
+
+	return nil
+}
+
+// Template builds a template from the body, scheduled from its start.
+func (body *RequestBody) Template(owner int, loc *time.Location) Template {
+	template := Template{
+		OwnerID:   owner,
+		Title:     body.Title,
+		Amount:    body.Amount,
+		Currency:  body.Currency,
+		Note:      body.Note,
+		Tags:      pq.StringArray(body.Tags),
+		Frequency: body.Frequency,
+		Interval:  body.Interval,
+		StartsAt:  body.StartsAt,
+		EndsAt:    body.EndsAt,
+		Count:     body.Count,
+	}
+
+	if template.Interval == 0 {
+		template.Interval = 1
+	}
+
+	template.schedule(loc)
+	return template
+}
+
+// occurrence returns when the n-th expense of the template, counting from
+// zero, is due. Dates follow the calendar in loc, so a template keeps its
+// time of day across daylight saving changes. Days that a month does not
+// have fall on its last day: monthly from January 31 is due on February 28
+// or 29, then on March 31.
+func (t *Template) occurrence(n int, loc *time.Location) time.Time {
+	start := t.StartsAt.In(loc)
+	year, month, day := start.Date()
+	hour, min, sec := start.Clock()
+	step := n * t.Interval
+
+	switch t.Frequency {
+	case FrequencyDaily:
+		day += step
+	case FrequencyWeekly:
+		day += 7 * step
+	case FrequencyMonthly:
+		year, month, _ = time.Date(year, month+time.Month(step), 1, 0, 0, 0, 0, loc).Date()
+		day = clampDay(year, month, day, loc)
+	case FrequencyYearly:
+		year += step
+		day = clampDay(year, month, day, loc)
+	}
+
+	return time.Date(year, month, day, hour, min, sec, start.Nanosecond(), loc)
+}
+
+// clampDay limits day to the number of days in the month.
+func clampDay(year int, month time.Month, day int, loc *time.Location) int {
+	if last := time.Date(year, month+1, 0, 0, 0, 0, 0, loc).Day(); day > last {
+		return last
+	}
+
+	return day
+}
+
+// schedule sets NextAt to the next occurrence, or to nil when the template
+// has reached its count or end date.
+func (t *Template) schedule(loc *time.Location) {
+	t.NextAt = nil
+	if t.Count != nil && t.Occurrences >= *t.Count {
+		return
+	}
+
+	next := t.occurrence(t.Occurrences, loc)
+	if t.EndsAt != nil && next.After(*t.EndsAt) {
+		return
+	}
+
+	t.NextAt = &next
+}
+
+// due returns up to limit occurrences that are due at or before now and
+// advances the template past them. Occurrences missed while the scheduler
+// was not running are all returned, oldest first.
+func (t *Template) due(now time.Time, loc *time.Location, limit int) []Occurrence {
+	var due []Occurrence
+	for len(due) < limit && t.NextAt != nil && !t.NextAt.After(now) {
+		due = append(due, Occurrence{TemplateID: t.ID, Sequence: t.Occurrences, At: *t.NextAt})
+		t.Occurrences++
+		t.schedule(loc)
+	}
+
+	return due
+}
+
+// actor names the template in the history of the expenses it creates.
+func (t *Template) actor() string {
+	return fmt.Sprintf("recurring:%d", t.ID)
+}
+
+// expense builds the expense of an occurrence.
+func (t *Template) expense(occurrence Occurrence) expenses.Expense {
+	return expenses.Expense{
+		Title:    t.Title,
+		Amount:   t.Amount,
+		Currency: t.Currency,
+		Note:     t.Note,
+		Tags:     t.Tags,
+		SpentAt:  occurrence.At,
+		Version:  1,
+		OwnerID:  t.OwnerID,
+	}
+}
@@ -0,0 +1,152 @@
+//go:build unit
+// +build unit
+
+package recurring
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOccurrence(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		template Template
+		n        int
+		wantAt   time.Time
+	}{
+		{
+			name:     "Should repeat every interval days",
+			template: Template{Frequency: FrequencyDaily, Interval: 3, StartsAt: time.Date(2023, time.January, 30, 9, 0, 0, 0, loc)},
+			n:        1,
+			wantAt:   time.Date(2023, time.February, 2, 9, 0, 0, 0, loc),
+		},
+		{
+			name:     "Should keep the time of day across daylight saving",
+			template: Template{Frequency: FrequencyWeekly, Interval: 1, StartsAt: time.Date(2023, time.March, 6, 9, 0, 0, 0, loc)},
+			n:        1,
+			wantAt:   time.Date(2023, time.March, 13, 9, 0, 0, 0, loc),
+		},
+		{
+			name:     "Should fall on the last day of a shorter month",
+			template: Template{Frequency: FrequencyMonthly, Interval: 1, StartsAt: time.Date(2023, time.January, 31, 9, 0, 0, 0, loc)},
+			n:        1,
+			wantAt:   time.Date(2023, time.February, 28, 9, 0, 0, 0, loc),
+		},
+		{
+			name:     "Should return to the starting day after a shorter month",
+			template: Template{Frequency: FrequencyMonthly, Interval: 1, StartsAt: time.Date(2023, time.January, 31, 9, 0, 0, 0, loc)},
+			n:        2,
+			wantAt:   time.Date(2023, time.March, 31, 9, 0, 0, 0, loc),
+		},
+		{
+			name:     "Should cross years every interval months",
+			template: Template{Frequency: FrequencyMonthly, Interval: 5, StartsAt: time.Date(2023, time.October, 15, 9, 0, 0, 0, loc)},
+			n:        1,
+			wantAt:   time.Date(2024, time.March, 15, 9, 0, 0, 0, loc),
+		},
+		{
+			name:     "Should fall on February 28 in years without February 29",
+			template: Template{Frequency: FrequencyYearly, Interval: 1, StartsAt: time.Date(2024, time.February, 29, 9, 0, 0, 0, loc)},
+			n:        1,
+			wantAt:   time.Date(2025, time.February, 28, 9, 0, 0, 0, loc),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if at := test.template.occurrence(test.n, loc); !at.Equal(test.wantAt) {
+				t.Errorf("unexpected occurrence: got %v want %v", at, test.wantAt)
+			}
+		})
+	}
+}
+
+func TestDue(t *testing.T) {
+	start := time.Date(2023, time.January, 1, 8, 0, 0, 0, time.UTC)
+	count := 3
+	ends := start.AddDate(0, 0, 1)
+
+	tests := []struct {
+		name            string
+		template        Template
+		now             time.Time
+		limit           int
+		wantSequences   []int
+		wantOccurrences int
+		wantFinished    bool
+	}{
+		{
+			name:            "Should return nothing before the start",
+			template:        Template{Frequency: FrequencyDaily, Interval: 1, StartsAt: start},
+			now:             start.Add(-time.Minute),
+			limit:           100,
+			wantOccurrences: 0,
+		},
+		{
+			name:            "Should catch up every missed occurrence",
+			template:        Template{Frequency: FrequencyDaily, Interval: 1, StartsAt: start},
+			now:             start.AddDate(0, 0, 4),
+			limit:           100,
+			wantSequences:   []int{0, 1, 2, 3, 4},
+			wantOccurrences: 5,
+		},
+		{
+			name:            "Should stop at the limit",
+			template:        Template{Frequency: FrequencyDaily, Interval: 1, StartsAt: start},
+			now:             start.AddDate(0, 0, 4),
+			limit:           2,
+			wantSequences:   []int{0, 1},
+			wantOccurrences: 2,
+		},
+		{
+			name:            "Should finish after count occurrences",
+			template:        Template{Frequency: FrequencyDaily, Interval: 1, StartsAt: start, Count: &count},
+			now:             start.AddDate(0, 0, 10),
+			limit:           100,
+			wantSequences:   []int{0, 1, 2},
+			wantOccurrences: 3,
+			wantFinished:    true,
+		},
+		{
+			name:            "Should finish at the end date",
+			template:        Template{Frequency: FrequencyDaily, Interval: 1, StartsAt: start, EndsAt: &ends},
+			now:             start.AddDate(0, 0, 10),
+			limit:           100,
+			wantSequences:   []int{0, 1},
+			wantOccurrences: 2,
+			wantFinished:    true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			template := test.template
+			template.schedule(time.UTC)
+
+			due := template.due(test.now, time.UTC, test.limit)
+			if len(due) != len(test.wantSequences) {
+				t.Fatalf("unexpected occurrences: got %+v want sequences %v", due, test.wantSequences)
+			}
+
+			for i, occurrence := range due {
+				if occurrence.Sequence != test.wantSequences[i] || !occurrence.At.Equal(start.AddDate(0, 0, test.wantSequences[i])) {
+					t.Errorf("unexpected occurrence: got %+v", occurrence)
+				}
+			}
+
+			if template.Occurrences != test.wantOccurrences {
+				t.Errorf("unexpected occurrences count: got %v want %v", template.Occurrences, test.wantOccurrences)
+			}
+
+			if finished := template.NextAt == nil; finished != test.wantFinished {
+				t.Errorf("unexpected finished: got %v want %v", finished, test.wantFinished)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package recurring
+
+import (
+	"time"
+
+	"github.com/tirathawat/assessment/config"
+	"github.com/tirathawat/assessment/logs"
+)
+
+// batchSize bounds the occurrences of a template materialized in one
+// transaction, so catching up after a long downtime does not hold a lock
+// for long.
+const batchSize = 100
+
+// Scheduler creates the expenses of recurring templates as they fall due.
+// Occurrences missed while the server was down are created on the next
+// run, each spent at the time it was due.
+type Scheduler struct {
+	store    Store
+	timeZone *time.Location
+	interval time.Duration
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+func NewScheduler(store Store, cfg *config.AppConfig) *Scheduler {
+	return &Scheduler{
+		store:    store,
+		timeZone: cfg.TimeZone.Get(),
+		interval: cfg.RecurringInterval,
+	}
+}
+
+func (s *Scheduler) Start() {
+	s.stop = make(chan struct{})
+	s.done = make(chan struct{})
+
+	go func() {
+		defer close(s.done)
+
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			s.run()
+
+			select {
+			case <-ticker.C:
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+}
+
+func (s *Scheduler) Stop() {
+	if s.stop == nil {
+		return
+	}
+
+	close(s.stop)
+	<-s.done
+}
+
+// Materialize creates the expenses of every occurrence due at or before
+// now and returns how many occurrences were handled. A template that fails
+// does not hold back the others; the first error is returned after all
+// of them have been tried.
+func (s *Scheduler) Materialize(now time.Time) (int, error) {
+	ids, err := s.store.Due(now)
+	if err != nil {
+		return 0, err
+	}
+
+	var total int
+	var first error
+	for _, id := range ids {
+		for {
+			handled, err := s.store.Materialize(id, now, s.timeZone, batchSize)
+			total += handled
+			if err != nil {
+				logs.Error().Err(err).Msgf("failed to materialize recurring template: %d", id)
+				if first == nil {
+					first = err
+				}
+				break
+			}
+
+			if handled < batchSize {
+				break
+			}
+		}
+	}
+
+	return total, first
+}
+
+func (s *Scheduler) run() {
+	handled, err := s.Materialize(time.Now())
+	if err != nil {
+		logs.Error().Err(err).Msg("failed to materialize recurring expenses")
+	}
+
+	if handled > 0 {
+		logs.Info().Msgf("created %d recurring expenses", handled)
+	}
+}
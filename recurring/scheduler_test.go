@@ -0,0 +1,80 @@
+//go:build unit
+// +build unit
+
+package recurring_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/tirathawat/assessment/recurring"
+)
+
+func TestMaterialize(t *testing.T) {
+	tests := []struct {
+		name        string
+		store       *MockStore
+		wantHandled int
+		wantCalls   []int
+		wantErr     bool
+	}{
+		{
+			name:        "Should materialize every due template",
+			store:       &MockStore{due: []int{1, 2}, pending: map[int]int{1: 1, 2: 3}},
+			wantHandled: 4,
+			wantCalls:   []int{1, 2},
+		},
+		{
+			name:        "Should keep materializing a template until it has caught up",
+			store:       &MockStore{due: []int{1}, pending: map[int]int{1: 250}},
+			wantHandled: 250,
+			wantCalls:   []int{1, 1, 1},
+		},
+		{
+			name:        "Should carry on with other templates when one fails",
+			store:       &MockStore{due: []int{1, 2}, pending: map[int]int{2: 1}, failing: 1},
+			wantHandled: 1,
+			wantCalls:   []int{1, 2},
+			wantErr:     true,
+		},
+		{
+			name:    "Should return error when due templates cannot be listed",
+			store:   &MockStore{err: errors.New("error")},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handled, err := recurring.NewScheduler(test.store, cfg).Materialize(time.Now())
+			if (err != nil) != test.wantErr {
+				t.Errorf("unexpected error: got %v want error %v", err, test.wantErr)
+			}
+
+			if handled != test.wantHandled {
+				t.Errorf("unexpected handled: got %v want %v", handled, test.wantHandled)
+			}
+
+			if len(test.store.calls) != len(test.wantCalls) {
+				t.Fatalf("unexpected calls: got %v want %v", test.store.calls, test.wantCalls)
+			}
+			for i := range test.wantCalls {
+				if test.store.calls[i] != test.wantCalls[i] {
+					t.Errorf("unexpected calls: got %v want %v", test.store.calls, test.wantCalls)
+				}
+			}
+		})
+	}
+}
+
+func TestSchedulerStop(t *testing.T) {
+	store := &MockStore{}
+	scheduler := recurring.NewScheduler(store, cfg)
+	scheduler.Start()
+	scheduler.Stop()
+
+	if len(store.calls) != 0 {
+		t.Errorf("unexpected calls: got %v", store.calls)
+	}
+}
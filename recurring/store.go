@@ -0,0 +1,112 @@
+package recurring
+
+import (
+	"errors"
+	"time"
+
+	"github.com/tirathawat/assessment/expenses"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Store interface {
+	Create(template *Template) error
+	List(owner int) ([]Template, error)
+	// Get returns the owner's template, or gorm.ErrRecordNotFound.
+	Get(owner, id int) (Template, error)
+	// Delete reports false when the owner has no template with the id.
+	// Expenses already created from the template are kept.
+	Delete(owner, id int) (bool, error)
+	// Due returns the ids of the templates with an occurrence due at or
+	// before now.
+	Due(now time.Time) ([]int, error)
+	// Materialize creates the expenses of up to limit occurrences of the
+	// template that are due at or before now, and returns how many
+	// occurrences it handled. Occurrences that already have an expense
+	// are skipped, and templates locked by another scheduler are left to
+	// it.
+	Materialize(id int, now time.Time, loc *time.Location, limit int) (int, error)
+}
+
+type store struct {
+	db *gorm.DB
+}
+
+func NewStore(db *gorm.DB) Store {
+	return &store{db}
+}
+
+func (s *store) Create(template *Template) error {
+	return s.db.Create(template).Error
+}
+
+func (s *store) List(owner int) ([]Template, error) {
+	templates := []Template{}
+	err := s.db.Where("owner_id = ?", owner).Order("id").Find(&templates).Error
+	return templates, err
+}
+
+func (s *store) Get(owner, id int) (Template, error) {
+	var template Template
+	err := s.db.Where("owner_id = ?", owner).First(&template, "id = ?", id).Error
+	return template, err
+}
+
+func (s *store) Delete(owner, id int) (bool, error) {
+	result := s.db.Where("owner_id = ?", owner).Delete(&Template{}, "id = ?", id)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (s *store) Due(now time.Time) ([]int, error) {
+	var ids []int
+	err := s.db.Model(&Template{}).Where("next_at <= ?", now).Order("next_at").Pluck("id", &ids).Error
+	return ids, err
+}
+
+func (s *store) Materialize(id int, now time.Time, loc *time.Location, limit int) (int, error) {
+	handled := 0
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var template Template
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			First(&template, "id = ? AND next_at <= ?", id, now).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		due := template.due(now, loc, limit)
+		for _, occurrence := range due {
+			result := tx.Omit("Template", "Expense").Clauses(clause.OnConflict{DoNothing: true}).Create(&occurrence)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				continue
+			}
+
+			expense := template.expense(occurrence)
+			if err := tx.Create(&expense).Error; err != nil {
+				return err
+			}
+
+			history := expenses.NewHistory(expenses.ActionCreate, template.actor(), nil, &expense)
+			if err := tx.Create(&history).Error; err != nil {
+				return err
+			}
+
+			err := tx.Model(&Occurrence{}).
+				Where("template_id = ? AND sequence = ?", occurrence.TemplateID, occurrence.Sequence).
+				Update("expense_id", expense.ID).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		handled = len(due)
+		return tx.Model(&template).Select("occurrences", "next_at").Updates(&template).Error
+	})
+
+	return handled, err
+}
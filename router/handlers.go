@@ -1,7 +1,29 @@
 package router
 
-import "github.com/tirathawat/assessment/expenses"
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/tirathawat/assessment/apikeys"
+	"github.com/tirathawat/assessment/attachments"
+	"github.com/tirathawat/assessment/budgets"
+	"github.com/tirathawat/assessment/expenses"
+	"github.com/tirathawat/assessment/groups"
+	"github.com/tirathawat/assessment/logs"
+	"github.com/tirathawat/assessment/rates"
+	"github.com/tirathawat/assessment/recurring"
+)
 
 type Handlers struct {
-	Expense expenses.Handler
+	Expense     expenses.Handler
+	Attachment  attachments.Handler
+	Group       groups.Handler
+	Budget      budgets.Handler
+	Recurring   recurring.Handler
+	Rate        rates.Handler
+	Key         apikeys.Handler
+	Log         logs.Handler
+	Idempotency gin.HandlerFunc
+	APIKey      gin.HandlerFunc
+	Auth        gin.HandlerFunc
+	User        gin.HandlerFunc
+	Language    gin.HandlerFunc
 }
@@ -6,11 +6,79 @@ import (
 )
 
 func Register(router *gin.Engine, h *Handlers) {
-	expenses := router.Group("/expenses").Use(middleware.Auth())
+	router.Use(h.Language)
+
+	expenses := router.Group("/expenses", h.APIKey, h.Auth, h.User)
+
+	read := expenses.Group("", middleware.RequireScope(middleware.ScopeRead))
+	{
+		read.GET("/trash", h.Expense.Trash)
+		read.GET("/summary", h.Expense.Summary)
+		read.GET("/export", h.Expense.Export)
+		read.GET("/:id", h.Expense.Get)
+		read.GET("/:id/history", h.Expense.History)
+		read.GET("/:id/attachments", h.Attachment.List)
+		read.GET("/:id/attachments/:attachment", h.Attachment.Download)
+		read.GET("/", h.Expense.List)
+	}
+
+	write := expenses.Group("", middleware.RequireScope(middleware.ScopeWrite))
+	{
+		write.POST("/", h.Idempotency, h.Expense.Create)
+		write.POST("/import", h.Idempotency, h.Expense.Import)
+		write.PUT("/:id", h.Expense.Update)
+		write.PATCH("/:id", h.Expense.Patch)
+		write.DELETE("/:id", h.Expense.Delete)
+		write.POST("/:id/restore", h.Expense.Restore)
+		write.POST("/:id/revert", h.Expense.Revert)
+		write.POST("/:id/attachments", h.Attachment.Upload)
+		write.DELETE("/:id/attachments/:attachment", h.Attachment.Delete)
+	}
+
+	groups := router.Group("/groups", h.APIKey, h.Auth, h.User)
+	{
+		groups.GET("/", middleware.RequireScope(middleware.ScopeRead), h.Group.List)
+		groups.GET("/:id", middleware.RequireScope(middleware.ScopeRead), h.Group.Get)
+		groups.GET("/:id/balances", middleware.RequireScope(middleware.ScopeRead), h.Group.Balances)
+		groups.POST("/", middleware.RequireScope(middleware.ScopeWrite), h.Group.Create)
+		groups.POST("/:id/members", middleware.RequireScope(middleware.ScopeWrite), h.Group.AddMember)
+		groups.POST("/:id/expenses", middleware.RequireScope(middleware.ScopeWrite), h.Idempotency, h.Group.AddExpense)
+	}
+
+	budgets := router.Group("/budgets", h.APIKey, h.Auth, h.User)
+	{
+		budgets.GET("/status", middleware.RequireScope(middleware.ScopeRead), h.Budget.Status)
+		budgets.GET("/", middleware.RequireScope(middleware.ScopeRead), h.Budget.List)
+		budgets.POST("/", middleware.RequireScope(middleware.ScopeWrite), h.Budget.Create)
+		budgets.PUT("/:id", middleware.RequireScope(middleware.ScopeWrite), h.Budget.Update)
+		budgets.DELETE("/:id", middleware.RequireScope(middleware.ScopeWrite), h.Budget.Delete)
+	}
+
+	recurring := router.Group("/recurring", h.APIKey, h.Auth, h.User)
+	{
+		recurring.GET("/", middleware.RequireScope(middleware.ScopeRead), h.Recurring.List)
+		recurring.GET("/:id", middleware.RequireScope(middleware.ScopeRead), h.Recurring.Get)
+		recurring.POST("/", middleware.RequireScope(middleware.ScopeWrite), h.Idempotency, h.Recurring.Create)
+		recurring.DELETE("/:id", middleware.RequireScope(middleware.ScopeWrite), h.Recurring.Delete)
+	}
+
+	rates := router.Group("/rates", h.APIKey, h.Auth, h.User)
+	{
+		rates.GET("/", middleware.RequireScope(middleware.ScopeRead), h.Rate.List)
+		rates.PUT("/", middleware.RequireScope(middleware.ScopeAdmin), h.Rate.Upsert)
+	}
+
+	keys := router.Group("/api-keys", h.APIKey, h.Auth, h.User, middleware.RequireScope(middleware.ScopeAdmin))
+	{
+		keys.POST("/", h.Key.Create)
+		keys.GET("/", h.Key.List)
+		keys.DELETE("/:id", h.Key.Revoke)
+	}
+
+	// Operators sign in with a token; API keys cannot reach these routes.
+	admin := router.Group("/admin", h.Auth, h.User, middleware.RequireScope(middleware.ScopeAdmin))
 	{
-		expenses.POST("/", h.Expense.Create)
-		expenses.GET("/:id", h.Expense.Get)
-		expenses.PUT("/:id", h.Expense.Update)
-		expenses.GET("/", h.Expense.List)
+		admin.GET("/log-level", h.Log.Level)
+		admin.PUT("/log-level", h.Log.SetLevel)
 	}
 }
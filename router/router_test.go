@@ -0,0 +1,242 @@
+//go:build unit
+// +build unit
+
+package router_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/tirathawat/assessment/apikeys"
+	"github.com/tirathawat/assessment/attachments"
+	"github.com/tirathawat/assessment/budgets"
+	"github.com/tirathawat/assessment/config"
+	"github.com/tirathawat/assessment/expenses"
+	"github.com/tirathawat/assessment/groups"
+	"github.com/tirathawat/assessment/logs"
+	"github.com/tirathawat/assessment/middleware"
+	"github.com/tirathawat/assessment/rates"
+	"github.com/tirathawat/assessment/recurring"
+	"github.com/tirathawat/assessment/router"
+	"github.com/tirathawat/assessment/users"
+)
+
+func next(c *gin.Context) {
+	c.Next()
+}
+
+// newRouter registers every route with auth in front of them. The handlers
+// have no stores, so only requests rejected before reaching a store can be
+// served.
+func newRouter(auth gin.HandlerFunc) *gin.Engine {
+	return newRouterWith(auth, next)
+}
+
+// newRouterWith is newRouter with apiKey standing in for the API key
+// middleware.
+func newRouterWith(auth, apiKey gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	cfg := &config.AppConfig{DefaultCurrency: "THB"}
+
+	r := gin.New()
+	router.Register(r, &router.Handlers{
+		Expense:     expenses.NewHandler(nil, cfg),
+		Attachment:  attachments.NewHandler(nil, nil, cfg),
+		Group:       groups.NewHandler(nil, cfg),
+		Budget:      budgets.NewHandler(nil, nil, cfg),
+		Recurring:   recurring.NewHandler(nil, cfg),
+		Rate:        rates.NewHandler(nil),
+		Key:         apikeys.NewHandler(nil),
+		Log:         logs.NewHandler(),
+		Idempotency: next,
+		APIKey:      apiKey,
+		Auth:        auth,
+		User:        asUser,
+		Language:    next,
+	})
+
+	return r
+}
+
+// asUser stands in for users.Middleware.
+func asUser(c *gin.Context) {
+	c.Set(users.IDKey, 1)
+	c.Next()
+}
+
+// grant authenticates every request with scopes.
+func grant(scopes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(middleware.SubjectKey, "user-1")
+		c.Set(middleware.ScopesKey, scopes)
+		c.Next()
+	}
+}
+
+func TestRatesRequireAdmin(t *testing.T) {
+	tests := []struct {
+		name           string
+		scopes         []string
+		method         string
+		wantStatusCode int
+	}{
+		{
+			name:           "Should return 403 when a writer changes rates",
+			scopes:         []string{middleware.ScopeRead, middleware.ScopeWrite},
+			method:         http.MethodPut,
+			wantStatusCode: http.StatusForbidden,
+		},
+		{
+			name:           "Should let an admin change rates",
+			scopes:         []string{middleware.ScopeAdmin},
+			method:         http.MethodPut,
+			wantStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := newRouter(grant(test.scopes...))
+
+			resp := httptest.NewRecorder()
+			r.ServeHTTP(resp, httptest.NewRequest(test.method, "/rates/", strings.NewReader("{")))
+
+			if resp.Code != test.wantStatusCode {
+				t.Errorf("unexpected status code: got %v want %v: %s", resp.Code, test.wantStatusCode, resp.Body)
+			}
+		})
+	}
+}
+
+const secret = "test-secret"
+
+type tokenClaims struct {
+	jwt.RegisteredClaims
+	Scope string `json:"scope,omitempty"`
+}
+
+func bearer(t *testing.T, subject, scope string) string {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, tokenClaims{
+		RegisteredClaims: jwt.RegisteredClaims{Subject: subject, ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))},
+		Scope:            scope,
+	}).SignedString([]byte(secret))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return "Bearer " + token
+}
+
+func TestAdminRoutes(t *testing.T) {
+	auth, err := middleware.Auth(&config.AppConfig{JWTSecret: secret, AdminSubjects: []string{"operator"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := newRouter(auth)
+
+	routes := []struct {
+		method string
+		path   string
+	}{
+		{http.MethodPut, "/rates/"},
+		{http.MethodPost, "/api-keys/"},
+	}
+
+	tests := []struct {
+		name           string
+		token          string
+		wantStatusCode int
+	}{
+		{
+			name:           "Should return 403 for a user signed in with a token",
+			token:          bearer(t, "user-1", ""),
+			wantStatusCode: http.StatusForbidden,
+		},
+		{
+			name:           "Should pass a token with the admin scope",
+			token:          bearer(t, "user-1", middleware.ScopeAdmin),
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:           "Should pass a configured admin subject",
+			token:          bearer(t, "operator", ""),
+			wantStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tests {
+		for _, route := range routes {
+			t.Run(test.name+" on "+route.method+" "+route.path, func(t *testing.T) {
+				request := httptest.NewRequest(route.method, route.path, strings.NewReader("{"))
+				request.Header.Set("Authorization", test.token)
+
+				resp := httptest.NewRecorder()
+				r.ServeHTTP(resp, request)
+
+				if resp.Code != test.wantStatusCode {
+					t.Errorf("unexpected status code: got %v want %v: %s", resp.Code, test.wantStatusCode, resp.Body)
+				}
+			})
+		}
+	}
+}
+
+func TestLogLevelRequiresOperator(t *testing.T) {
+	auth, err := middleware.Auth(&config.AppConfig{JWTSecret: secret, AdminSubjects: []string{"operator"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	apiKey := func(c *gin.Context) {
+		if c.GetHeader(apikeys.Header) != "" {
+			c.Set(middleware.SubjectKey, "operator")
+			c.Set(middleware.ScopesKey, []string{middleware.ScopeAdmin})
+		}
+		c.Next()
+	}
+
+	tests := []struct {
+		name           string
+		headers        map[string]string
+		wantStatusCode int
+	}{
+		{
+			name:           "Should return 403 for a user that is not an operator",
+			headers:        map[string]string{"Authorization": bearer(t, "user-1", "")},
+			wantStatusCode: http.StatusForbidden,
+		},
+		{
+			name:           "Should return 401 for an API key, even with the admin scope",
+			headers:        map[string]string{apikeys.Header: "exp_key"},
+			wantStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:           "Should pass an operator",
+			headers:        map[string]string{"Authorization": bearer(t, "operator", "")},
+			wantStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := newRouterWith(auth, apiKey)
+
+			request := httptest.NewRequest(http.MethodPut, "/admin/log-level", strings.NewReader("{"))
+			for key, value := range test.headers {
+				request.Header.Set(key, value)
+			}
+
+			resp := httptest.NewRecorder()
+			r.ServeHTTP(resp, request)
+
+			if resp.Code != test.wantStatusCode {
+				t.Errorf("unexpected status code: got %v want %v: %s", resp.Code, test.wantStatusCode, resp.Body)
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	_ "time/tzdata"
 
 	"github.com/tirathawat/assessment/di"
 	"github.com/tirathawat/assessment/logs"
@@ -9,6 +9,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/tirathawat/assessment/config"
 	"github.com/tirathawat/assessment/logs"
+	"github.com/tirathawat/assessment/middleware"
 	"github.com/tirathawat/assessment/router"
 )
 
@@ -18,13 +19,21 @@ type Server interface {
 	Port() string
 }
 
+// Job is a background task that runs for the lifetime of the server.
+type Job interface {
+	Start()
+	Stop()
+}
+
 type server struct {
 	*http.Server
 	port string
+	jobs []Job
 }
 
-func NewServer(cfg *config.AppConfig, handlers *router.Handlers) Server {
+func NewServer(cfg *config.AppConfig, handlers *router.Handlers, jobs ...Job) Server {
 	r := gin.Default()
+	r.Use(middleware.RequestID())
 	r.Use(gin.Logger())
 	r.Use(gin.Recovery())
 	r.Use(cors.Default())
@@ -39,10 +48,15 @@ func NewServer(cfg *config.AppConfig, handlers *router.Handlers) Server {
 	return &server{
 		Server: s,
 		port:   cfg.Port,
+		jobs:   jobs,
 	}
 }
 
 func (s *server) Run() {
+	for _, job := range s.jobs {
+		job.Start()
+	}
+
 	go func() {
 		if err := s.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			logs.Error().Err(err).Msg("Cannot initialize application")
@@ -54,7 +68,13 @@ func (s *server) Run() {
 func (s *server) Shutdown() error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
-	return s.Server.Shutdown(ctx)
+	err := s.Server.Shutdown(ctx)
+
+	for _, job := range s.jobs {
+		job.Stop()
+	}
+
+	return err
 }
 
 func (s *server) Port() string {
@@ -20,6 +20,7 @@ type HTTPRequest struct {
 	Endpoint string
 	Body     string
 	Token    string
+	Headers  map[string]string
 }
 
 func (r *HTTPRequest) MakeHTTPRequest(respBody any) (statusCode int, err error) {
@@ -33,6 +34,10 @@ func (r *HTTPRequest) MakeHTTPRequest(respBody any) (statusCode int, err error)
 		request.Header.Set(authorizationHeader, r.Token)
 	}
 
+	for key, value := range r.Headers {
+		request.Header.Set(key, value)
+	}
+
 	resp, err := (&http.Client{}).Do(request)
 	if err != nil {
 		return
@@ -55,18 +60,11 @@ func (r *HTTPRequest) MakeHTTPRequest(respBody any) (statusCode int, err error)
 }
 
 func (r *HTTPRequest) MakeTestHTTPRequest(HandlerFunc gin.HandlerFunc, respBody any, params ...gin.Param) (statusCode int, err error) {
-	request, err := http.NewRequest(r.Method, r.Endpoint, strings.NewReader(r.Body))
+	resp, err := r.MakeTestHTTPResponse(HandlerFunc, params...)
 	if err != nil {
 		return
 	}
 
-	resp := httptest.NewRecorder()
-	c, _ := gin.CreateTestContext(resp)
-	c.Request = request
-	c.Params = params
-
-	HandlerFunc(c)
-
 	statusCode = resp.Code
 	if statusCode != http.StatusOK && statusCode != http.StatusCreated {
 		return statusCode, nil
@@ -78,3 +76,26 @@ func (r *HTTPRequest) MakeTestHTTPRequest(HandlerFunc gin.HandlerFunc, respBody
 
 	return statusCode, nil
 }
+
+// MakeTestHTTPResponse runs the handler and returns the recorded response
+// for tests that need its headers or a body that is not JSON.
+func (r *HTTPRequest) MakeTestHTTPResponse(HandlerFunc gin.HandlerFunc, params ...gin.Param) (*httptest.ResponseRecorder, error) {
+	request, err := http.NewRequest(r.Method, r.Endpoint, strings.NewReader(r.Body))
+	if err != nil {
+		return nil, err
+	}
+
+	for key, value := range r.Headers {
+		request.Header.Set(key, value)
+	}
+
+	resp := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(resp)
+	c.Request = request
+	c.Params = params
+
+	HandlerFunc(c)
+	c.Writer.WriteHeaderNow()
+
+	return resp, nil
+}
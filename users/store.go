@@ -0,0 +1,35 @@
+package users
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Store interface {
+	FindOrCreate(subject string) (User, error)
+}
+
+type store struct {
+	db *gorm.DB
+}
+
+func NewStore(db *gorm.DB) Store {
+	return &store{db}
+}
+
+// FindOrCreate returns the user with the subject, creating it on first use.
+// The upsert makes concurrent first requests resolve to the same user.
+func (s *store) FindOrCreate(subject string) (User, error) {
+	var user User
+	err := s.db.Where("subject = ?", subject).Limit(1).Find(&user).Error
+	if err != nil || user.ID != 0 {
+		return user, err
+	}
+
+	user = User{Subject: subject}
+	err = s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "subject"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"subject": subject}),
+	}).Create(&user).Error
+	return user, err
+}
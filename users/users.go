@@ -0,0 +1,59 @@
+package users
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tirathawat/assessment/errs"
+	"github.com/tirathawat/assessment/logs"
+	"github.com/tirathawat/assessment/middleware"
+)
+
+var ErrLookupFailed = errs.New(http.StatusInternalServerError, "USER_LOOKUP_FAILED", "failed to look up user")
+
+// IDKey is the gin context key holding the ID of the authenticated user.
+const IDKey = "user_id"
+
+// User is an account known by the subject of its tokens. Users are created
+// the first time they authenticate.
+type User struct {
+	ID        int       `gorm:"primary_key" json:"id"`
+	Subject   string    `gorm:"type:text;not null;uniqueIndex" json:"subject"`
+	CreatedAt time.Time `gorm:"not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+}
+
+// Middleware resolves the subject stored by middleware.Auth to a user and
+// stores the user's ID in the context. It must run after Auth.
+func Middleware(store Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		subject := middleware.Subject(c)
+		if subject == "" {
+			logs.Error().Context(c.Request.Context()).Msg("unauthorized")
+			errs.Abort(c, http.StatusUnauthorized, middleware.ErrUnauthorized)
+			return
+		}
+
+		user, err := store.FindOrCreate(subject)
+		if err != nil {
+			logs.Error().Context(c.Request.Context()).Err(err).Msgf("failed to look up user: %s", subject)
+			errs.Abort(c, http.StatusInternalServerError, ErrLookupFailed)
+			return
+		}
+
+		c.Set(IDKey, user.ID)
+		c.Request = c.Request.WithContext(logs.WithValue(c.Request.Context(), logs.UserIDKey, user.ID))
+		c.Next()
+	}
+}
+
+// ID returns the ID stored by Middleware.
+func ID(c *gin.Context) (int, bool) {
+	id, ok := c.Get(IDKey)
+	if !ok {
+		return 0, false
+	}
+
+	userID, ok := id.(int)
+	return userID, ok && userID != 0
+}
@@ -0,0 +1,95 @@
+//go:build unit
+// +build unit
+
+package users_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tirathawat/assessment/middleware"
+	"github.com/tirathawat/assessment/users"
+)
+
+type MockStore struct {
+	users map[string]users.User
+	err   error
+}
+
+func (m *MockStore) FindOrCreate(subject string) (users.User, error) {
+	if m.err != nil {
+		return users.User{}, m.err
+	}
+
+	user, ok := m.users[subject]
+	if !ok {
+		user = users.User{ID: len(m.users) + 1, Subject: subject}
+		m.users[subject] = user
+	}
+
+	return user, nil
+}
+
+func TestMiddleware(t *testing.T) {
+	tests := []struct {
+		name           string
+		store          *MockStore
+		subjects       []string
+		wantStatusCode int
+		wantIDs        []int
+	}{
+		{
+			name:           "Should resolve the same subject to the same user",
+			store:          &MockStore{users: map[string]users.User{}},
+			subjects:       []string{"alice", "bob", "alice"},
+			wantStatusCode: http.StatusOK,
+			wantIDs:        []int{1, 2, 1},
+		},
+		{
+			name:           "Should return 401 when there is no subject",
+			store:          &MockStore{users: map[string]users.User{}},
+			subjects:       []string{""},
+			wantStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:           "Should return 500 when the store fails",
+			store:          &MockStore{err: errors.New("error")},
+			subjects:       []string{"alice"},
+			wantStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var ids []int
+			for _, subject := range test.subjects {
+				r := gin.New()
+				r.GET("/", func(c *gin.Context) {
+					if subject != "" {
+						c.Set(middleware.SubjectKey, subject)
+					}
+				}, users.Middleware(test.store), func(c *gin.Context) {
+					id, _ := users.ID(c)
+					ids = append(ids, id)
+					c.Status(http.StatusOK)
+				})
+
+				resp := httptest.NewRecorder()
+				r.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/", nil))
+				if resp.Code != test.wantStatusCode {
+					t.Errorf("unexpected status code: got %v want %v", resp.Code, test.wantStatusCode)
+				}
+			}
+
+			for i, id := range test.wantIDs {
+				if i >= len(ids) || ids[i] != id {
+					t.Errorf("unexpected user ids: got %v want %v", ids, test.wantIDs)
+					break
+				}
+			}
+		})
+	}
+}